@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
@@ -11,6 +13,8 @@ import (
 	scoreWorker "github.com/bitmark-inc/autonomy-api/background/score"
 	"github.com/bitmark-inc/autonomy-api/schema"
 	"github.com/bitmark-inc/autonomy-api/score"
+	"github.com/bitmark-inc/autonomy-api/store"
+	"github.com/bitmark-inc/autonomy-api/utils"
 )
 
 // accountRegister is the API for register a new account
@@ -48,7 +52,7 @@ func (s *Server) accountRegister(c *gin.Context) {
 		c.Error(err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"result": a.Profile,
 	})
 }
@@ -62,7 +66,7 @@ func (s *Server) accountDetail(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"result": account.Profile,
 	})
 }
@@ -85,24 +89,143 @@ func (s *Server) accountUpdateMetadata(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 }
 
-// accountDelete is the API to remove an account from our service
+// accountDeletionTimeout is how long a deletion in progress could be left without
+// completing a step. A stale deletion is resumed by the sweeper of any server.
+const accountDeletionTimeout = 10 * time.Minute
+
+// accountDelete is the API to remove an account from our service. The deletion
+// runs in background and its progress is reported by `accountDeletionStatusByID`.
+// If the server stops before the deletion finishes, it is resumed by the sweeper.
 func (s *Server) accountDelete(c *gin.Context) {
 	accountNumber := c.GetString("requester")
 
-	if err := s.store.DeleteAccount(accountNumber); err != nil {
-		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+	deletion, err := s.mongoStore.StartAccountDeletion(accountNumber)
+	if err != nil {
+		switch err {
+		case store.ErrAccountDeletionInProgress:
+			abortWithEncoding(c, http.StatusConflict, errorAccountDeleting)
+		default:
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
 		return
 	}
 
-	if err := s.mongoStore.DeleteAccount(accountNumber); err != nil {
-		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+	go func(d schema.AccountDeletion) {
+		if err := s.deleteAccount(d); err != nil {
+			log.WithError(err).WithField("account_number", d.AccountNumber).Error("fail to delete account")
+		}
+	}(*deletion)
+
+	responseWithEncoding(c, http.StatusAccepted, gin.H{
+		"result":      deletion,
+		"total_steps": len(schema.DeletionSteps),
+	})
+}
+
+// accountDeletionStatus is the API to query the progress of deleting an account
+func (s *Server) accountDeletionStatus(c *gin.Context) {
+	accountNumber := c.GetString("requester")
+
+	deletion, err := s.mongoStore.GetAccountDeletion(accountNumber)
+	if err != nil {
+		if err == store.ErrAccountDeletionNotFound {
+			abortWithEncoding(c, http.StatusNotFound, errorDeletionNotFound, err)
+		} else {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{
+		"result":      deletion,
+		"total_steps": len(schema.DeletionSteps),
+	})
+}
+
+// accountDeletionStatusByID is the API to query the progress of deleting an account by the
+// ID of the deletion. It does not require a token since tokens of the account are revoked
+// by the last step of the deletion.
+func (s *Server) accountDeletionStatusByID(c *gin.Context) {
+	deletion, err := s.mongoStore.GetAccountDeletionByID(c.Param("deletionID"))
+	if err != nil {
+		if err == store.ErrAccountDeletionNotFound {
+			abortWithEncoding(c, http.StatusNotFound, errorDeletionNotFound, err)
+		} else {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{
+		"result":      deletion,
+		"total_steps": len(schema.DeletionSteps),
+	})
+}
+
+// deleteAccount runs every step of removing an account. Steps completed in a
+// previous run are skipped, so a failed deletion could be resumed.
+func (s *Server) deleteAccount(d schema.AccountDeletion) error {
+	accountNumber := d.AccountNumber
+
+	steps := map[schema.DeletionStep]func() error{
+		schema.DeletionStepWorkflows: func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			return utils.CancelAccountWorkflows(*s.cadenceClient, ctx, accountNumber, d.PointsOfInterest)
+		},
+		schema.DeletionStepHelpRequests: func() error {
+			return s.store.DeleteAccountHelps(accountNumber)
+		},
+		schema.DeletionStepAccount: func() error {
+			return s.store.DeleteAccount(accountNumber)
+		},
+		schema.DeletionStepPOIRatings: func() error {
+			_, err := s.mongoStore.RemovePOIRatings(accountNumber)
+			return err
+		},
+		schema.DeletionStepReports: func() error {
+			return s.mongoStore.AnonymizeReports(d.ProfileID)
+		},
+		schema.DeletionStepScoreHistory: func() error {
+			return s.mongoStore.DeleteScoreHistory(accountNumber)
+		},
+		schema.DeletionStepLocationHistory: func() error {
+			_, err := s.mongoStore.DeleteLocationHistory(accountNumber)
+			return err
+		},
+		schema.DeletionStepExports: func() error {
+			return s.mongoStore.DeleteAccountExports(accountNumber)
+		},
+		schema.DeletionStepProfile: func() error {
+			return s.mongoStore.DeleteAccount(accountNumber)
+		},
+		schema.DeletionStepTokens: func() error {
+			return s.mongoStore.RevokeAccountTokens(accountNumber)
+		},
+	}
+
+	for _, step := range schema.DeletionSteps {
+		if d.IsStepCompleted(step) {
+			continue
+		}
+
+		if err := steps[step](); err != nil {
+			err = fmt.Errorf("%s: %s", step, err)
+			if finishErr := s.mongoStore.FinishAccountDeletion(accountNumber, err); finishErr != nil {
+				log.WithError(finishErr).Error("fail to update account deletion")
+			}
+			return err
+		}
+
+		if err := s.mongoStore.CompleteAccountDeletionStep(accountNumber, step); err != nil {
+			return err
+		}
+	}
+
+	return s.mongoStore.FinishAccountDeletion(accountNumber, nil)
 }
 
 // accountHere is an api to acking for an account
@@ -115,40 +238,37 @@ func (s *Server) getProfileFormula(c *gin.Context) {
 	var isDefaultFormula bool
 	accountNumber := c.GetString("requester")
 
-	var params struct {
-		Language string `form:"lang"`
-	}
+	lang := c.GetString("language")
 
-	if err := c.Bind(&params); err != nil {
-		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+	coefficient, err := s.mongoStore.GetProfileCoefficient(accountNumber)
+	if err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
 	}
 
-	lang := "en"
-	if params.Language != "" {
-		lang = params.Language
-	}
-
-	coefficient, err := s.mongoStore.GetProfileCoefficient(accountNumber)
+	symptoms, err := s.mongoStore.ListOfficialSymptoms(lang)
 	if err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
 	}
 
-	symptoms, err := s.mongoStore.ListOfficialSymptoms(lang)
+	behaviors, err := s.mongoStore.ListOfficialBehavior(lang)
 	if err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
 	}
 
+	formula := score.AccountFormula(accountNumber)
 	if coefficient == nil {
 		isDefaultFormula = true
-		coefficient = &schema.ScoreCoefficient{
-			Symptoms:       score.DefaultScoreV1SymptomCoefficient,
-			Behaviors:      score.DefaultScoreV1BehaviorCoefficient,
-			Confirms:       score.DefaultScoreV1ConfirmCoefficient,
-			SymptomWeights: schema.DefaultSymptomWeights,
-		}
+		defaultCoefficient := formula.DefaultCoefficient()
+		defaultCoefficient.SymptomWeights = schema.DefaultSymptomWeights
+		coefficient = &defaultCoefficient
+	}
+
+	// formulas customized before behavior weights were introduced use the default ones
+	if len(coefficient.BehaviorWeights) == 0 {
+		coefficient.BehaviorWeights = schema.DefaultBehaviorWeights()
 	}
 
 	type SymptomWeightsRepresentation struct {
@@ -168,17 +288,87 @@ func (s *Server) getProfileFormula(c *gin.Context) {
 
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"is_default": isDefaultFormula,
+	type BehaviorWeightsRepresentation struct {
+		Behavior schema.Behavior `json:"behavior"`
+		Weight   float64         `json:"weight"`
+	}
+
+	behaviorWeightsRepresentationList := make([]BehaviorWeightsRepresentation, 0)
+	for _, b := range behaviors {
+		if weight, ok := coefficient.BehaviorWeights[string(b.ID)]; ok {
+			behaviorWeightsRepresentationList = append(behaviorWeightsRepresentationList, BehaviorWeightsRepresentation{
+				Behavior: b,
+				Weight:   weight,
+			})
+		}
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{
+		"is_default":      isDefaultFormula,
+		"formula_version": formula.Version(),
 		"coefficient": map[string]interface{}{
-			"symptoms":        coefficient.Symptoms,
-			"behaviors":       coefficient.Behaviors,
-			"confirms":        coefficient.Confirms,
-			"symptom_weights": SymptomWeightsRepresentationList,
+			"symptoms":         coefficient.Symptoms,
+			"behaviors":        coefficient.Behaviors,
+			"confirms":         coefficient.Confirms,
+			"symptom_weights":  SymptomWeightsRepresentationList,
+			"behavior_weights": behaviorWeightsRepresentationList,
 		},
 	})
 }
 
+// validateBehaviorWeights checks customized weights of behaviors. Only official behaviors
+// could be weighted and the ones which are not given keep their default weights.
+func validateBehaviorWeights(weights schema.BehaviorWeights) (schema.BehaviorWeights, error) {
+	validated := schema.DefaultBehaviorWeights()
+
+	var total float64
+	for id, w := range weights {
+		if _, ok := validated[id]; !ok {
+			return nil, fmt.Errorf("unknown official behavior: %s", id)
+		}
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("invalid weight of behavior %s: %v", id, w)
+		}
+		validated[id] = w
+	}
+
+	for _, w := range validated {
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("weights of behaviors are all zero")
+	}
+
+	return validated, nil
+}
+
+// syncFormulaMetrics recalculates metrics of an account and its POIs by a coefficient.
+// Metrics are recalculated from raw metrics since the saved ones do not keep the
+// distributions of behaviors.
+func (s *Server) syncFormulaMetrics(accountNumber string, coefficient *schema.ScoreCoefficient) error {
+	profile, err := s.mongoStore.GetProfile(accountNumber)
+	if err != nil {
+		return err
+	}
+
+	if profile.Location != nil {
+		location := schema.Location{
+			Latitude:  profile.Location.Coordinates[1],
+			Longitude: profile.Location.Coordinates[0],
+		}
+		if _, err := s.mongoStore.SyncAccountMetrics(accountNumber, coefficient, location); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range profile.PointsOfInterest {
+		if _, err := s.mongoStore.SyncAccountPOIMetrics(accountNumber, coefficient, p.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // updateProfileFormula will update a customized formula submitted by a user
 func (s *Server) updateProfileFormula(c *gin.Context) {
 	accountNumber := c.GetString("requester")
@@ -192,47 +382,26 @@ func (s *Server) updateProfileFormula(c *gin.Context) {
 		return
 	}
 
-	params.Coefficient.UpdatedAt = time.Now().UTC()
-
-	if err := s.mongoStore.UpdateProfileCoefficient(accountNumber, params.Coefficient); err != nil {
-		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
-		return
-	}
-
-	profile, err := s.mongoStore.GetProfile(accountNumber)
+	behaviorWeights, err := validateBehaviorWeights(params.Coefficient.BehaviorWeights)
 	if err != nil {
-		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
 		return
 	}
 
-	profile.Metric.Score = score.TotalScoreV1(params.Coefficient,
-		profile.Metric.Details.Symptoms.Score,
-		profile.Metric.Details.Behaviors.Score,
-		profile.Metric.Details.Confirm.Score,
-	)
+	params.Coefficient.BehaviorWeights = behaviorWeights
+	params.Coefficient.UpdatedAt = time.Now().UTC()
 
-	if err := s.mongoStore.UpdateProfileMetric(accountNumber, profile.Metric); err != nil {
+	if err := s.mongoStore.UpdateProfileCoefficient(accountNumber, params.Coefficient); err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
 	}
 
-	for _, profilePOI := range profile.PointsOfInterest {
-		poi, err := s.mongoStore.GetPOI(profilePOI.ID)
-		if err != nil {
-			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
-			return
-		}
-
-		metric := poi.Metric
-		metric.Score = score.TotalScoreV1(params.Coefficient, metric.Details.Symptoms.Score, metric.Details.Behaviors.Score, metric.Details.Confirm.Score)
-
-		if err := s.mongoStore.UpdateProfilePOIMetric(profile.AccountNumber, poi.ID, metric); err != nil {
-			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
-			return
-		}
+	if err := s.syncFormulaMetrics(accountNumber, &params.Coefficient); err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 }
 
 // resetProfileFormula cleans up existing customized formula for a user
@@ -244,37 +413,10 @@ func (s *Server) resetProfileFormula(c *gin.Context) {
 		return
 	}
 
-	profile, err := s.mongoStore.GetProfile(accountNumber)
-	if err != nil {
-		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
-		return
-	}
-
-	profile.Metric.Score = score.DefaultTotalScore(
-		profile.Metric.Details.Symptoms.Score,
-		profile.Metric.Details.Behaviors.Score,
-		profile.Metric.Details.Confirm.Score,
-	)
-
-	if err := s.mongoStore.UpdateProfileMetric(accountNumber, profile.Metric); err != nil {
+	if err := s.syncFormulaMetrics(accountNumber, nil); err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
 	}
 
-	for _, profilePOI := range profile.PointsOfInterest {
-		poi, err := s.mongoStore.GetPOI(profilePOI.ID)
-		if err != nil {
-			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
-			return
-		}
-
-		metric := poi.Metric
-
-		if err := s.mongoStore.UpdateProfilePOIMetric(profile.AccountNumber, poi.ID, metric); err != nil {
-			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
-			return
-		}
-	}
-
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 }
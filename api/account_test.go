@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+func TestValidateBehaviorWeights(t *testing.T) {
+	weights, err := validateBehaviorWeights(schema.BehaviorWeights{"wear_mask": 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, weights["wear_mask"])
+	assert.Equal(t, 1.0, weights["clean_hand"])
+	assert.Len(t, weights, len(schema.DefaultBehaviorWeightMatrix))
+
+	weights, err = validateBehaviorWeights(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.DefaultBehaviorWeights(), weights)
+
+	_, err = validateBehaviorWeights(schema.BehaviorWeights{"new_behavior": 1})
+	assert.EqualError(t, err, "unknown official behavior: new_behavior")
+
+	_, err = validateBehaviorWeights(schema.BehaviorWeights{"wear_mask": -1})
+	assert.Error(t, err)
+
+	_, err = validateBehaviorWeights(schema.BehaviorWeights{
+		"clean_hand":        0,
+		"social_distancing": 0,
+		"touch_face":        0,
+		"wear_mask":         0,
+		"covering_coughs":   0,
+		"clean_surface":     0,
+	})
+	assert.EqualError(t, err, "weights of behaviors are all zero")
+}
+
+func TestUpdateProfileFormulaWithBehaviorWeights(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	poiID := primitive.NewObjectID()
+	location := schema.Location{Latitude: 25.03, Longitude: 121.56}
+
+	// saved metrics do not keep distributions of behaviors
+	profile := &schema.Profile{
+		AccountNumber: "account-formula",
+		Location:      &schema.GeoJSON{Type: "Point", Coordinates: []float64{location.Longitude, location.Latitude}},
+		Metric: schema.Metric{
+			Score:   20,
+			Details: schema.Details{Behaviors: schema.BehaviorDetail{Score: 20}},
+		},
+		PointsOfInterest: []schema.ProfilePOI{{ID: poiID}},
+	}
+
+	weighted := gomock.AssignableToTypeOf(&schema.ScoreCoefficient{})
+	assertWeights := func(coefficient *schema.ScoreCoefficient) {
+		assert.Equal(t, 0.0, coefficient.BehaviorWeights["wear_mask"])
+		assert.Equal(t, 1.0, coefficient.BehaviorWeights["clean_hand"])
+	}
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().
+		UpdateProfileCoefficient("account-formula", gomock.Any()).
+		DoAndReturn(func(accountNumber string, coefficient schema.ScoreCoefficient) error {
+			assertWeights(&coefficient)
+			return nil
+		})
+	mongoStore.EXPECT().GetProfile("account-formula").Return(profile, nil)
+
+	// metrics are recalculated from raw metrics by the submitted weights
+	mongoStore.EXPECT().
+		SyncAccountMetrics("account-formula", weighted, location).
+		DoAndReturn(func(accountNumber string, coefficient *schema.ScoreCoefficient, location schema.Location) (*schema.Metric, error) {
+			assertWeights(coefficient)
+			return &schema.Metric{}, nil
+		})
+	mongoStore.EXPECT().
+		SyncAccountPOIMetrics("account-formula", weighted, poiID).
+		DoAndReturn(func(accountNumber string, coefficient *schema.ScoreCoefficient, poiID primitive.ObjectID) (*schema.Metric, error) {
+			assertWeights(coefficient)
+			return &schema.Metric{}, nil
+		})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("requester", "account-formula")
+	})
+	r.PUT("/profile_formula", (&Server{mongoStore: mongoStore}).updateProfileFormula)
+	r.DELETE("/profile_formula", (&Server{mongoStore: mongoStore}).resetProfileFormula)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/profile_formula", bytes.NewBufferString(
+		`{"coefficient":{"symptoms":0,"behaviors":1,"confirms":0,"behavior_weights":{"wear_mask":0}}}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/profile_formula", bytes.NewBufferString(
+		`{"coefficient":{"behavior_weights":{"new_behavior":1}}}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the default formula is applied after a reset
+	mongoStore.EXPECT().ResetProfileCoefficient("account-formula").Return(nil)
+	mongoStore.EXPECT().GetProfile("account-formula").Return(profile, nil)
+	mongoStore.EXPECT().SyncAccountMetrics("account-formula", gomock.Nil(), location).Return(&schema.Metric{}, nil)
+	mongoStore.EXPECT().SyncAccountPOIMetrics("account-formula", gomock.Nil(), poiID).Return(&schema.Metric{}, nil)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/profile_formula", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAccountDeletionStatusByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().
+		GetAccountDeletionByID("deletion-id").
+		Return(&schema.AccountDeletion{ID: "deletion-id", AccountNumber: "account-deleted", Status: schema.DeletionCompleted}, nil)
+	mongoStore.EXPECT().
+		GetAccountDeletionByID("unknown").
+		Return(nil, store.ErrAccountDeletionNotFound)
+
+	// no account is recognized since tokens are revoked
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/accounts/deletions/:deletionID", (&Server{mongoStore: mongoStore}).accountDeletionStatusByID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/accounts/deletions/deletion-id", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"completed"`)
+	assert.NotContains(t, w.Body.String(), "account-deleted")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/accounts/deletions/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestResumeStaleAccountDeletions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	deletion := &schema.AccountDeletion{
+		AccountNumber:  "account-deleting",
+		Status:         schema.DeletionInProgress,
+		CompletedSteps: schema.DeletionSteps,
+	}
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	gomock.InOrder(
+		mongoStore.EXPECT().ClaimStaleAccountDeletion(now.Add(-accountDeletionTimeout)).Return(deletion, nil),
+		// steps completed before the server stopped are not run again
+		mongoStore.EXPECT().FinishAccountDeletion("account-deleting", nil).Return(nil),
+		mongoStore.EXPECT().ClaimStaleAccountDeletion(now.Add(-accountDeletionTimeout)).Return(nil, store.ErrAccountDeletionNotFound),
+	)
+
+	(&Server{mongoStore: mongoStore}).resumeStaleAccountDeletions(now)
+}
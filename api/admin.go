@@ -0,0 +1,228 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/autonomy-api/store"
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+// adminAuditMiddleware records every request made to the admin API
+func (s *Server) adminAuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		s.auditRequest(c, "admin", c.Request.Method+" "+c.FullPath(), c.Param("accountNumber"))
+	}
+}
+
+// adminAccountDetail returns both the account and the profile of an account number
+func (s *Server) adminAccountDetail(c *gin.Context) {
+	accountNumber := c.Param("accountNumber")
+
+	account, err := s.store.GetAccount(accountNumber)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			abortWithEncoding(c, http.StatusNotFound, errorAccountNotFound)
+		} else {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
+		return
+	}
+
+	profile, err := s.mongoStore.GetProfile(accountNumber)
+	if err != nil && err != mongo.ErrNoDocuments {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{
+		"account": account,
+		"profile": profile,
+	})
+}
+
+// adminAccountDelete removes an account which is not being deleted. Unlike the
+// account API, it waits for the deletion and could resume a failed one.
+func (s *Server) adminAccountDelete(c *gin.Context) {
+	deletion, err := s.mongoStore.StartAccountDeletion(c.Param("accountNumber"))
+	if err != nil {
+		switch err {
+		case store.ErrAccountDeletionInProgress:
+			abortWithEncoding(c, http.StatusConflict, errorAccountDeleting)
+		default:
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
+		return
+	}
+
+	if err := s.deleteAccount(*deletion); err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+// adminTriggerAccountUpdate re-triggers AccountStateUpdateWorkflow for an account
+func (s *Server) adminTriggerAccountUpdate(c *gin.Context) {
+	accountNumber := c.Param("accountNumber")
+
+	if err := utils.TriggerAccountUpdate(*s.cadenceClient, c, []string{accountNumber}); err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+// adminTriggerPOIUpdate re-triggers POIStateUpdateWorkflow for a POI
+func (s *Server) adminTriggerPOIUpdate(c *gin.Context) {
+	poiID, err := primitive.ObjectIDFromHex(c.Param("poiID"))
+	if err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("invalid POI ID"))
+		return
+	}
+
+	if err := utils.TriggerPOIUpdate(*s.cadenceClient, c, []primitive.ObjectID{poiID}); err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+type adminItemRequestBody struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+	Into string `json:"into"`
+}
+
+// adminUpdateSymptom edits a customized symptom
+func (s *Server) adminUpdateSymptom(c *gin.Context) {
+	var body adminItemRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if body.Name == "" {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("empty symptom name"))
+		return
+	}
+
+	if err := s.mongoStore.UpdateCustomizedSymptom(c.Param("symptomID"), body.Name, body.Desc); err != nil {
+		s.abortWithAdminItemError(c, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+// adminMergeSymptom merges a customized symptom into another symptom
+func (s *Server) adminMergeSymptom(c *gin.Context) {
+	var body adminItemRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if body.Into == "" || body.Into == c.Param("symptomID") {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("invalid merge target"))
+		return
+	}
+
+	if err := s.mongoStore.MergeCustomizedSymptom(c.Param("symptomID"), body.Into); err != nil {
+		s.abortWithAdminItemError(c, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+// adminUpdateBehavior edits a customized behavior
+func (s *Server) adminUpdateBehavior(c *gin.Context) {
+	var body adminItemRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if body.Name == "" {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("empty behavior name"))
+		return
+	}
+
+	if err := s.mongoStore.UpdateCustomizedBehavior(c.Param("behaviorID"), body.Name, body.Desc); err != nil {
+		s.abortWithAdminItemError(c, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+// adminMergeBehavior merges a customized behavior into another behavior
+func (s *Server) adminMergeBehavior(c *gin.Context) {
+	var body adminItemRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if body.Into == "" || body.Into == c.Param("behaviorID") {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("invalid merge target"))
+		return
+	}
+
+	if err := s.mongoStore.MergeCustomizedBehavior(c.Param("behaviorID"), body.Into); err != nil {
+		s.abortWithAdminItemError(c, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+func (s *Server) abortWithAdminItemError(c *gin.Context, err error) {
+	switch err {
+	case store.ErrSymptomNotFound:
+		abortWithEncoding(c, http.StatusNotFound, errorSymptomNotFound)
+	case store.ErrBehaviorNotFound:
+		abortWithEncoding(c, http.StatusNotFound, errorBehaviorNotFound)
+	default:
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+	}
+}
+
+// adminExpireHelps expires a specific help request if `helpID` is given.
+// Otherwise, it expires all pending help requests which are out of date.
+func (s *Server) adminExpireHelps(c *gin.Context) {
+	var params struct {
+		HelpID string `form:"help_id"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if params.HelpID != "" {
+		if err := s.store.ExpireHelp(params.HelpID); err != nil {
+			if err == store.ErrRequestNotExist {
+				abortWithEncoding(c, http.StatusNotFound, errorRequestNotExist)
+			} else {
+				abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			}
+			return
+		}
+	} else if err := s.store.ExpireHelps(); err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
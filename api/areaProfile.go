@@ -26,7 +26,6 @@ func (s *Server) autonomyProfile(c *gin.Context) {
 		POIID        string  `form:"poi_id"`
 		Latitude     float64 `form:"lat"`
 		Longitude    float64 `form:"lng"`
-		Language     string  `form:"lang"`
 		AllResources bool    `form:"all_resources"`
 	}
 
@@ -35,11 +34,6 @@ func (s *Server) autonomyProfile(c *gin.Context) {
 		return
 	}
 
-	if "" == params.Language {
-		params.Language = "en"
-	}
-
-	c.Set("language", params.Language)
 	c.Set("allResources", params.AllResources)
 
 	if params.Me {
@@ -102,7 +96,7 @@ func (s *Server) currentAreaProfile(c *gin.Context) {
 			// will sync with coefficient = profile.ScoreCoefficient
 		} else {
 			autonomyScore, autonomyScoreDelta := score.CalculateIndividualAutonomyScore(individualMetric, metric)
-			c.JSON(http.StatusOK, gin.H{
+			responseWithEncoding(c, http.StatusOK, gin.H{
 				"autonomy_score":       autonomyScore,
 				"autonomy_score_delta": autonomyScoreDelta,
 				"individual":           individualMetric,
@@ -122,7 +116,7 @@ func (s *Server) currentAreaProfile(c *gin.Context) {
 	}
 
 	autonomyScore, autonomyScoreDelta := score.CalculateIndividualAutonomyScore(individualMetric, metric)
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"autonomy_score":       autonomyScore,
 		"autonomy_score_delta": autonomyScoreDelta,
 		"individual":           individualMetric,
@@ -140,6 +134,7 @@ type placeProfileResponse struct {
 	HasMoreResource bool                       `json:"has_more_resources"`
 	Metric          schema.Metric              `json:"neighbor"`
 	Resources       []schema.POIResourceRating `json:"resources"`
+	Confidence      float64                    `json:"resource_confidence"`
 	Score           float64                    `json:"autonomy_score"`
 	ScoreDelta      float64                    `json:"autonomy_score_delta"`
 }
@@ -157,10 +152,14 @@ func summarizePlaceProfile(poi *schema.POI, profile *schema.Profile, language st
 		}
 	}
 
+	now := time.Now()
 	resources := poi.ResourceRatings.Resources
 	if len(resources) == 0 {
 		resources = []schema.POIResourceRating{}
 	} else {
+		resp.Confidence = score.ResourceConfidence(poi.PlaceType, resources, now)
+		score.SmoothResourceRatings(poi.PlaceType, resources, now)
+
 		sort.SliceStable(resources, func(i, j int) bool {
 			return resources[i].Ratings > resources[j].Ratings // Inverse sort
 		})
@@ -230,7 +229,7 @@ func (s *Server) placeProfile(c *gin.Context, id string, location *schema.Locati
 		}
 
 		resp := summarizePlaceProfile(poi, profile, language, allResources)
-		c.JSON(http.StatusOK, resp)
+		responseWithEncoding(c, http.StatusOK, resp)
 	} else if location != nil {
 		// Get POI resource by coordinates
 		poi, err := s.mongoStore.GetPOIByCoordinates(*location)
@@ -243,7 +242,7 @@ func (s *Server) placeProfile(c *gin.Context, id string, location *schema.Locati
 
 		if poi != nil {
 			resp := summarizePlaceProfile(poi, profile, language, allResources)
-			c.JSON(http.StatusOK, resp)
+			responseWithEncoding(c, http.StatusOK, resp)
 		} else {
 			// Collect profile by location if there is no poi meet the location
 			metric, err := s.mongoStore.CollectRawMetrics(*location)
@@ -251,8 +250,8 @@ func (s *Server) placeProfile(c *gin.Context, id string, location *schema.Locati
 				abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 				return
 			}
-			*metric = score.CalculateMetric(*metric, nil)
-			score, _, scoreDelta := score.CalculatePOIAutonomyScore(nil, *metric)
+			*metric = score.CalculateMetric(score.DefaultFormula(), *metric, nil)
+			score, _, scoreDelta := score.CalculatePOIAutonomyScore("", nil, *metric)
 
 			resp := placeProfileResponse{
 				Score:      score,
@@ -260,7 +259,7 @@ func (s *Server) placeProfile(c *gin.Context, id string, location *schema.Locati
 				Metric:     *metric,
 				Resources:  resources,
 			}
-			c.JSON(http.StatusOK, resp)
+			responseWithEncoding(c, http.StatusOK, resp)
 		}
 	} else {
 		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters)
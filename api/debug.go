@@ -82,7 +82,7 @@ func (s *Server) currentAreaDebugData(c *gin.Context) {
 		Symptoms: symptomCount,
 	}
 
-	c.JSON(http.StatusOK, debug)
+	responseWithEncoding(c, http.StatusOK, debug)
 }
 
 func (s *Server) poiDebugData(c *gin.Context) {
@@ -114,7 +114,7 @@ func (s *Server) poiDebugData(c *gin.Context) {
 		metricLastUpdate := time.Unix(metric.LastUpdate, 0)
 		if time.Since(metricLastUpdate) >= metricUpdateInterval {
 			m, err :=
-				s.mongoStore.SyncPOIMetrics(poiID, poi.ResourceRatings.Resources, loc)
+				s.mongoStore.SyncPOIMetrics(poiID, poi.PlaceType, poi.ResourceRatings.Resources, loc)
 			if err != nil {
 				c.Error(err)
 				abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
@@ -161,5 +161,5 @@ func (s *Server) poiDebugData(c *gin.Context) {
 		Symptoms: symptomCount,
 	}
 
-	c.JSON(http.StatusOK, debug)
+	responseWithEncoding(c, http.StatusOK, debug)
 }
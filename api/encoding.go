@@ -0,0 +1,251 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+const (
+	mimeJSON    = "application/json"
+	mimeMsgpack = "application/msgpack"
+
+	// responses smaller than this are not worth compressing
+	gzipMinLength = 1024
+)
+
+// acceptedValues parses a header like `Accept` or `Accept-Encoding` and returns
+// its values ordered by their quality. Values with `q=0` are dropped.
+func acceptedValues(header string) []string {
+	type value struct {
+		name    string
+		quality float64
+	}
+
+	values := make([]value, 0)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			values = append(values, value{name, quality})
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].quality > values[j].quality
+	})
+
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = v.name
+	}
+	return names
+}
+
+// negotiateContentType returns the most preferred response format in the `Accept` header.
+// JSON is used if none of the supported formats is acceptable.
+func negotiateContentType(accept string) string {
+	for _, v := range acceptedValues(accept) {
+		switch v {
+		case mimeMsgpack, "application/x-msgpack":
+			return mimeMsgpack
+		case mimeJSON, "application/*", "*/*":
+			return mimeJSON
+		}
+	}
+	return mimeJSON
+}
+
+// acceptsGzip tells if gzip is acceptable in the `Accept-Encoding` header
+func acceptsGzip(acceptEncoding string) bool {
+	for _, v := range acceptedValues(acceptEncoding) {
+		if v == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeBody marshals a response object into the given format. MessagePack bodies
+// are converted from JSON so that both formats share the same structure and keys.
+func encodeBody(contentType string, obj interface{}) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != mimeMsgpack {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf).UseCompactEncoding(true)
+	if err := encoder.Encode(msgpackValue(v, reflect.ValueOf(obj))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// msgpackValue converts a value decoded from the JSON of a typed value into the one encoded
+// in MessagePack. Numbers take the kinds of their typed values, so that a float is always
+// encoded as a float regardless of its value. Values marshaled by their own methods are
+// converted by `normalizeJSONNumbers`.
+func msgpackValue(v interface{}, typed reflect.Value) interface{} {
+	for typed.IsValid() && (typed.Kind() == reflect.Ptr || typed.Kind() == reflect.Interface) {
+		if typed.IsNil() || typed.Type().Implements(jsonMarshalerType) {
+			break
+		}
+		typed = typed.Elem()
+	}
+	if !typed.IsValid() || typed.Type().Implements(jsonMarshalerType) || reflect.PtrTo(typed.Type()).Implements(jsonMarshalerType) {
+		return normalizeJSONNumbers(v)
+	}
+
+	switch t := v.(type) {
+	case json.Number:
+		switch typed.Kind() {
+		case reflect.Float32, reflect.Float64:
+			f, _ := t.Float64()
+			return f
+		}
+		return normalizeJSONNumbers(t)
+	case map[string]interface{}:
+		switch typed.Kind() {
+		case reflect.Map:
+			for _, k := range typed.MapKeys() {
+				var key string
+				switch k.Kind() {
+				case reflect.String:
+					key = k.String()
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					key = strconv.FormatInt(k.Int(), 10)
+				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+					key = strconv.FormatUint(k.Uint(), 10)
+				default:
+					return normalizeJSONNumbers(t)
+				}
+				if item, ok := t[key]; ok {
+					t[key] = msgpackValue(item, typed.MapIndex(k))
+				}
+			}
+		case reflect.Struct:
+			jsonFields(typed, func(name string, field reflect.Value) {
+				if item, ok := t[name]; ok {
+					t[name] = msgpackValue(item, field)
+				}
+			})
+		default:
+			return normalizeJSONNumbers(t)
+		}
+	case []interface{}:
+		switch typed.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i, item := range t {
+				t[i] = msgpackValue(item, typed.Index(i))
+			}
+		default:
+			return normalizeJSONNumbers(t)
+		}
+	}
+	return v
+}
+
+// jsonFields calls `fn` with the JSON key and the value of every field of a struct, where
+// fields of embedded structs are taken as the ones of the struct like `encoding/json` does
+func jsonFields(v reflect.Value, fn func(name string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		field := v.Field(i)
+		if f.Anonymous && name == "" {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct {
+				jsonFields(field, fn)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fn(name, field)
+	}
+}
+
+// normalizeJSONNumbers turns json numbers into integers where possible, so that
+// they are encoded as integers instead of floats in MessagePack
+func normalizeJSONNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = normalizeJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = normalizeJSONNumbers(item)
+		}
+	}
+	return v
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+func encodingTestRouter(obj interface{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ok", func(c *gin.Context) {
+		responseWithEncoding(c, http.StatusOK, obj)
+	})
+	r.GET("/error", func(c *gin.Context) {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters)
+	})
+	return r
+}
+
+func encodingTestRequest(r *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAcceptedValues(t *testing.T) {
+	assert.Equal(t, []string{"application/msgpack", "application/json"},
+		acceptedValues("application/json;q=0.5, application/msgpack"))
+	assert.Equal(t, []string{"br"}, acceptedValues("gzip;q=0, br"))
+	assert.Empty(t, acceptedValues(""))
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	assert.Equal(t, mimeJSON, negotiateContentType(""))
+	assert.Equal(t, mimeJSON, negotiateContentType("*/*"))
+	assert.Equal(t, mimeJSON, negotiateContentType("text/html"))
+	assert.Equal(t, mimeMsgpack, negotiateContentType("application/msgpack"))
+	assert.Equal(t, mimeMsgpack, negotiateContentType("application/x-msgpack, application/json;q=0.8"))
+	assert.Equal(t, mimeJSON, negotiateContentType("application/json, application/msgpack;q=0.8"))
+}
+
+func TestResponseWithEncodingJSON(t *testing.T) {
+	r := encodingTestRouter(gin.H{"result": gin.H{"score": 87.5, "count": 3}})
+
+	w := encodingTestRequest(r, "/ok", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"result":{"score":87.5,"count":3}}`, w.Body.String())
+}
+
+func TestResponseWithEncodingMsgpack(t *testing.T) {
+	r := encodingTestRouter(gin.H{"result": gin.H{"score": 87.5, "count": 3, "names": []string{"a", "b"}}})
+
+	w := encodingTestRequest(r, "/ok", map[string]string{"Accept": "application/msgpack"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, mimeMsgpack, w.Header().Get("Content-Type"))
+
+	var body map[string]map[string]interface{}
+	assert.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 87.5, body["result"]["score"])
+	assert.EqualValues(t, 3, body["result"]["count"])
+	assert.Equal(t, []interface{}{"a", "b"}, body["result"]["names"])
+}
+
+func TestResponseWithEncodingMsgpackNumberTypes(t *testing.T) {
+	type detail struct {
+		Weight float64 `json:"weight"`
+	}
+	type metric struct {
+		detail
+		Score   float64            `json:"score"`
+		Count   int                `json:"count"`
+		Weights map[string]float64 `json:"weights"`
+		Details []detail           `json:"details"`
+		Hidden  float64            `json:"-"`
+	}
+	r := encodingTestRouter(gin.H{"result": &metric{
+		detail:  detail{Weight: 1},
+		Score:   80,
+		Count:   3,
+		Weights: map[string]float64{"fever": 2},
+		Details: []detail{{Weight: 4}},
+	}})
+
+	w := encodingTestRequest(r, "/ok", map[string]string{"Accept": "application/msgpack"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// floats of integral values are still floats
+	var body map[string]map[string]interface{}
+	assert.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 80.0, body["result"]["score"])
+	assert.Equal(t, 1.0, body["result"]["weight"])
+	assert.Equal(t, map[string]interface{}{"fever": 2.0}, body["result"]["weights"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"weight": 4.0}}, body["result"]["details"])
+	assert.IsType(t, int8(0), body["result"]["count"])
+	assert.NotContains(t, body["result"], "Hidden")
+}
+
+func TestResponseWithEncodingGzip(t *testing.T) {
+	large := gin.H{"result": strings.Repeat("autonomy", gzipMinLength)}
+	r := encodingTestRouter(large)
+
+	w := encodingTestRequest(r, "/ok", map[string]string{"Accept-Encoding": "gzip, deflate"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+
+	var body map[string]string
+	assert.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, large["result"], body["result"])
+
+	// small responses are not compressed
+	r = encodingTestRouter(gin.H{"result": "OK"})
+	w = encodingTestRequest(r, "/ok", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"result":"OK"}`, w.Body.String())
+}
+
+func TestResponseWithEncodingMsgpackGzip(t *testing.T) {
+	large := gin.H{"result": strings.Repeat("autonomy", gzipMinLength)}
+	r := encodingTestRouter(large)
+
+	w := encodingTestRequest(r, "/ok", map[string]string{
+		"Accept":          "application/msgpack",
+		"Accept-Encoding": "gzip",
+	})
+	assert.Equal(t, mimeMsgpack, w.Header().Get("Content-Type"))
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+
+	var body map[string]string
+	assert.NoError(t, msgpack.Unmarshal(data, &body))
+	assert.Equal(t, large["result"], body["result"])
+}
+
+func TestAbortWithEncoding(t *testing.T) {
+	r := encodingTestRouter(nil)
+
+	w := encodingTestRequest(r, "/error", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":{"code":1010,"message":"invalid parameters"}}`, w.Body.String())
+
+	w = encodingTestRequest(r, "/error", map[string]string{"Accept": "application/msgpack"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, mimeMsgpack, w.Header().Get("Content-Type"))
+
+	var body map[string]map[string]interface{}
+	assert.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &body))
+	assert.EqualValues(t, 1010, body["error"]["code"])
+	assert.Equal(t, "invalid parameters", body["error"]["message"])
+}
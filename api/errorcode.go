@@ -9,13 +9,17 @@ var (
 		1001: "invalid authorization format",
 		1002: "difference between the request time and the current time is too large",
 		1003: "invalid token",
+		1004: "token has been revoked",
+		1005: "invalid service credential",
 
 		1006: "invalid value of client version",
 		1007: "API for this client version has been discontinued",
 		1008: "resource is not supported",
+		1009: "service is not permitted to access this route or account",
 
 		1010: "invalid parameters",
 		1011: "cannot parse request",
+		1012: "too many requests",
 
 		1100: "his account has been registered or has been taken",
 		1101: "account not found",
@@ -25,13 +29,20 @@ var (
 		1105: "update score error",
 		1106: "unknown POI",
 		1107: "no POI in the profile",
+		1108: store.ErrAccountExportNotFound.Error(),
+		1109: "export is not ready",
+		1110: "no encryption public key for the account",
+		1111: store.ErrAccountDeletionNotFound.Error(),
 
 		1200: store.ErrRequestNotExist.Error(),
 		1201: store.ErrMultipleRequestMade.Error(),
 
 		1300: store.ErrPOIListNotFound.Error(),
 		1301: store.ErrPOIListMismatch.Error(),
-		1302: store.ErrEmptyPOIResourceName.Error(),
+		1303: store.ErrEmptyPOIResourceName.Error(),
+
+		1400: store.ErrSymptomNotFound.Error(),
+		1401: store.ErrBehaviorNotFound.Error(),
 	}
 
 	errorInternalServer             = errorJSON(999)
@@ -39,12 +50,16 @@ var (
 	errorInvalidAuthorizationFormat = errorJSON(1001)
 	errorRequestTimeTooSkewed       = errorJSON(1002)
 	errorInvalidToken               = errorJSON(1003)
+	errorTokenRevoked               = errorJSON(1004)
+	errorInvalidServiceCredential   = errorJSON(1005)
 	errorInvalidClientVersion       = errorJSON(1006)
 	errorUnsupportedClientVersion   = errorJSON(1007)
 	errorResourceNotSupport         = errorJSON(1008)
+	errorServiceNotPermitted        = errorJSON(1009)
 
 	errorInvalidParameters  = errorJSON(1010)
 	errorCannotParseRequest = errorJSON(1011)
+	errorTooManyRequests    = errorJSON(1012)
 
 	errorAccountTaken           = errorJSON(1100)
 	errorAccountNotFound        = errorJSON(1101)
@@ -54,6 +69,10 @@ var (
 	errorUpdateScore            = errorJSON(1105)
 	errorUnknownPOI             = errorJSON(1106)
 	errorNoPOIInProfile         = errorJSON(1107)
+	errorExportNotFound         = errorJSON(1108)
+	errorExportNotReady         = errorJSON(1109)
+	errorNoEncryptionKey        = errorJSON(1110)
+	errorDeletionNotFound       = errorJSON(1111)
 
 	errorRequestNotExist     = errorJSON(1200)
 	errorMultipleRequestMade = errorJSON(1201)
@@ -61,6 +80,9 @@ var (
 	errorPOIListNotFound      = errorJSON(1300)
 	errorPOIListMissmatch     = errorJSON(1301)
 	errorEmptyPOIResourceName = errorJSON(1303)
+
+	errorSymptomNotFound  = errorJSON(1400)
+	errorBehaviorNotFound = errorJSON(1401)
 )
 
 type ErrorResponse struct {
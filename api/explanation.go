@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
+	"github.com/bitmark-inc/autonomy-api/store"
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+// score changes less than this are told as unchanged
+const explanationMinimumDelta = 0.05
+
+// scoreExplanation explains the autonomy score of the requester or a POI. Scores are
+// explained by the saved metrics, so an explanation always matches the score shown by
+// the autonomy profile.
+func (s *Server) scoreExplanation(c *gin.Context) {
+	var params struct {
+		Me    bool   `form:"me"`
+		POIID string `form:"poi_id"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	var explanation score.Explanation
+	if params.Me {
+		account, ok := c.MustGet("account").(*schema.Account)
+		if !ok {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
+			return
+		}
+
+		profile, err := s.mongoStore.GetProfile(account.AccountNumber)
+		if err != nil {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			return
+		}
+
+		formula := score.LookupFormula(profile.Metric.FormulaVersion)
+		explanation = score.ExplainIndividualScore(formula, profile.IndividualMetric, profile.Metric, profile.ScoreCoefficient)
+	} else if params.POIID != "" {
+		poiID, err := primitive.ObjectIDFromHex(params.POIID)
+		if err != nil {
+			abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("invalid POI ID"))
+			return
+		}
+
+		poi, err := s.mongoStore.GetPOI(poiID)
+		if err != nil {
+			switch err {
+			case store.ErrPOINotFound:
+				abortWithEncoding(c, http.StatusBadRequest, errorUnknownPOI)
+			default:
+				abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			}
+			return
+		}
+
+		formula := score.LookupFormula(poi.Metric.FormulaVersion)
+		explanation = score.ExplainPOIScore(formula, poi.PlaceType, poi.ResourceRatings.Resources, poi.Metric)
+	} else {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters)
+		return
+	}
+
+	s.localizeExplanation(&explanation, utils.NewLocalizer(c.GetString("language")))
+	responseWithEncoding(c, http.StatusOK, explanation)
+}
+
+// localizeExplanation fills names of components and drivers and the summary of an explanation
+func (s *Server) localizeExplanation(e *score.Explanation, localizer *i18n.Localizer) {
+	componentName := func(id string) string {
+		name, _ := localizer.Localize(&i18n.LocalizeConfig{MessageID: fmt.Sprintf("explanation.components.%s.name", id)})
+		return name
+	}
+
+	for i := range e.Components {
+		e.Components[i].Name = componentName(e.Components[i].ID)
+	}
+	for i := range e.Neighborhood {
+		e.Neighborhood[i].Name = componentName(e.Neighborhood[i].ID)
+	}
+
+	for i, d := range e.Symptoms {
+		name, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: fmt.Sprintf("symptoms.%s.name", d.ID)})
+		if err != nil {
+			if symptoms, _ := s.mongoStore.FindSymptomsByIDs([]string{d.ID}); len(symptoms) == 1 {
+				name = symptoms[0].Name
+			}
+		}
+		e.Symptoms[i].Name = name
+	}
+
+	for i, d := range e.Behaviors {
+		name, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: fmt.Sprintf("behaviors.%s.name", d.ID)})
+		if err != nil {
+			if behaviors, _ := s.mongoStore.FindBehaviorsByIDs([]string{d.ID}); len(behaviors) == 1 {
+				name = behaviors[0].Name
+			}
+		}
+		e.Behaviors[i].Name = name
+	}
+
+	messageID := "explanation.summary.unchanged"
+	templateData := map[string]interface{}{}
+	if main := e.MainComponent(); main != nil && math.Abs(e.ScoreDelta) >= explanationMinimumDelta {
+		messageID = "explanation.summary.increase"
+		if e.ScoreDelta < 0 {
+			messageID = "explanation.summary.decrease"
+		}
+		templateData["Delta"] = fmt.Sprintf("%.1f", math.Abs(e.ScoreDelta))
+		templateData["Component"] = componentName(main.ID)
+	}
+
+	e.Summary, _ = localizer.Localize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		TemplateData: templateData,
+	})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
+	"github.com/bitmark-inc/autonomy-api/store"
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+func TestScoreExplanation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gin.SetMode(gin.TestMode)
+	viper.Set("i18n.dir", "../i18n")
+	utils.InitI18NBundle()
+
+	account := &schema.Account{AccountNumber: "account-explanation"}
+	profile := &schema.Profile{
+		AccountNumber:    account.AccountNumber,
+		IndividualMetric: schema.IndividualMetric{Score: 80, ScoreYesterday: 80},
+		Metric: schema.Metric{
+			Score:          25,
+			ScoreYesterday: 50,
+			FormulaVersion: score.FormulaVersionV1,
+			Details: schema.Details{
+				Symptoms: schema.SymptomDetail{
+					TodayData: schema.NearestSymptomData{
+						WeightDistribution: map[string]int{"fever": 2, "custom_symptom": 1},
+					},
+				},
+				Behaviors: schema.BehaviorDetail{
+					YesterdayDistribution: map[string]int{"clean_hand": 1},
+				},
+				Confirm: schema.ConfirmDetail{Score: 50, ScoreYesterday: 100},
+			},
+		},
+	}
+
+	// counts of symptoms are read from the saved metric
+	saved, err := bson.Marshal(profile)
+	assert.NoError(t, err)
+	profile = &schema.Profile{}
+	assert.NoError(t, bson.Unmarshal(saved, profile))
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().GetProfile(account.AccountNumber).Return(profile, nil)
+	mongoStore.EXPECT().
+		FindSymptomsByIDs([]string{"custom_symptom"}).
+		Return([]schema.Symptom{{ID: "custom_symptom", Name: "Custom symptom"}}, nil)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("account", account)
+		c.Set("language", "en")
+	})
+	r.GET("/explanation", (&Server{mongoStore: mongoStore}).scoreExplanation)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/explanation?me=true", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var explanation score.Explanation
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &explanation))
+	assert.Equal(t, score.FormulaVersionV1, explanation.FormulaVersion)
+	assert.Equal(t, "your neighborhood", explanation.Components[1].Name)
+	assert.Equal(t, "confirmed cases nearby", explanation.Neighborhood[2].Name)
+	assert.Equal(t, "The score fell 6.8% since yesterday, mostly because of confirmed cases nearby.", explanation.Summary)
+	assert.Equal(t, "Fever", explanation.Symptoms[0].Name)
+	assert.Equal(t, "Custom symptom", explanation.Symptoms[1].Name)
+	assert.Equal(t, []score.Driver{{ID: "clean_hand", Name: "Frequent hand cleaning", Yesterday: 1, Delta: -1}}, explanation.Behaviors)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/explanation", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/explanation?poi_id=unknown", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	poiID := primitive.NewObjectID()
+	mongoStore.EXPECT().GetPOI(poiID).Return(nil, store.ErrPOINotFound)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/explanation?poi_id="+poiID.Hex(), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "1106")
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bitmark-inc/bitmark-sdk-go/account"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+// accountExportTimeout is how long an export is allowed to be built. An export in progress
+// for longer is considered failed, e.g. the server was restarted while building it.
+const accountExportTimeout = 30 * time.Minute
+
+// latestAccountExport returns the latest export of an account after failing the stale ones
+func (s *Server) latestAccountExport(accountNumber string) (*schema.AccountExport, error) {
+	if err := s.mongoStore.FailStaleAccountExports(accountNumber, time.Now().Add(-accountExportTimeout)); err != nil {
+		return nil, err
+	}
+
+	return s.mongoStore.GetLatestAccountExport(accountNumber)
+}
+
+// accountPrepareExport is the API to request an export of all personal data of an account.
+// The archive is built in background and clients poll `accountExportStatus` until it is completed.
+func (s *Server) accountPrepareExport(c *gin.Context) {
+	a := c.MustGet("account")
+	account, ok := a.(*schema.Account)
+	if !ok {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
+		return
+	}
+
+	if account.EncPubKey == "" {
+		abortWithEncoding(c, http.StatusBadRequest, errorNoEncryptionKey)
+		return
+	}
+
+	latest, err := s.latestAccountExport(account.AccountNumber)
+	if err != nil && err != store.ErrAccountExportNotFound {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	// an export in progress is reused instead of building another one
+	if latest != nil && (latest.Status == schema.ExportPending || latest.Status == schema.ExportProcessing) {
+		responseWithEncoding(c, http.StatusAccepted, gin.H{"result": latest})
+		return
+	}
+
+	export, err := s.mongoStore.CreateAccountExport(account.AccountNumber)
+	if err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	go s.buildAccountExport(*account, export.ID)
+
+	responseWithEncoding(c, http.StatusAccepted, gin.H{"result": export})
+}
+
+// accountExportStatus is the API to query the latest export of an account
+func (s *Server) accountExportStatus(c *gin.Context) {
+	accountNumber := c.GetString("requester")
+
+	export, err := s.latestAccountExport(accountNumber)
+	if err != nil {
+		if err == store.ErrAccountExportNotFound {
+			abortWithEncoding(c, http.StatusNotFound, errorExportNotFound, err)
+		} else {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": export})
+}
+
+// accountDownloadExport is the API to download the encrypted archive of the latest export
+func (s *Server) accountDownloadExport(c *gin.Context) {
+	accountNumber := c.GetString("requester")
+
+	export, err := s.latestAccountExport(accountNumber)
+	if err != nil {
+		if err == store.ErrAccountExportNotFound {
+			abortWithEncoding(c, http.StatusNotFound, errorExportNotFound, err)
+		} else {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
+		return
+	}
+
+	if export.Status != schema.ExportCompleted {
+		abortWithEncoding(c, http.StatusConflict, errorExportNotReady)
+		return
+	}
+
+	archive, err := s.mongoStore.OpenAccountExportArchive(export.ID)
+	if err != nil {
+		if err == store.ErrAccountExportNotFound {
+			abortWithEncoding(c, http.StatusNotFound, errorExportNotFound, err)
+		} else {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
+		return
+	}
+	defer archive.Close()
+
+	c.DataFromReader(http.StatusOK, export.ArchiveSize, "application/octet-stream", archive, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="autonomy-export-%s.zip.enc"`, export.CreatedAt.Format("20060102")),
+	})
+}
+
+// buildAccountExport collects personal data from both databases, packs them into
+// a zip archive and encrypts the archive to the encryption public key of the account.
+func (s *Server) buildAccountExport(a schema.Account, exportID string) {
+	logger := log.WithField("account_number", a.AccountNumber)
+
+	if err := s.mongoStore.UpdateAccountExport(exportID, schema.ExportProcessing); err != nil {
+		logger.WithError(err).Error("fail to update export status")
+		return
+	}
+
+	archive, err := s.accountExportArchive(a)
+	if err == nil {
+		err = s.mongoStore.CompleteAccountExport(exportID, archive)
+	}
+	if err != nil {
+		logger.WithError(err).Error("fail to build export archive")
+		if err := s.mongoStore.UpdateAccountExport(exportID, schema.ExportFailed); err != nil {
+			logger.WithError(err).Error("fail to update export status")
+		}
+		return
+	}
+
+	logger.WithField("export_id", exportID).Info("export completed")
+}
+
+func (s *Server) accountExportArchive(a schema.Account) ([]byte, error) {
+	helps, err := s.store.ListAccountHelps(a.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.mongoStore.ExportAccountDocuments(a.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]interface{}{
+		"account.json":       a,
+		"help_requests.json": helps,
+	}
+	for name, docs := range documents {
+		files[name+".json"] = docs
+	}
+
+	data, err := zipJSONFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptExport(s.bitmarkAccount.EncrKey, a.EncPubKey, data)
+}
+
+// zipJSONFiles packs each value as an indented json file into a zip archive
+func zipJSONFiles(files map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	for name, value := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encryptExport encrypts data to a hex encoded peer public key. Clients decrypt it
+// with their private key and the `enc_pub_key` published in the information API.
+func encryptExport(key account.EncrKey, peerPublicKey string, data []byte) ([]byte, error) {
+	if peerPublicKey == "" {
+		return nil, fmt.Errorf("no encryption public key")
+	}
+
+	publicKey, err := hex.DecodeString(peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.Encrypt(data, publicKey)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bitmark-inc/bitmark-sdk-go/account"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+func TestExportArchiveRoundTrip(t *testing.T) {
+	serverKey, err := account.NewEncrKey(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	userKey, err := account.NewEncrKey(bytes.Repeat([]byte{2}, 32))
+	assert.NoError(t, err)
+
+	data, err := zipJSONFiles(map[string]interface{}{
+		"account.json": map[string]string{"account_number": "account-a"},
+	})
+	assert.NoError(t, err)
+
+	ciphertext, err := encryptExport(serverKey, hex.EncodeToString(userKey.PublicKeyBytes()), data)
+	assert.NoError(t, err)
+
+	plaintext, err := userKey.Decrypt(ciphertext, serverKey.PublicKeyBytes())
+	assert.NoError(t, err)
+
+	r, err := zip.NewReader(bytes.NewReader(plaintext), int64(len(plaintext)))
+	assert.NoError(t, err)
+	assert.Len(t, r.File, 1)
+	assert.Equal(t, "account.json", r.File[0].Name)
+
+	f, err := r.File[0].Open()
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+
+	var a map[string]string
+	assert.NoError(t, json.Unmarshal(content, &a))
+	assert.Equal(t, "account-a", a["account_number"])
+}
+
+func TestEncryptExportWithoutKey(t *testing.T) {
+	serverKey, err := account.NewEncrKey(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+
+	_, err = encryptExport(serverKey, "", []byte("data"))
+	assert.Error(t, err)
+
+	_, err = encryptExport(serverKey, "not-hex", []byte("data"))
+	assert.Error(t, err)
+}
+
+func TestAccountDownloadExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	archive := []byte("encrypted archive")
+	export := schema.AccountExport{
+		ID:          "export-a",
+		Status:      schema.ExportCompleted,
+		ArchiveSize: int64(len(archive)),
+		CreatedAt:   time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().FailStaleAccountExports("account-a", gomock.Any()).DoAndReturn(func(_ string, before time.Time) error {
+		// exports in progress for longer than the timeout are failed
+		assert.WithinDuration(t, time.Now().Add(-accountExportTimeout), before, time.Minute)
+		return nil
+	})
+	mongoStore.EXPECT().GetLatestAccountExport("account-a").Return(&export, nil)
+	mongoStore.EXPECT().OpenAccountExportArchive("export-a").Return(ioutil.NopCloser(bytes.NewReader(archive)), nil)
+
+	s := &Server{mongoStore: mongoStore}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/accounts/me/export/download", func(c *gin.Context) {
+		c.Set("requester", "account-a")
+	}, s.accountDownloadExport)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/accounts/me/export/download", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, archive, w.Body.Bytes())
+	assert.Equal(t, `attachment; filename="autonomy-export-20200601.zip.enc"`, w.Header().Get("Content-Disposition"))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,7 +33,8 @@ func parseGeoPosition(geoPosition string) (float64, float64, error) {
 }
 
 // updateGeoPositionMiddleware is a middleware to store geo-position for every
-// api requests from users
+// api requests from users. The position is also appended into the location
+// history if the user opts in.
 func (s *Server) updateGeoPositionMiddleware(c *gin.Context) {
 	gp := c.GetHeader("Geo-Position")
 	accountNumber := c.GetString("requester")
@@ -41,6 +43,8 @@ func (s *Server) updateGeoPositionMiddleware(c *gin.Context) {
 		if lat, long, err := parseGeoPosition(gp); err == nil {
 			if err := s.store.UpdateAccountGeoPosition(accountNumber, lat, long); err != nil {
 				c.Error(err)
+			} else if err := s.recordLocationHistory(accountNumber, lat, long, time.Now().UTC()); err != nil {
+				c.Error(err)
 			}
 		} else {
 			c.Error(err)
@@ -32,7 +32,7 @@ func (s *Server) createBehavior(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"id": id,
 	})
 	return
@@ -46,19 +46,7 @@ func (s *Server) goodBehaviors(c *gin.Context) {
 		return
 	}
 
-	var params struct {
-		Language string `form:"lang"`
-	}
-
-	if err := c.Bind(&params); err != nil {
-		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
-		return
-	}
-
-	lang := "en"
-	if params.Language != "" {
-		lang = params.Language
-	}
+	lang := c.GetString("language")
 
 	var loc *schema.Location
 	loc = account.Profile.State.LastLocation
@@ -82,15 +70,14 @@ func (s *Server) goodBehaviors(c *gin.Context) {
 		behaviors = append(behaviors, nonOfficialBehaviors...)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"behaviors": behaviors})
+	responseWithEncoding(c, http.StatusOK, gin.H{"behaviors": behaviors})
 }
 
 func (s *Server) getBehaviorsV2(c *gin.Context) {
 	a := c.MustGet("account")
 
 	var params struct {
-		Language string `form:"lang"`
-		All      bool   `form:"all"`
+		All bool `form:"all"`
 	}
 
 	if err := c.Bind(&params); err != nil {
@@ -98,10 +85,7 @@ func (s *Server) getBehaviorsV2(c *gin.Context) {
 		return
 	}
 
-	lang := "en"
-	if params.Language != "" {
-		lang = params.Language
-	}
+	lang := c.GetString("language")
 
 	account, ok := a.(*schema.Account)
 	if !ok {
@@ -128,7 +112,7 @@ func (s *Server) getBehaviorsV2(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		responseWithEncoding(c, http.StatusOK, gin.H{
 			"official_behaviors":   official,
 			"customized_behaviors": customized,
 		})
@@ -141,7 +125,7 @@ func (s *Server) getBehaviorsV2(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"official_behaviors":     official,
 		"neighborhood_behaviors": customized,
 	})
@@ -154,6 +138,28 @@ func (s *Server) reportBehaviors(c *gin.Context) {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
 		return
 	}
+
+	key, err := idempotencyKey(c)
+	if err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	windowStart := idempotencyWindowStart(time.Now())
+	if key != "" {
+		report, err := s.mongoStore.FindBehaviorReportByIdempotencyKey(account.Profile.ID.String(), key, windowStart)
+		if err != nil {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			return
+		}
+
+		// a repeat submission gets the original response without saving or triggering anything
+		if report != nil {
+			responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+			return
+		}
+	}
+
 	var loc *schema.Location
 	loc = account.Profile.State.LastLocation
 	if nil == loc {
@@ -181,19 +187,26 @@ func (s *Server) reportBehaviors(c *gin.Context) {
 	}
 
 	data := schema.BehaviorReportData{
-		ProfileID:     account.Profile.ID.String(),
-		AccountNumber: account.Profile.AccountNumber,
-		Behaviors:     behaviors,
-		Location:      schema.GeoJSON{Type: "Point", Coordinates: []float64{loc.Longitude, loc.Latitude}},
-		Timestamp:     time.Now().UTC().Unix(),
+		ProfileID:      account.Profile.ID.String(),
+		AccountNumber:  account.Profile.AccountNumber,
+		Behaviors:      behaviors,
+		Location:       schema.GeoJSON{Type: "Point", Coordinates: []float64{loc.Longitude, loc.Latitude}},
+		Timestamp:      time.Now().UTC().Unix(),
+		IdempotencyKey: key,
 	}
 
-	err = s.mongoStore.GoodBehaviorSave(&data)
+	original, err := s.mongoStore.GoodBehaviorSave(&data, windowStart)
 	if err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
 		return
 	}
 
+	// a concurrent repeat submission is only found when saving it
+	if original != nil {
+		responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+		return
+	}
+
 	if _, err := s.mongoStore.SyncProfileIndividualMetrics(account.Profile.ID.String()); err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
@@ -231,6 +244,6 @@ func (s *Server) reportBehaviors(c *gin.Context) {
 	} else {
 		c.Error(err)
 	}
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 	return
 }
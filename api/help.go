@@ -10,6 +10,8 @@ import (
 	"github.com/bitmark-inc/autonomy-api/store"
 )
 
+const defaultHelpLimit = int64(10)
+
 // askForHelp is the API for asking help from others
 func (s *Server) askForHelp(c *gin.Context) {
 	requester := c.GetString("requester")
@@ -62,7 +64,7 @@ func (s *Server) askForHelp(c *gin.Context) {
 	}
 
 	// TODO: broadcast a notification to surrounding users
-	c.JSON(http.StatusOK, req)
+	responseWithEncoding(c, http.StatusOK, req)
 	return
 }
 
@@ -95,18 +97,42 @@ func (s *Server) queryHelps(c *gin.Context) {
 			return
 		}
 
-		helps, err := s.store.ListHelps(p.AccountNumber, p.State.LastLocation.Latitude, p.State.LastLocation.Longitude, 10)
+		var params pageParams
+		if err := c.BindQuery(&params); err != nil {
+			abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+			return
+		}
+
+		// clients before pagination list every help without a cursor or a limit
+		var cursor store.Cursor
+		var limit int64
+		if params.Cursor != "" || params.Limit != 0 {
+			var err error
+			if cursor, limit, err = params.parse(defaultHelpLimit); err != nil {
+				abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+				return
+			}
+		}
+
+		helps, err := s.store.ListHelps(p.AccountNumber, p.State.LastLocation.Latitude, p.State.LastLocation.Longitude, cursor, limit)
 		if err != nil {
+			if err == store.ErrInvalidCursor {
+				abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+				return
+			}
 			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 			return
 		}
 
 		result = gin.H{
 			"result": helps,
+			"next_cursor": nextCursor(len(helps), limit, func() store.Cursor {
+				return store.Cursor{ID: helps[len(helps)-1].ID.String()}
+			}),
 		}
 	}
 
-	c.JSON(http.StatusOK, result)
+	responseWithEncoding(c, http.StatusOK, result)
 	return
 }
 
@@ -126,5 +152,5 @@ func (s *Server) answerHelp(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
 )
 
 const (
@@ -18,11 +19,12 @@ const (
 )
 
 type historyQueryParams struct {
-	Before   int64  `form:"before"`
-	Limit    int64  `form:"limit"`
-	Language string `form:"lang"`
+	pageParams
+	Before int64 `form:"before"`
 }
 
+// getHistory is the API to list reports of the requester from the latest one. Pages are
+// requested by `cursor`, while `before` is kept for clients which page by timestamps.
 func (s *Server) getHistory(c *gin.Context) {
 	account, ok := c.MustGet("account").(*schema.Account)
 	if !ok {
@@ -36,50 +38,64 @@ func (s *Server) getHistory(c *gin.Context) {
 		return
 	}
 
-	var before, limit int64
-
-	switch {
-	case params.Before > 0:
-		before = params.Before
-	case params.Before == 0:
-		before = time.Now().UTC().Unix()
-	default:
-		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("negative before"))
+	cursor, limit, err := params.parse(defaultLimit)
+	if err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
 		return
 	}
 
-	switch {
-	case params.Limit > 0:
-		limit = params.Limit
-	case params.Limit == 0:
-		limit = defaultLimit
-	default:
-		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("negative limit"))
-		return
+	if params.Cursor == "" {
+		switch {
+		case params.Before > 0:
+			cursor = store.Cursor{Time: time.Unix(params.Before, 0).UTC()}
+		case params.Before == 0:
+			cursor = store.Cursor{Time: time.Now().UTC()}
+		default:
+			abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("negative before"))
+			return
+		}
 	}
 
-	lang := "en"
-	if params.Language != "" {
-		lang = params.Language
-	}
+	lang := c.GetString("language")
 
 	switch c.Param("reportType") {
 	case reportTypeSymptoms:
-		records, err := s.mongoStore.GetReportedSymptoms(account.AccountNumber, before, limit, lang)
+		records, err := s.mongoStore.GetReportedSymptoms(account.AccountNumber, cursor, limit, lang)
 		if err != nil {
-			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			abortWithHistoryError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"symptoms_history": records})
+		responseWithEncoding(c, http.StatusOK, gin.H{
+			"symptoms_history": records,
+			"next_cursor": nextCursor(len(records), limit, func() store.Cursor {
+				last := records[len(records)-1]
+				return store.Cursor{Time: time.Unix(last.Timestamp, 0).UTC(), ID: last.ID.Hex()}
+			}),
+		})
 	case reportTypeBehaviors:
-		records, err := s.mongoStore.GetReportedBehaviors(account.AccountNumber, before, limit, lang)
+		records, err := s.mongoStore.GetReportedBehaviors(account.AccountNumber, cursor, limit, lang)
 		if err != nil {
-			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			abortWithHistoryError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"behaviors_history": records})
+
+		responseWithEncoding(c, http.StatusOK, gin.H{
+			"behaviors_history": records,
+			"next_cursor": nextCursor(len(records), limit, func() store.Cursor {
+				last := records[len(records)-1]
+				return store.Cursor{Time: time.Unix(last.Timestamp, 0).UTC(), ID: last.ID.Hex()}
+			}),
+		})
 	default:
 		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters)
 	}
 }
+
+func abortWithHistoryError(c *gin.Context, err error) {
+	if err == store.ErrInvalidCursor {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+	abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+}
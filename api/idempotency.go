@@ -0,0 +1,46 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	maxIdempotencyKeyLength  = 255
+	defaultIdempotencyWindow = 24 * time.Hour
+)
+
+// idempotencyKey reads the idempotency key of a request. The key is empty if the
+// client does not send one.
+func idempotencyKey(c *gin.Context) (string, error) {
+	key := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if len(key) > maxIdempotencyKeyLength {
+		return "", fmt.Errorf("idempotency key is longer than %d", maxIdempotencyKeyLength)
+	}
+	return key, nil
+}
+
+// idempotencyWindowStart returns the earliest report time that a repeat submission
+// is matched against
+func idempotencyWindowStart(now time.Time) int64 {
+	window := viper.GetDuration("report.idempotency_window")
+	if window <= 0 {
+		window = defaultIdempotencyWindow
+	}
+	return now.Add(-window).Unix()
+}
+
+// reportedLocation converts the location of a report back to a location
+func reportedLocation(g schema.GeoJSON) schema.Location {
+	return schema.Location{
+		Longitude: g.Coordinates[0],
+		Latitude:  g.Coordinates[1],
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+var idempotencyTestAccount = &schema.Account{
+	AccountNumber: "account-idempotency",
+	Profile: schema.AccountProfile{
+		ID:            uuid.MustParse("c1d6f6d5-4ac4-4b4a-9a38-4d6c51b2f0a1"),
+		AccountNumber: "account-idempotency",
+	},
+}
+
+func idempotencyTestRouter(s *Server) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("account", idempotencyTestAccount)
+	})
+	r.POST("/symptoms", s.reportSymptoms)
+	r.POST("/behaviors", s.reportBehaviors)
+	return r
+}
+
+func TestReportSymptomsRepeatedSubmission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	centers := []schema.NearbyTestCenter{{Name: "test center"}}
+
+	mongoStore.EXPECT().
+		FindSymptomReportByIdempotencyKey(idempotencyTestAccount.Profile.ID.String(), "key-1", gomock.Any()).
+		Return(&schema.SymptomReportData{
+			Symptoms: []schema.Symptom{{ID: "cough"}, {ID: "customized"}},
+			Location: schema.GeoJSON{Type: "Point", Coordinates: []float64{121.5, 25.0}},
+		}, nil)
+	mongoStore.EXPECT().
+		NearbyTestCenter(schema.Location{Longitude: 121.5, Latitude: 25.0}, int64(10)).
+		Return(centers, nil)
+
+	// neither SymptomReportSave nor any workflow trigger is expected
+	r := idempotencyTestRouter(&Server{mongoStore: mongoStore})
+	req := httptest.NewRequest("POST", "/symptoms", bytes.NewBufferString(`{"symptoms":["cough"]}`))
+	req.Header.Set(idempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Official int                       `json:"official"`
+		Guide    []schema.NearbyTestCenter `json:"guide"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 1, body.Official)
+	assert.Equal(t, centers, body.Guide)
+}
+
+func TestReportBehaviorsRepeatedSubmission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().
+		FindBehaviorReportByIdempotencyKey(idempotencyTestAccount.Profile.ID.String(), "key-2", gomock.Any()).
+		Return(&schema.BehaviorReportData{}, nil)
+
+	r := idempotencyTestRouter(&Server{mongoStore: mongoStore})
+	req := httptest.NewRequest("POST", "/behaviors", bytes.NewBufferString(`{"behaviors":["clean_hand"]}`))
+	req.Header.Set(idempotencyKeyHeader, "key-2")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"result":"OK"}`, w.Body.String())
+}
+
+func TestReportBehaviorsConcurrentRepeatedSubmission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	account := *idempotencyTestAccount
+	account.Profile.State.LastLocation = &schema.Location{Longitude: 121.5, Latitude: 25.0}
+	profileID := account.Profile.ID.String()
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().FindBehaviorReportByIdempotencyKey(profileID, "key-3", gomock.Any()).Return(nil, nil)
+	mongoStore.EXPECT().FindBehaviorsByIDs([]string{"clean_hand"}).Return([]schema.Behavior{{ID: "clean_hand"}}, nil)
+	// another submission with the same key is saved in the meantime
+	mongoStore.EXPECT().
+		GoodBehaviorSave(gomock.Any(), gomock.Any()).
+		Return(&schema.BehaviorReportData{ProfileID: profileID, IdempotencyKey: "key-3"}, nil)
+
+	// neither metrics nor any workflow is updated for the repeat submission
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/behaviors", func(c *gin.Context) {
+		c.Set("account", &account)
+	}, (&Server{mongoStore: mongoStore}).reportBehaviors)
+
+	req := httptest.NewRequest("POST", "/behaviors", bytes.NewBufferString(`{"behaviors":["clean_hand"]}`))
+	req.Header.Set(idempotencyKeyHeader, "key-3")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"result":"OK"}`, w.Body.String())
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := idempotencyTestRouter(&Server{mongoStore: mocks.NewMockMongoStore(ctrl)})
+	req := httptest.NewRequest("POST", "/behaviors", bytes.NewBufferString(`{"behaviors":["clean_hand"]}`))
+	req.Header.Set(idempotencyKeyHeader, strings.Repeat("k", maxIdempotencyKeyLength+1))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+// languageMiddleware resolves the language of a request into `language` of the context.
+// The query param `lang` overrides the header `Accept-Language`. Unsupported languages
+// are resolved along fallback chains of the locale list, e.g. zh-Hant-TW to zh_tw. The
+// header `Content-Language` is the language tag of the resolved language, e.g. zh-TW.
+func languageMiddleware(c *gin.Context) {
+	lang := utils.ResolveLanguage(c.Query("lang"), c.GetHeader("Accept-Language"))
+	c.Set("language", lang)
+	c.Header("Content-Language", utils.LanguageTag(lang))
+	c.Next()
+}
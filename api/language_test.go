@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+func TestLanguageMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("i18n.dir", "../i18n")
+	utils.InitI18NBundle()
+
+	r := gin.New()
+	r.Use(languageMiddleware)
+	r.GET("/api/lang", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("language"))
+	})
+
+	request := func(query, acceptLanguage string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/lang"+query, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("", "")
+	assert.Equal(t, "en", w.Body.String())
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+
+	w = request("", "zh-Hant-TW,zh;q=0.9,en;q=0.8")
+	assert.Equal(t, "zh_tw", w.Body.String())
+	assert.Equal(t, "zh-TW", w.Header().Get("Content-Language"))
+
+	// the query param overrides the header
+	assert.Equal(t, "zh_cn", request("?lang=zh-Hans", "zh-TW").Body.String())
+	assert.Equal(t, "en", request("?lang=en", "zh-TW").Body.String())
+
+	// an unsupported query param falls back to the header
+	assert.Equal(t, "zh_tw", request("?lang=fr", "zh-TW").Body.String())
+}
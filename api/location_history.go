@@ -0,0 +1,150 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const (
+	defaultLocationHistoryRetention   = 30 * 24 * time.Hour
+	defaultLocationHistoryMinInterval = 5 * time.Minute
+)
+
+type locationHistoryPoint struct {
+	ID         string  `json:"id"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	RecordedAt int64   `json:"recorded_at"`
+	ExpiresAt  int64   `json:"expires_at"`
+}
+
+// locationHistoryRetention returns how long a point of a trail is kept
+func locationHistoryRetention() time.Duration {
+	retention := viper.GetDuration("location_history.retention")
+	if retention <= 0 {
+		retention = defaultLocationHistoryRetention
+	}
+	return retention
+}
+
+// locationHistoryMinInterval returns the minimal interval between points if an account stays still
+func locationHistoryMinInterval() time.Duration {
+	interval := viper.GetDuration("location_history.min_interval")
+	if interval <= 0 {
+		interval = defaultLocationHistoryMinInterval
+	}
+	return interval
+}
+
+// recordLocationHistory appends a geo-position into the trail of an account which opts in
+func (s *Server) recordLocationHistory(accountNumber string, lat, long float64, now time.Time) error {
+	_, err := s.mongoStore.AppendLocationHistory(schema.LocationHistory{
+		AccountNumber: accountNumber,
+		Location:      schema.GeoJSON{Type: "Point", Coordinates: []float64{long, lat}},
+		RecordedAt:    now,
+		ExpiresAt:     now.Add(locationHistoryRetention()),
+	}, locationHistoryMinInterval())
+	return err
+}
+
+// getLocationHistory is the API to view the trail of the requester
+func (s *Server) getLocationHistory(c *gin.Context) {
+	accountNumber := c.GetString("requester")
+
+	var params struct {
+		Before int64 `form:"before"`
+		Limit  int64 `form:"limit"`
+	}
+	if err := c.BindQuery(&params); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if params.Before < 0 || params.Limit < 0 {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("negative before or limit"))
+		return
+	}
+
+	before := time.Now().UTC()
+	if params.Before > 0 {
+		before = time.Unix(params.Before, 0).UTC()
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	profile, err := s.mongoStore.GetProfile(accountNumber)
+	if err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	records, err := s.mongoStore.GetLocationHistory(accountNumber, before, limit)
+	if err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	points := make([]locationHistoryPoint, 0, len(records))
+	for _, r := range records {
+		points = append(points, locationHistoryPoint{
+			ID:         r.ID.Hex(),
+			Latitude:   r.Location.Coordinates[1],
+			Longitude:  r.Location.Coordinates[0],
+			RecordedAt: r.RecordedAt.Unix(),
+			ExpiresAt:  r.ExpiresAt.Unix(),
+		})
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{
+		"enabled": profile.LocationHistoryEnabled,
+		"result":  points,
+	})
+}
+
+// updateLocationHistorySetting is the API to opt in or out the recording of the trail.
+// Points recorded before opting out are kept until they expire or are deleted.
+func (s *Server) updateLocationHistorySetting(c *gin.Context) {
+	accountNumber := c.GetString("requester")
+
+	var params struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.BindJSON(&params); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if params.Enabled == nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, fmt.Errorf("enabled not provided"))
+		return
+	}
+
+	if err := s.mongoStore.SetLocationHistoryEnabled(accountNumber, *params.Enabled); err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
+}
+
+// deleteLocationHistory is the API to delete the whole trail of the requester
+func (s *Server) deleteLocationHistory(c *gin.Context) {
+	accountNumber := c.GetString("requester")
+
+	deleted, err := s.mongoStore.DeleteLocationHistory(accountNumber)
+	if err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"deleted": deleted})
+}
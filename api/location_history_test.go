@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+func locationHistoryTestRouter(s *Server) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("requester", "account-location-history")
+	})
+	r.GET("/location_history", s.getLocationHistory)
+	r.PUT("/location_history", s.updateLocationHistorySetting)
+	r.DELETE("/location_history", s.deleteLocationHistory)
+	return r
+}
+
+func TestGetLocationHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recordedAt := time.Date(2020, 6, 1, 8, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().
+		GetProfile("account-location-history").
+		Return(&schema.Profile{LocationHistoryEnabled: true}, nil)
+	mongoStore.EXPECT().
+		GetLocationHistory("account-location-history", time.Unix(1591000000, 0).UTC(), int64(10)).
+		Return([]schema.LocationHistory{
+			{
+				ID:         id,
+				Location:   schema.GeoJSON{Type: "Point", Coordinates: []float64{121.5, 25.0}},
+				RecordedAt: recordedAt,
+				ExpiresAt:  recordedAt.Add(defaultLocationHistoryRetention),
+			},
+		}, nil)
+
+	r := locationHistoryTestRouter(&Server{mongoStore: mongoStore})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/location_history?before=1591000000&limit=10", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Enabled bool                   `json:"enabled"`
+		Result  []locationHistoryPoint `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.True(t, body.Enabled)
+	assert.Equal(t, []locationHistoryPoint{
+		{
+			ID:         id.Hex(),
+			Latitude:   25.0,
+			Longitude:  121.5,
+			RecordedAt: recordedAt.Unix(),
+			ExpiresAt:  recordedAt.Add(defaultLocationHistoryRetention).Unix(),
+		},
+	}, body.Result)
+}
+
+func TestUpdateLocationHistorySetting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().SetLocationHistoryEnabled("account-location-history", false).Return(nil)
+
+	r := locationHistoryTestRouter(&Server{mongoStore: mongoStore})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/location_history", bytes.NewBufferString(`{"enabled":false}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the setting should be given explicitly
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/location_history", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteLocationHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().DeleteLocationHistory("account-location-history").Return(int64(3), nil)
+
+	r := locationHistoryTestRouter(&Server{mongoStore: mongoStore})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/location_history", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"deleted":3}`, w.Body.String())
+}
+
+func TestRecordLocationHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2020, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().
+		AppendLocationHistory(schema.LocationHistory{
+			AccountNumber: "account-location-history",
+			Location:      schema.GeoJSON{Type: "Point", Coordinates: []float64{121.5, 25.0}},
+			RecordedAt:    now,
+			ExpiresAt:     now.Add(defaultLocationHistoryRetention),
+		}, defaultLocationHistoryMinInterval).
+		Return(true, nil)
+
+	s := &Server{mongoStore: mongoStore}
+	assert.NoError(t, s.recordLocationHistory("account-location-history", 25.0, 121.5, now))
+}
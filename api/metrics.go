@@ -45,7 +45,7 @@ func (s *Server) getSymptomMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"me": gin.H{
 			"total_today": meToday,
 			"delta":       score.ChangeRate(float64(meToday), float64(meYesterday)),
@@ -91,7 +91,7 @@ func (s *Server) getBehaviorMetrics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"me": gin.H{
 			"total_today": meToday,
 			"delta":       score.ChangeRate(float64(meToday), float64(meYesterday)),
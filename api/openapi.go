@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// loadOpenAPIRouter loads the OpenAPI document configured by `openapi.file`.
+// Requests are not validated if the document is not configured.
+func loadOpenAPIRouter() *openapi3filter.Router {
+	file := viper.GetString("openapi.file")
+	if file == "" {
+		log.Warn("openapi document is not configured, requests will not be validated")
+		return nil
+	}
+
+	router, err := newOpenAPIRouter(file)
+	if err != nil {
+		log.WithError(err).WithField("file", file).Panic("fail to load openapi document")
+	}
+
+	return router
+}
+
+func newOpenAPIRouter(file string) (*openapi3filter.Router, error) {
+	swagger, err := openapi3.NewSwaggerLoader().LoadSwaggerFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	router := openapi3filter.NewRouter()
+	if err := router.AddSwagger(swagger); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// openAPIValidator is a middleware to validate path parameters, query parameters and
+// request bodies against the OpenAPI document. Headers and credentials are left to
+// `clientVersionGateway` and the authentication middlewares which respond their own
+// error codes. Requests to routes which are not in the document are passed through.
+func (s *Server) openAPIValidator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.openAPIRouter == nil {
+			c.Next()
+			return
+		}
+
+		route, pathParams, err := s.openAPIRouter.FindRoute(c.Request.Method, c.Request.URL)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		// the body is read by the validator and then the handler
+		var body []byte
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body, err = ioutil.ReadAll(c.Request.Body)
+			if err != nil {
+				abortWithEncoding(c, http.StatusBadRequest, errorCannotParseRequest, err)
+				return
+			}
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		req := c.Request.Clone(c)
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		// `BindJSON` decodes a body regardless of its content type
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		if err := validateOpenAPIRequest(&openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &openapi3filter.Options{},
+		}); err != nil {
+			abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// validateOpenAPIRequest validates parameters other than headers and the body of a request
+func validateOpenAPIRequest(input *openapi3filter.RequestValidationInput) error {
+	operation := input.Route.Operation
+
+	parameters := make([]*openapi3.Parameter, 0)
+	for _, p := range input.Route.PathItem.Parameters {
+		if operation.Parameters.GetByInAndName(p.Value.In, p.Value.Name) == nil {
+			parameters = append(parameters, p.Value)
+		}
+	}
+	for _, p := range operation.Parameters {
+		parameters = append(parameters, p.Value)
+	}
+
+	for _, p := range parameters {
+		if p.In == openapi3.ParameterInHeader || p.In == openapi3.ParameterInCookie {
+			continue
+		}
+		if err := openapi3filter.ValidateParameter(input.Request.Context(), input, p); err != nil {
+			return err
+		}
+	}
+
+	if operation.RequestBody != nil {
+		return openapi3filter.ValidateRequestBody(input.Request.Context(), input, operation.RequestBody.Value)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const openAPIDocument = "../openapi/openapi.yaml"
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPIDocumentCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	swagger, err := openapi3.NewSwaggerLoader().LoadSwaggerFromFile(openAPIDocument)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, route := range (&Server{}).setupRouter().Routes() {
+		path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+
+		pathItem, ok := swagger.Paths[path]
+		if !assert.True(t, ok, "path %s is not in the openapi document", path) {
+			continue
+		}
+		assert.NotNil(t, pathItem.GetOperation(route.Method), "%s %s is not in the openapi document", route.Method, path)
+	}
+}
+
+func TestOpenAPIDocumentCoversErrorCodes(t *testing.T) {
+	swagger, err := openapi3.NewSwaggerLoader().LoadSwaggerFromFile(openAPIDocument)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	codes := swagger.Components.Schemas["ErrorResponse"].Value.Properties["code"].Value
+	documented := make(map[int64]bool)
+	for _, v := range codes.Enum {
+		documented[int64(v.(float64))] = true
+	}
+
+	for code, message := range errorMessageMap {
+		assert.True(t, documented[code], "error code %d is not in the openapi document", code)
+		assert.True(t, strings.Contains(codes.Description, message), "message of error code %d is not in the openapi document", code)
+	}
+}
+
+func TestOpenAPIValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router, err := newOpenAPIRouter(openAPIDocument)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := &Server{openAPIRouter: router}
+	r := gin.New()
+	r.Use(s.openAPIValidator())
+
+	var received string
+	handler := func(c *gin.Context) {
+		var body json.RawMessage
+		c.ShouldBindJSON(&body)
+		received = string(body)
+		c.Status(http.StatusNoContent)
+	}
+	r.POST("/api/reports/sync", handler)
+	r.GET("/api/history/:reportType", handler)
+	r.GET("/api/autonomy_profile", handler)
+	r.GET("/not-documented", handler)
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{"POST", "/api/reports/sync", `{"reports":[{"type":"symptom","items":["fever"],"timestamp":1590000000,"location":{"latitude":25.04,"longitude":121.53}}]}`, http.StatusNoContent},
+		{"POST", "/api/reports/sync", `{"reports":[]}`, http.StatusBadRequest},
+		{"POST", "/api/reports/sync", `{"reports":[{"type":"unknown","items":["fever"],"timestamp":1590000000,"location":{"latitude":25.04,"longitude":121.53}}]}`, http.StatusBadRequest},
+		{"POST", "/api/reports/sync", `{"reports":[{"type":"symptom","items":["fever"],"timestamp":1590000000,"location":{"latitude":95,"longitude":121.53}}]}`, http.StatusBadRequest},
+		{"POST", "/api/reports/sync", `not json`, http.StatusBadRequest},
+		{"GET", "/api/history/symptoms?limit=10", "", http.StatusNoContent},
+		{"GET", "/api/history/symptoms?limit=ten", "", http.StatusBadRequest},
+		{"GET", "/api/history/unknown", "", http.StatusBadRequest},
+		{"GET", "/api/autonomy_profile?lat=25.04&lng=121.53", "", http.StatusNoContent},
+		{"GET", "/api/autonomy_profile?lat=north", "", http.StatusBadRequest},
+		{"GET", "/not-documented", "", http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		received = ""
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		assert.Equal(t, tc.code, w.Code, "%s %s %s", tc.method, tc.path, tc.body)
+
+		if w.Code == http.StatusBadRequest {
+			var resp struct {
+				Error ErrorResponse `json:"error"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, errorInvalidParameters, resp.Error)
+		} else if tc.body != "" {
+			// the body is still readable by the handler after validation
+			assert.Equal(t, tc.body, received)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+// pageParams are the query params of lists paged by cursors. A client requests the
+// next page with `next_cursor` of the previous response until it is null.
+type pageParams struct {
+	Cursor string `form:"cursor"`
+	Limit  int64  `form:"limit"`
+}
+
+// parse returns the decoded cursor and the page size
+func (p pageParams) parse(defaultLimit int64) (store.Cursor, int64, error) {
+	var cursor store.Cursor
+	if p.Cursor != "" {
+		var err error
+		if cursor, err = store.DecodeCursor(p.Cursor); err != nil {
+			return cursor, 0, err
+		}
+	}
+
+	switch {
+	case p.Limit > 0:
+		return cursor, p.Limit, nil
+	case p.Limit == 0:
+		return cursor, defaultLimit, nil
+	default:
+		return cursor, 0, fmt.Errorf("negative limit")
+	}
+}
+
+// nextCursor returns the encoded cursor of the last item of a full page. It returns nil
+// if the page is not full or not limited, which means there are no more items.
+func nextCursor(count int, limit int64, last func() store.Cursor) *string {
+	if count == 0 || limit <= 0 || int64(count) < limit {
+		return nil
+	}
+
+	cursor := last().Encode()
+	return &cursor
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+func paginationTestRouter(s *Server) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("account", &schema.Account{AccountNumber: "account-pagination"})
+	})
+	r.GET("/history/:reportType", s.getHistory)
+	r.GET("/pois", s.listOwnPOI)
+	r.GET("/v2/pois", s.listOwnPOIV2)
+	return r
+}
+
+func TestGetHistoryPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	location := schema.GeoJSON{Type: "Point", Coordinates: []float64{121.5, 25.0}}
+	// reports of the same timestamp are told apart by ids across pages
+	reports := []*schema.SymptomReportData{
+		{ID: primitive.NewObjectID(), Location: location, Timestamp: 1591000200},
+		{ID: primitive.NewObjectID(), Location: location, Timestamp: 1591000200},
+	}
+	last := store.Cursor{Time: time.Unix(1591000200, 0).UTC(), ID: reports[1].ID.Hex()}
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	gomock.InOrder(
+		mongoStore.EXPECT().
+			GetReportedSymptoms("account-pagination", store.Cursor{Time: time.Unix(1591000400, 0).UTC()}, int64(2), "").
+			Return(reports, nil),
+		mongoStore.EXPECT().
+			GetReportedSymptoms("account-pagination", last, int64(2), "").
+			Return(reports[:1], nil),
+	)
+
+	r := paginationTestRouter(&Server{mongoStore: mongoStore})
+
+	var body struct {
+		NextCursor *string `json:"next_cursor"`
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/history/symptoms?before=1591000400&limit=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	if assert.NotNil(t, body.NextCursor) {
+		assert.Equal(t, last.Encode(), *body.NextCursor)
+	}
+
+	// the last page is not full
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/history/symptoms?limit=2&cursor="+*body.NextCursor, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Nil(t, body.NextCursor)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/history/symptoms?cursor=invalid!", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListOwnPOIPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pois := make([]schema.POIDetail, 3)
+	for i := range pois {
+		pois[i].ID = primitive.NewObjectID()
+	}
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().ListPOI("account-pagination").Return(pois, nil).Times(5)
+
+	r := paginationTestRouter(&Server{mongoStore: mongoStore})
+
+	// the first version always returns the whole list
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/pois?limit=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var all []schema.POIDetail
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+	assert.Len(t, all, 3)
+
+	var page struct {
+		POIs       []schema.POIDetail `json:"pois"`
+		NextCursor *string            `json:"next_cursor"`
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/v2/pois?limit=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []primitive.ObjectID{pois[0].ID, pois[1].ID}, []primitive.ObjectID{page.POIs[0].ID, page.POIs[1].ID})
+	assert.NotNil(t, page.NextCursor)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/v2/pois?limit=2&cursor="+*page.NextCursor, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	page.NextCursor = nil
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.POIs, 1)
+	assert.Equal(t, pois[2].ID, page.POIs[0].ID)
+	assert.Nil(t, page.NextCursor)
+
+	// the default limit covers the whole list
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/v2/pois", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	page.NextCursor = nil
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.POIs, 3)
+	assert.Nil(t, page.NextCursor)
+
+	// the POI of a cursor has been removed
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/v2/pois?cursor="+store.Cursor{ID: primitive.NewObjectID().Hex()}.Encode(), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListHelpsPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	helps := []schema.HelpRequest{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	autonomyStore := mocks.NewMockAutonomyCore(ctrl)
+	gomock.InOrder(
+		autonomyStore.EXPECT().ListHelps("account-helps", 25.0, 121.0, store.Cursor{}, int64(0)).Return(helps, nil),
+		autonomyStore.EXPECT().ListHelps("account-helps", 25.0, 121.0, store.Cursor{}, int64(2)).Return(helps, nil),
+		autonomyStore.EXPECT().ListHelps("account-helps", 25.0, 121.0, store.Cursor{ID: helps[1].ID.String()}, defaultHelpLimit).Return([]schema.HelpRequest{}, nil),
+	)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("account", &schema.Account{Profile: schema.AccountProfile{
+			AccountNumber: "account-helps",
+			State:         schema.ActivityState{LastLocation: &schema.Location{Latitude: 25, Longitude: 121}},
+		}})
+	})
+	r.GET("/helps", (&Server{store: autonomyStore}).queryHelps)
+
+	var page struct {
+		Result     []schema.HelpRequest `json:"result"`
+		NextCursor *string              `json:"next_cursor"`
+	}
+
+	// clients before pagination get every help in one page
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/helps", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Result, 2)
+	assert.Nil(t, page.NextCursor)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/helps?limit=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.NotNil(t, page.NextCursor)
+
+	// pages after the first one are limited by default
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/helps?cursor="+*page.NextCursor, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	page.NextCursor = nil
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Result, 0)
+	assert.Nil(t, page.NextCursor)
+}
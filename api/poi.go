@@ -13,6 +13,8 @@ import (
 	"github.com/bitmark-inc/autonomy-api/utils"
 )
 
+const defaultPOILimit = int64(20)
+
 type poiRequestBody struct {
 	ID       string           `json:"poi_id"`
 	Alias    string           `json:"alias"`
@@ -42,7 +44,7 @@ func (s *Server) addPOI(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, schema.POIDetail{
+	responseWithEncoding(c, http.StatusOK, schema.POIDetail{
 		ProfilePOI: schema.ProfilePOI{
 			ID:      poi.ID,
 			Alias:   poi.Alias,
@@ -96,7 +98,7 @@ func (s *Server) addOwnPOI(c *gin.Context) {
 		},
 	}
 
-	c.JSON(http.StatusOK, resp)
+	responseWithEncoding(c, http.StatusOK, resp)
 }
 
 func (s *Server) listOwnPOI(c *gin.Context) {
@@ -111,10 +113,75 @@ func (s *Server) listOwnPOI(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, pois)
+	responseWithEncoding(c, http.StatusOK, pois)
 	return
 }
 
+// listOwnPOIV2 returns a page of POIs in the profile with the cursor of the next page
+func (s *Server) listOwnPOIV2(c *gin.Context) {
+	account, ok := c.MustGet("account").(*schema.Account)
+	if !ok {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
+		return
+	}
+
+	var params pageParams
+	if err := c.BindQuery(&params); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	cursor, limit, err := params.parse(defaultPOILimit)
+	if err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	pois, err := s.mongoStore.ListPOI(account.AccountNumber)
+	if err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	page, err := pagePOIs(pois, cursor, limit)
+	if err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{
+		"pois": page,
+		"next_cursor": nextCursor(len(page), limit, func() store.Cursor {
+			return store.Cursor{ID: page[len(page)-1].ID.Hex()}
+		}),
+	})
+}
+
+// pagePOIs returns POIs after the one of a cursor. POIs are in the order of the profile
+// instead of timestamps, so a cursor only refers to the id of a POI.
+func pagePOIs(pois []schema.POIDetail, cursor store.Cursor, limit int64) ([]schema.POIDetail, error) {
+	start := 0
+	if cursor.ID != "" {
+		start = -1
+		for i, p := range pois {
+			if p.ID.Hex() == cursor.ID {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, store.ErrInvalidCursor
+		}
+	}
+
+	end := start + int(limit)
+	if end > len(pois) {
+		end = len(pois)
+	}
+
+	return pois[start:end], nil
+}
+
 func (s *Server) listPOI(c *gin.Context) {
 	account, ok := c.MustGet("account").(*schema.Account)
 	if !ok {
@@ -159,12 +226,13 @@ func (s *Server) listPOI(c *gin.Context) {
 					Longitude: p.Location.Coordinates[0],
 					Latitude:  p.Location.Coordinates[1],
 				},
-				Distance:      p.Distance,
-				ResourceScore: p.ResourceScore,
+				Distance:           p.Distance,
+				ResourceScore:      p.ResourceScore,
+				ResourceConfidence: p.ResourceConfidence,
 			}
 		}
 
-		c.JSON(http.StatusOK, response)
+		responseWithEncoding(c, http.StatusOK, response)
 		return
 	} else {
 		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters)
@@ -201,7 +269,7 @@ func (s *Server) updatePOIAlias(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 }
 
 func (s *Server) updatePOIOrder(c *gin.Context) {
@@ -235,7 +303,7 @@ func (s *Server) updatePOIOrder(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 }
 
 func (s *Server) deletePOI(c *gin.Context) {
@@ -256,7 +324,7 @@ func (s *Server) deletePOI(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": "OK"})
 }
 
 // addPOIResources add resources into a POI
@@ -267,15 +335,6 @@ func (s *Server) addPOIResources(c *gin.Context) {
 		return
 	}
 
-	var query struct {
-		Language string `form:"lang"`
-	}
-
-	if err := c.BindQuery(&query); err != nil {
-		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
-		return
-	}
-
 	var params struct {
 		ResourceIDs      []string `json:"resource_ids"`
 		NewResourceNames []string `json:"new_resource_names"`
@@ -297,7 +356,7 @@ func (s *Server) addPOIResources(c *gin.Context) {
 		addedResources = append(addedResources, schema.Resource{Name: name})
 	}
 
-	resources, err := s.mongoStore.AddPOIResources(poiID, addedResources, query.Language)
+	resources, err := s.mongoStore.AddPOIResources(poiID, addedResources, c.GetString("language"))
 	if err != nil {
 		switch err {
 		case store.ErrPOINotFound:
@@ -308,7 +367,7 @@ func (s *Server) addPOIResources(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"resources": resources,
 	})
 	return
@@ -323,9 +382,8 @@ func (s *Server) getPOIResources(c *gin.Context) {
 	}
 
 	var params struct {
-		Language      string `form:"lang"`
-		ImportantOnly bool   `form:"important"`
-		IncludeAdded  bool   `form:"include_added"`
+		ImportantOnly bool `form:"important"`
+		IncludeAdded  bool `form:"include_added"`
 	}
 
 	if err := c.Bind(&params); err != nil {
@@ -333,13 +391,13 @@ func (s *Server) getPOIResources(c *gin.Context) {
 		return
 	}
 
-	resources, err := s.mongoStore.GetPOIResources(poiID, params.ImportantOnly, params.IncludeAdded, params.Language)
+	resources, err := s.mongoStore.GetPOIResources(poiID, params.ImportantOnly, params.IncludeAdded, c.GetString("language"))
 	if err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"resources": resources,
 	})
 	return
@@ -73,7 +73,7 @@ func (s *Server) updatePOIRating(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{})
+	responseWithEncoding(c, http.StatusOK, gin.H{})
 }
 
 func (s *Server) getProfileRatings(c *gin.Context) {
@@ -100,7 +100,7 @@ func (s *Server) getProfileRatings(c *gin.Context) {
 
 	if err != nil {
 		c.Error(fmt.Errorf("GetPOIResourceMetric:%v", getPoiErr))
-		c.JSON(http.StatusOK, gin.H{"ratings": metric.Resources})
+		responseWithEncoding(c, http.StatusOK, gin.H{"ratings": metric.Resources})
 		return
 	}
 
@@ -120,18 +120,7 @@ func (s *Server) getProfileRatings(c *gin.Context) {
 		}
 	}
 
-	var params struct {
-		Language string `form:"lang"`
-	}
-
-	if err := c.BindQuery(&params); err != nil {
-		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
-		return
-	}
-
-	if "" == params.Language {
-		params.Language = "en"
-	}
+	lang := c.GetString("language")
 
 	poiResources, err := s.mongoStore.GetPOIResourceMetric(poiObj)
 	if err != nil {
@@ -144,7 +133,7 @@ func (s *Server) getProfileRatings(c *gin.Context) {
 	}
 
 	for i, r := range metric.Resources {
-		name, _ := store.ResolveResourceNameByID(r.ID, params.Language)
+		name, _ := store.ResolveResourceNameByID(r.ID, lang)
 		if "" == name { // show original name
 			name = resourceNames[r.ID]
 		}
@@ -157,5 +146,5 @@ func (s *Server) getProfileRatings(c *gin.Context) {
 	if nil == metric.Resources {
 		metric.Resources = []schema.RatingResource{}
 	}
-	c.JSON(http.StatusOK, gin.H{"ratings": metric.Resources})
+	responseWithEncoding(c, http.StatusOK, gin.H{"ratings": metric.Resources})
 }
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"github.com/bitmark-inc/autonomy-api/ratelimit"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+// route groups which have their own budgets
+const (
+	rateLimitGroupAPI    = "api"
+	rateLimitGroupScore  = "score"
+	rateLimitGroupReport = "report"
+	rateLimitGroupExport = "export"
+)
+
+// identities a budget is counted by
+const (
+	rateLimitByIP        = "ip"
+	rateLimitByRequester = "requester"
+)
+
+const (
+	rateLimitStorageMemory = "memory"
+	rateLimitStorageMongo  = "mongo"
+)
+
+// defaultRateLimits are the budgets of route groups. Calculating scores geocodes
+// addresses and reporting triggers updates of nearby accounts, so both of them
+// have much stricter budgets than the other routes. Building an export reads all
+// data of an account, which is allowed only a few times a day.
+var defaultRateLimits = map[string]map[string]ratelimit.Limit{
+	rateLimitGroupAPI: {
+		rateLimitByIP:        {Requests: 600, Period: time.Minute},
+		rateLimitByRequester: {Requests: 300, Period: time.Minute},
+	},
+	rateLimitGroupScore: {
+		rateLimitByIP:        {Requests: 30, Period: time.Minute},
+		rateLimitByRequester: {Requests: 10, Period: time.Minute},
+	},
+	rateLimitGroupReport: {
+		rateLimitByIP:        {Requests: 60, Period: time.Minute},
+		rateLimitByRequester: {Requests: 20, Period: time.Minute, Burst: 30},
+	},
+	rateLimitGroupExport: {
+		rateLimitByRequester: {Requests: 3, Period: 24 * time.Hour},
+	},
+}
+
+// mongoRateLimitStore keeps buckets in mongodb so that they are shared by all instances
+type mongoRateLimitStore struct {
+	store.RateLimit
+}
+
+func (s mongoRateLimitStore) Take(key string, limit ratelimit.Limit, now time.Time) (bool, time.Duration, error) {
+	return s.TakeRateLimitToken(key, limit, now)
+}
+
+func (s mongoRateLimitStore) Refund(key string, limit ratelimit.Limit, now time.Time) error {
+	return s.RefundRateLimitToken(key, limit, now)
+}
+
+type rateLimiter struct {
+	store  ratelimit.Store
+	limits map[string]map[string]ratelimit.Limit
+
+	// networks of reverse proxies whose X-Forwarded-For headers are trusted
+	trustedProxies []*net.IPNet
+}
+
+// parseTrustedProxies parses addresses or CIDR blocks of trusted proxies
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %s: %s", p, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// loadRateLimiter reads budgets from `ratelimit.limits`. A configured budget replaces the
+// default one of the same group and identity. Buckets are kept in memory unless
+// `ratelimit.storage` is `mongo`, which is required if several instances are deployed.
+func loadRateLimiter(mongoStore store.MongoStore) *rateLimiter {
+	limits := map[string]map[string]ratelimit.Limit{}
+	for group, scopes := range defaultRateLimits {
+		limits[group] = map[string]ratelimit.Limit{}
+		for scope, limit := range scopes {
+			limits[group][scope] = limit
+		}
+	}
+
+	configured := map[string]map[string]ratelimit.Limit{}
+	if err := viper.UnmarshalKey("ratelimit.limits", &configured); err != nil {
+		log.WithError(err).Error("fail to load rate limits")
+	}
+	for group, scopes := range configured {
+		if _, ok := limits[group]; !ok {
+			limits[group] = map[string]ratelimit.Limit{}
+		}
+		for scope, limit := range scopes {
+			limits[group][scope] = limit
+		}
+	}
+
+	var s ratelimit.Store
+	switch storage := viper.GetString("ratelimit.storage"); storage {
+	case rateLimitStorageMongo:
+		s = mongoRateLimitStore{mongoStore}
+	case rateLimitStorageMemory, "":
+		s = ratelimit.NewMemoryStore()
+	default:
+		log.WithField("storage", storage).Panic("unknown rate limit storage")
+	}
+
+	trustedProxies, err := parseTrustedProxies(viper.GetStringSlice("ratelimit.trusted_proxies"))
+	if err != nil {
+		log.WithError(err).Panic("fail to load trusted proxies")
+	}
+
+	return &rateLimiter{
+		store:          s,
+		limits:         limits,
+		trustedProxies: trustedProxies,
+	}
+}
+
+func (l *rateLimiter) trusted(ip net.IP) bool {
+	for _, network := range l.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client of a request. X-Forwarded-For is only
+// followed through trusted proxies, since it could be set to anything by clients. The
+// address is the nearest one to the server which is not a trusted proxy.
+func (l *rateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(r.RemoteAddr)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !l.trusted(ip) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+
+		ip = hop
+		if !l.trusted(ip) {
+			break
+		}
+	}
+
+	return ip.String()
+}
+
+func (l *rateLimiter) take(group, scope, id string, now time.Time) (bool, time.Duration, error) {
+	limit, ok := l.limits[group][scope]
+	if !ok || limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	return l.store.Take(fmt.Sprintf("%s:%s:%s", group, scope, id), limit, now)
+}
+
+func (l *rateLimiter) refund(group, scope, id string, now time.Time) error {
+	limit, ok := l.limits[group][scope]
+	if !ok || limit.Unlimited() {
+		return nil
+	}
+
+	return l.store.Refund(fmt.Sprintf("%s:%s:%s", group, scope, id), limit, now)
+}
+
+// rateLimit is a middleware to limit requests to a route group. Each identity in `scopes`
+// has its own bucket. The requester is only known after `authMiddleware`. A request denied
+// by a bucket gives back tokens taken from the buckets checked before, so that it is not
+// charged to any of them.
+func (s *Server) rateLimit(group string, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.rateLimiter == nil {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		taken := make(map[string]string)
+		for _, scope := range scopes {
+			var id string
+			switch scope {
+			case rateLimitByIP:
+				id = s.rateLimiter.clientIP(c.Request)
+			case rateLimitByRequester:
+				id = c.GetString("requester")
+			}
+			if id == "" {
+				continue
+			}
+
+			allowed, retryAfter, err := s.rateLimiter.take(group, scope, id, now)
+			if err == store.ErrRateLimitConflict {
+				// the bucket is too busy to be updated, which is a burst of requests
+				log.WithField("group", group).Warn("rate limit bucket is updated concurrently")
+				allowed, retryAfter = false, time.Second
+			} else if err != nil {
+				// requests are not blocked by an unavailable storage
+				log.WithError(err).WithField("group", group).Warn("fail to check rate limit")
+				continue
+			}
+
+			if !allowed {
+				for scope, id := range taken {
+					if err := s.rateLimiter.refund(group, scope, id, now); err != nil {
+						log.WithError(err).WithField("group", group).Warn("fail to refund rate limit")
+					}
+				}
+
+				c.Header("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(retryAfter.Seconds())))))
+				abortWithEncoding(c, http.StatusTooManyRequests, errorTooManyRequests)
+				return
+			}
+			taken[scope] = id
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/ratelimit"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{
+		rateLimiter: &rateLimiter{
+			store: ratelimit.NewMemoryStore(),
+			limits: map[string]map[string]ratelimit.Limit{
+				rateLimitGroupScore: {
+					rateLimitByIP:        {Requests: 3, Period: time.Minute},
+					rateLimitByRequester: {Requests: 2, Period: time.Minute},
+				},
+			},
+		},
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if requester := c.GetHeader("requester"); requester != "" {
+			c.Set("requester", requester)
+		}
+	})
+	r.POST("/api/scores", s.rateLimit(rateLimitGroupScore, rateLimitByIP, rateLimitByRequester), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/api/unlimited", s.rateLimit(rateLimitGroupAPI, rateLimitByIP), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(method, path, ip, requester string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":12345"
+		if requester != "" {
+			req.Header.Set("requester", requester)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("POST", "/api/scores", "10.0.0.1", "account-a").Code)
+	assert.Equal(t, http.StatusNoContent, request("POST", "/api/scores", "10.0.0.1", "account-a").Code)
+
+	// the budget of the requester is used up
+	w := request("POST", "/api/scores", "10.0.0.1", "account-a")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	var resp struct {
+		Error ErrorResponse `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, errorTooManyRequests, resp.Error)
+
+	// the denied request is not charged to the IP, which is shared by another requester
+	assert.Equal(t, http.StatusNoContent, request("POST", "/api/scores", "10.0.0.1", "account-b").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("POST", "/api/scores", "10.0.0.1", "account-c").Code)
+	assert.Equal(t, http.StatusNoContent, request("POST", "/api/scores", "10.0.0.2", "account-b").Code)
+
+	// a group without a budget is not limited
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusNoContent, request("GET", "/api/unlimited", "10.0.0.1", "").Code)
+	}
+}
+
+func TestLoadRateLimiter(t *testing.T) {
+	viper.Set("ratelimit.storage", "memory")
+	viper.Set("ratelimit.limits", map[string]interface{}{
+		"score": map[string]interface{}{
+			"requester": map[string]interface{}{
+				"requests": 5,
+				"period":   "1h",
+			},
+		},
+	})
+	defer viper.Set("ratelimit.limits", nil)
+
+	l := loadRateLimiter(nil)
+
+	assert.Equal(t, ratelimit.Limit{Requests: 5, Period: time.Hour}, l.limits[rateLimitGroupScore][rateLimitByRequester])
+	// budgets which are not configured are the default ones
+	assert.Equal(t, defaultRateLimits[rateLimitGroupScore][rateLimitByIP], l.limits[rateLimitGroupScore][rateLimitByIP])
+	assert.Equal(t, defaultRateLimits[rateLimitGroupReport], l.limits[rateLimitGroupReport])
+}
+
+func TestRateLimitStoreErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var storeErr error
+	s := &Server{
+		rateLimiter: &rateLimiter{
+			store: ratelimit.StoreFunc(func(key string, limit ratelimit.Limit, now time.Time) (bool, time.Duration, error) {
+				return false, 0, storeErr
+			}),
+			limits: map[string]map[string]ratelimit.Limit{
+				rateLimitGroupScore: {
+					rateLimitByIP: {Requests: 3, Period: time.Minute},
+				},
+			},
+		},
+	}
+
+	r := gin.New()
+	r.POST("/api/scores", s.rateLimit(rateLimitGroupScore, rateLimitByIP), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	// a busy bucket denies requests
+	storeErr = store.ErrRateLimitConflict
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/scores", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// an unavailable storage does not block requests
+	storeErr = fmt.Errorf("connection refused")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/scores", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	assert.NoError(t, err)
+	l := &rateLimiter{trustedProxies: proxies}
+
+	request := func(remoteAddr string, forwardedFor ...string) *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		for _, f := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", f)
+		}
+		return req
+	}
+
+	// headers from clients are not trusted
+	assert.Equal(t, "203.0.113.1", l.clientIP(request("203.0.113.1:12345", "198.51.100.1")))
+	// the nearest address which is not a trusted proxy is the client
+	assert.Equal(t, "198.51.100.1", l.clientIP(request("10.0.0.1:12345", "1.2.3.4, 198.51.100.1")))
+	assert.Equal(t, "198.51.100.1", l.clientIP(request("192.168.1.1:12345", "1.2.3.4", "198.51.100.1, 10.0.0.2")))
+	// a request sent by a proxy itself
+	assert.Equal(t, "10.0.0.1", l.clientIP(request("10.0.0.1:12345")))
+	assert.Equal(t, "10.0.0.2", l.clientIP(request("10.0.0.1:12345", "10.0.0.2")))
+
+	_, err = parseTrustedProxies([]string{"proxy"})
+	assert.Error(t, err)
+}
@@ -35,7 +35,6 @@ type reportItemQueryParams struct {
 	Granularity schema.AggregationTimeGranularity `form:"granularity"`
 	Start       string                            `form:"start"`
 	End         string                            `form:"end"`
-	Language    string                            `form:"lang"`
 	PoiID       string                            `form:"poi_id"`
 }
 
@@ -98,7 +97,7 @@ func (s *Server) getReportItems(c *gin.Context) {
 	currentPeriodEnd := end.UTC().Unix()
 	previousPeriodStart := 2*currentPeriodStart - currentPeriodEnd
 	previousPeriodEnd := currentPeriodStart
-	localizer := utils.NewLocalizer(params.Language)
+	localizer := utils.NewLocalizer(c.GetString("language"))
 
 	utcOffset := "+0000"
 	if start.Location() != nil {
@@ -137,7 +136,7 @@ func (s *Server) getReportItems(c *gin.Context) {
 				}
 				return ""
 			})
-			c.JSON(http.StatusOK, gin.H{"report_items": items})
+			responseWithEncoding(c, http.StatusOK, gin.H{"report_items": items})
 			return
 		} else if params.Type == reportItemTypeBehavior {
 			currentBuckets, err := s.mongoStore.GetPersonalBehaviorTimeSeriesData(profileID, currentPeriodStart, currentPeriodEnd, utcOffset, params.Granularity)
@@ -163,7 +162,7 @@ func (s *Server) getReportItems(c *gin.Context) {
 				}
 				return ""
 			})
-			c.JSON(http.StatusOK, gin.H{"report_items": items})
+			responseWithEncoding(c, http.StatusOK, gin.H{"report_items": items})
 			return
 		}
 	case reportItemScopeNeighborhood:
@@ -229,7 +228,7 @@ func (s *Server) getReportItems(c *gin.Context) {
 			// TODO: translate
 			return scoreID
 		})
-		c.JSON(http.StatusOK, gin.H{"report_items": items})
+		responseWithEncoding(c, http.StatusOK, gin.H{"report_items": items})
 	case reportItemTypeSymptom:
 		currentDistribution, err := s.mongoStore.FindSymptomDistribution(profileID, &loc, consts.NEARBY_DISTANCE_RANGE, currentPeriodStart, currentPeriodEnd, false)
 		if err != nil {
@@ -254,7 +253,7 @@ func (s *Server) getReportItems(c *gin.Context) {
 			}
 			return ""
 		})
-		c.JSON(http.StatusOK, gin.H{"report_items": items})
+		responseWithEncoding(c, http.StatusOK, gin.H{"report_items": items})
 	case reportItemTypeBehavior:
 		currentDistribution, err := s.mongoStore.FindBehaviorDistribution(profileID, &loc, consts.NEARBY_DISTANCE_RANGE, currentPeriodStart, currentPeriodEnd)
 		if err != nil {
@@ -279,11 +278,11 @@ func (s *Server) getReportItems(c *gin.Context) {
 			}
 			return ""
 		})
-		c.JSON(http.StatusOK, gin.H{"report_items": items})
+		responseWithEncoding(c, http.StatusOK, gin.H{"report_items": items})
 	case reportItemTypeCase:
 		if _, ok := schema.CDSCountyCollectionMatrix[schema.CDSCountryType(loc.Country)]; !ok {
 			name, _ := localizer.Localize(&i18n.LocalizeConfig{MessageID: fmt.Sprintf("conditions.%s.name", "covid_19")})
-			c.JSON(http.StatusOK, gin.H{"report_items": []*reportItem{{Name: name}}})
+			responseWithEncoding(c, http.StatusOK, gin.H{"report_items": []*reportItem{{Name: name}}})
 			return
 		}
 		currActiveCount, _, _, err := s.mongoStore.GetCDSActive(loc, currentPeriodEnd)
@@ -306,7 +305,7 @@ func (s *Server) getReportItems(c *gin.Context) {
 			}
 			return ""
 		})
-		c.JSON(http.StatusOK, gin.H{"report_items": items})
+		responseWithEncoding(c, http.StatusOK, gin.H{"report_items": items})
 	default:
 		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters)
 		return
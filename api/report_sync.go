@@ -0,0 +1,380 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	workflow "go.uber.org/cadence/.gen/go/shared"
+
+	"github.com/bitmark-inc/autonomy-api/consts"
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+const (
+	maxSyncReports = 100
+
+	// tolerance of the difference between clocks of clients and the server
+	syncClockSkew      = 5 * time.Minute
+	defaultMaxBackdate = 14 * 24 * time.Hour
+
+	// a report is not reachable from the previous one if the speed is faster than an airliner
+	maxTravelSpeed = 1000 * 1000 / 3600.0 // in meters per second
+	// tolerance of the positioning error of mobile devices
+	locationTolerance = 5000.0 // in meters
+)
+
+const (
+	syncSymptomReport  = "symptom"
+	syncBehaviorReport = "behavior"
+
+	syncStatusSaved      = "saved"
+	syncStatusDuplicated = "duplicated"
+	syncStatusRejected   = "rejected"
+)
+
+// syncReport is a report collected by a client while it is offline
+type syncReport struct {
+	Type           string          `json:"type"`
+	Items          []string        `json:"items"`
+	Timestamp      int64           `json:"timestamp"`
+	Location       schema.Location `json:"location"`
+	IdempotencyKey string          `json:"idempotency_key"`
+}
+
+// syncPosition is where an account was at a time
+type syncPosition struct {
+	Location  schema.Location
+	Timestamp int64
+}
+
+// reachable tells if one could travel between two positions in time
+func (p syncPosition) reachable(other syncPosition) bool {
+	distance := utils.GreatCircleDistance(
+		p.Location.Latitude, p.Location.Longitude,
+		other.Location.Latitude, other.Location.Longitude)
+	elapsed := math.Abs(float64(other.Timestamp - p.Timestamp))
+
+	return distance <= locationTolerance+maxTravelSpeed*elapsed
+}
+
+type syncResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// syncReports is the API to submit symptom and behavior reports queued by a client.
+// Each report is saved with its own time and location. Metrics of affected areas are
+// recalculated once after all reports are saved.
+func (s *Server) syncReports(c *gin.Context) {
+	a := c.MustGet("account")
+	account, ok := a.(*schema.Account)
+	if !ok {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
+		return
+	}
+
+	var params struct {
+		Reports []syncReport `json:"reports"`
+	}
+
+	if err := c.BindJSON(&params); err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	if len(params.Reports) == 0 || len(params.Reports) > maxSyncReports {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters,
+			fmt.Errorf("number of reports should be between 1 and %d", maxSyncReports))
+		return
+	}
+
+	// reports are also checked against the last known position of the account
+	var last *syncPosition
+	if state := account.Profile.State; state.LastLocation != nil && !state.LastActiveTime.IsZero() {
+		last = &syncPosition{Location: *state.LastLocation, Timestamp: state.LastActiveTime.Unix()}
+	}
+
+	now := time.Now().UTC()
+	results, accepted := checkSyncReports(params.Reports, last, now, maxReportBackdate())
+
+	savedLocations := make([]schema.Location, 0)
+	symptomReported := false
+	for _, i := range accepted {
+		r := params.Reports[i]
+
+		status, reason, err := s.saveSyncReport(account, r, idempotencyWindowStart(now))
+		if err != nil {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			return
+		}
+
+		results[i].Status = status
+		results[i].Reason = reason
+
+		if status == syncStatusSaved {
+			savedLocations = append(savedLocations, r.Location)
+			if r.Type == syncSymptomReport {
+				symptomReported = true
+			}
+		}
+	}
+
+	if len(savedLocations) > 0 {
+		if _, err := s.mongoStore.SyncProfileIndividualMetrics(account.Profile.ID.String()); err != nil {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			return
+		}
+
+		s.triggerAreaUpdates(c, savedLocations)
+	}
+
+	if symptomReported {
+		go func() {
+			if err := utils.TriggerAccountSymptomFollowUpNudge(*s.cadenceClient, c, account.AccountNumber); err != nil {
+				if _, ok := err.(*workflow.WorkflowExecutionAlreadyStartedError); !ok {
+					sentry.CaptureException(err)
+				}
+			}
+
+			if err := utils.TriggerAccountHighRiskFollowUpNudge(*s.cadenceClient, c, account.AccountNumber); err != nil {
+				if _, ok := err.(*workflow.WorkflowExecutionAlreadyStartedError); !ok {
+					sentry.CaptureException(err)
+				}
+			}
+		}()
+	}
+
+	responseWithEncoding(c, http.StatusOK, gin.H{"result": results})
+}
+
+// saveSyncReport saves a report and updates the area profile with its customized items.
+// It returns the status of the report and the reason if the report is not saved.
+func (s *Server) saveSyncReport(account *schema.Account, r syncReport, windowStart int64) (string, string, error) {
+	profileID := account.Profile.ID.String()
+	location := schema.GeoJSON{Type: "Point", Coordinates: []float64{r.Location.Longitude, r.Location.Latitude}}
+
+	switch r.Type {
+	case syncSymptomReport:
+		if r.IdempotencyKey != "" {
+			report, err := s.mongoStore.FindSymptomReportByIdempotencyKey(profileID, r.IdempotencyKey, windowStart)
+			if err != nil {
+				return "", "", err
+			}
+			if report != nil {
+				return syncStatusDuplicated, "", nil
+			}
+		}
+
+		symptoms, err := s.mongoStore.FindSymptomsByIDs(r.Items)
+		if err != nil {
+			return "", "", err
+		}
+		if len(symptoms) == 0 {
+			return syncStatusRejected, "unknown symptoms", nil
+		}
+
+		original, err := s.mongoStore.SymptomReportSave(&schema.SymptomReportData{
+			ProfileID:      profileID,
+			AccountNumber:  account.AccountNumber,
+			Symptoms:       symptoms,
+			Location:       location,
+			Timestamp:      r.Timestamp,
+			IdempotencyKey: r.IdempotencyKey,
+		}, windowStart)
+		if err != nil {
+			return "", "", err
+		}
+		if original != nil {
+			return syncStatusDuplicated, "", nil
+		}
+
+		if _, customized := schema.SplitSymptoms(symptoms); len(customized) > 0 {
+			if err := s.mongoStore.UpdateAreaProfileSymptom(customized, r.Location); err != nil {
+				log.WithError(err).Warn("fail to update area profile symptoms")
+			}
+		}
+	case syncBehaviorReport:
+		if r.IdempotencyKey != "" {
+			report, err := s.mongoStore.FindBehaviorReportByIdempotencyKey(profileID, r.IdempotencyKey, windowStart)
+			if err != nil {
+				return "", "", err
+			}
+			if report != nil {
+				return syncStatusDuplicated, "", nil
+			}
+		}
+
+		behaviors, err := s.mongoStore.FindBehaviorsByIDs(r.Items)
+		if err != nil {
+			return "", "", err
+		}
+		if len(behaviors) == 0 {
+			return syncStatusRejected, "unknown behaviors", nil
+		}
+
+		original, err := s.mongoStore.GoodBehaviorSave(&schema.BehaviorReportData{
+			ProfileID:      profileID,
+			AccountNumber:  account.AccountNumber,
+			Behaviors:      behaviors,
+			Location:       location,
+			Timestamp:      r.Timestamp,
+			IdempotencyKey: r.IdempotencyKey,
+		}, windowStart)
+		if err != nil {
+			return "", "", err
+		}
+		if original != nil {
+			return syncStatusDuplicated, "", nil
+		}
+
+		if _, customized := schema.SplitBehaviors(behaviors); len(customized) > 0 {
+			if err := s.mongoStore.UpdateAreaProfileBehavior(customized, r.Location); err != nil {
+				log.WithError(err).Warn("fail to update area profile behaviors")
+			}
+		}
+	}
+
+	return syncStatusSaved, "", nil
+}
+
+// triggerAreaUpdates triggers a single update for each account and POI
+// near any of the given locations
+func (s *Server) triggerAreaUpdates(c *gin.Context, locations []schema.Location) {
+	accountSet := make(map[string]struct{})
+	poiSet := make(map[primitive.ObjectID]struct{})
+	visited := make(map[string]struct{})
+
+	for _, loc := range locations {
+		// reports within about a hundred meters share the same neighborhood
+		key := fmt.Sprintf("%.3f,%.3f", loc.Latitude, loc.Longitude)
+		if _, ok := visited[key]; ok {
+			continue
+		}
+		visited[key] = struct{}{}
+
+		accts, err := s.mongoStore.NearestDistance(consts.NEARBY_DISTANCE_RANGE, loc)
+		if err != nil {
+			c.Error(err)
+		}
+		for _, a := range accts {
+			accountSet[a] = struct{}{}
+		}
+
+		pois, err := s.mongoStore.NearestPOI(consts.NEARBY_DISTANCE_RANGE, loc)
+		if err != nil {
+			c.Error(err)
+		}
+		for _, p := range pois {
+			poiSet[p] = struct{}{}
+		}
+	}
+
+	accts := make([]string, 0, len(accountSet))
+	for a := range accountSet {
+		accts = append(accts, a)
+	}
+
+	pois := make([]primitive.ObjectID, 0, len(poiSet))
+	for p := range poiSet {
+		pois = append(pois, p)
+	}
+
+	go func() {
+		if err := utils.TriggerAccountUpdate(*s.cadenceClient, c, accts); err != nil {
+			sentry.CaptureException(err)
+		}
+	}()
+
+	go func() {
+		if err := utils.TriggerPOIUpdate(*s.cadenceClient, c, pois); err != nil {
+			sentry.CaptureException(err)
+		}
+	}()
+}
+
+// maxReportBackdate returns how far a report could be backdated
+func maxReportBackdate() time.Duration {
+	backdate := viper.GetDuration("report.max_backdate")
+	if backdate <= 0 {
+		backdate = defaultMaxBackdate
+	}
+	return backdate
+}
+
+// checkSyncReports validates the plausibility of reports. Each report should be reachable
+// from the previous accepted one, and from the last known position of the account if it is
+// given. It returns the result of every report and the indexes of accepted reports ordered
+// by their time.
+func checkSyncReports(reports []syncReport, last *syncPosition, now time.Time, maxBackdate time.Duration) ([]syncResult, []int) {
+	results := make([]syncResult, len(reports))
+	candidates := make([]int, 0, len(reports))
+
+	for i, r := range reports {
+		results[i] = syncResult{Index: i}
+
+		reason := ""
+		switch {
+		case r.Type != syncSymptomReport && r.Type != syncBehaviorReport:
+			reason = "unknown report type"
+		case len(r.Items) == 0:
+			reason = "no reported items"
+		case len(r.IdempotencyKey) > maxIdempotencyKeyLength:
+			reason = "idempotency key is too long"
+		case r.Location.Latitude < -90 || r.Location.Latitude > 90 ||
+			r.Location.Longitude < -180 || r.Location.Longitude > 180:
+			reason = "invalid location"
+		case r.Timestamp > now.Add(syncClockSkew).Unix():
+			reason = "report time is in the future"
+		case r.Timestamp < now.Add(-maxBackdate).Unix():
+			reason = "report time is too early"
+		}
+
+		if reason != "" {
+			results[i].Status = syncStatusRejected
+			results[i].Reason = reason
+			continue
+		}
+
+		candidates = append(candidates, i)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return reports[candidates[i]].Timestamp < reports[candidates[j]].Timestamp
+	})
+
+	accepted := make([]int, 0, len(candidates))
+	var prev *syncPosition
+	for _, i := range candidates {
+		current := syncPosition{Location: reports[i].Location, Timestamp: reports[i].Timestamp}
+
+		// the last known position takes place of the previous report once it is passed
+		if last != nil && last.Timestamp <= current.Timestamp && (prev == nil || prev.Timestamp < last.Timestamp) {
+			prev = last
+		}
+
+		jumped := prev != nil && !prev.reachable(current)
+		if last != nil && current.Timestamp < last.Timestamp && !current.reachable(*last) {
+			jumped = true
+		}
+
+		if jumped {
+			results[i].Status = syncStatusRejected
+			results[i].Reason = "impossible location jump"
+			continue
+		}
+
+		prev = &current
+		accepted = append(accepted, i)
+	}
+
+	return results, accepted
+}
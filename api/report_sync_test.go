@@ -0,0 +1,114 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+var (
+	syncTestNow     = time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	syncTestTaipei  = schema.Location{Latitude: 25.0340, Longitude: 121.5645}
+	syncTestTainan  = schema.Location{Latitude: 22.9997, Longitude: 120.2270}
+	syncTestNewYork = schema.Location{Latitude: 40.7128, Longitude: -74.0060}
+)
+
+func syncTestReport(reportType string, offset time.Duration, loc schema.Location) syncReport {
+	return syncReport{
+		Type:      reportType,
+		Items:     []string{"item"},
+		Timestamp: syncTestNow.Add(offset).Unix(),
+		Location:  loc,
+	}
+}
+
+func TestCheckSyncReportsOrder(t *testing.T) {
+	reports := []syncReport{
+		syncTestReport(syncBehaviorReport, -1*time.Hour, syncTestTaipei),
+		syncTestReport(syncSymptomReport, -3*time.Hour, syncTestTaipei),
+		syncTestReport(syncSymptomReport, -2*time.Hour, syncTestTaipei),
+	}
+
+	results, accepted := checkSyncReports(reports, nil, syncTestNow, defaultMaxBackdate)
+	assert.Equal(t, []int{1, 2, 0}, accepted)
+	for i, r := range results {
+		assert.Equal(t, i, r.Index)
+		assert.Empty(t, r.Status)
+	}
+}
+
+func TestCheckSyncReportsTime(t *testing.T) {
+	reports := []syncReport{
+		syncTestReport(syncSymptomReport, time.Hour, syncTestTaipei),
+		syncTestReport(syncSymptomReport, time.Minute, syncTestTaipei),
+		syncTestReport(syncSymptomReport, -15*24*time.Hour, syncTestTaipei),
+		syncTestReport(syncSymptomReport, -13*24*time.Hour, syncTestTaipei),
+	}
+
+	results, accepted := checkSyncReports(reports, nil, syncTestNow, defaultMaxBackdate)
+	assert.Equal(t, []int{3, 1}, accepted)
+	assert.Equal(t, syncResult{Index: 0, Status: syncStatusRejected, Reason: "report time is in the future"}, results[0])
+	assert.Equal(t, syncResult{Index: 2, Status: syncStatusRejected, Reason: "report time is too early"}, results[2])
+}
+
+func TestCheckSyncReportsInvalidReport(t *testing.T) {
+	noItems := syncTestReport(syncSymptomReport, -time.Hour, syncTestTaipei)
+	noItems.Items = nil
+
+	reports := []syncReport{
+		syncTestReport("condition", -time.Hour, syncTestTaipei),
+		noItems,
+		syncTestReport(syncBehaviorReport, -time.Hour, schema.Location{Latitude: 91, Longitude: 0}),
+	}
+
+	results, accepted := checkSyncReports(reports, nil, syncTestNow, defaultMaxBackdate)
+	assert.Empty(t, accepted)
+	assert.Equal(t, "unknown report type", results[0].Reason)
+	assert.Equal(t, "no reported items", results[1].Reason)
+	assert.Equal(t, "invalid location", results[2].Reason)
+}
+
+func TestCheckSyncReportsLocationJump(t *testing.T) {
+	reports := []syncReport{
+		syncTestReport(syncSymptomReport, -10*time.Hour, syncTestTaipei),
+		// Taipei to New York in an hour
+		syncTestReport(syncSymptomReport, -9*time.Hour, syncTestNewYork),
+		// Taipei to Tainan by high speed rail
+		syncTestReport(syncBehaviorReport, -8*time.Hour, syncTestTainan),
+		// the same place a minute later
+		syncTestReport(syncBehaviorReport, -8*time.Hour+time.Minute, syncTestTainan),
+		// back to Taipei in a minute
+		syncTestReport(syncBehaviorReport, -8*time.Hour+2*time.Minute, syncTestTaipei),
+	}
+
+	results, accepted := checkSyncReports(reports, nil, syncTestNow, defaultMaxBackdate)
+	assert.Equal(t, []int{0, 2, 3}, accepted)
+	assert.Equal(t, "impossible location jump", results[1].Reason)
+	assert.Equal(t, "impossible location jump", results[4].Reason)
+}
+
+func TestCheckSyncReportsFromLastPosition(t *testing.T) {
+	last := &syncPosition{Location: syncTestTaipei, Timestamp: syncTestNow.Add(-5 * time.Hour).Unix()}
+
+	reports := []syncReport{
+		// New York an hour before and after the account was in Taipei
+		syncTestReport(syncSymptomReport, -6*time.Hour, syncTestNewYork),
+		syncTestReport(syncSymptomReport, -4*time.Hour, syncTestNewYork),
+		// Tainan before and after the account was in Taipei
+		syncTestReport(syncBehaviorReport, -7*time.Hour, syncTestTainan),
+		syncTestReport(syncBehaviorReport, -2*time.Hour, syncTestTainan),
+	}
+
+	results, accepted := checkSyncReports(reports, last, syncTestNow, defaultMaxBackdate)
+	assert.Equal(t, []int{2, 3}, accepted)
+	assert.Equal(t, "impossible location jump", results[0].Reason)
+	assert.Equal(t, "impossible location jump", results[1].Reason)
+
+	// a single report is checked against the last position too
+	results, accepted = checkSyncReports(reports[1:2], last, syncTestNow, defaultMaxBackdate)
+	assert.Empty(t, accepted)
+	assert.Equal(t, "impossible location jump", results[0].Reason)
+}
@@ -46,7 +46,7 @@ func (s *Server) calculateScore(c *gin.Context) {
 			return
 		}
 
-		metric := score.CalculateMetric(*rawMetrics, nil)
+		metric := score.CalculateMetric(score.DefaultFormula(), *rawMetrics, nil)
 		if err != nil {
 			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
 			return
@@ -55,5 +55,5 @@ func (s *Server) calculateScore(c *gin.Context) {
 		scores = append(scores, &metric.Score)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"results": scores})
+	responseWithEncoding(c, http.StatusOK, gin.H{"results": scores})
 }
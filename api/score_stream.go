@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+// comments are sent periodically to keep idle streams from being closed by proxies, and
+// the token of a stream is checked for revocation at the same time
+var scoreStreamHeartbeatInterval = 30 * time.Second
+
+// delay before watching profiles again after a failure
+const scoreStreamRetryInterval = 5 * time.Second
+
+// events of score streams
+const (
+	scoreEventAutonomyScore = "autonomy_score"
+	scoreEventPOIScore      = "poi_score"
+)
+
+// autonomyScoreEvent is the same as the response of the autonomy profile of the requester
+type autonomyScoreEvent struct {
+	Score      float64                 `json:"autonomy_score"`
+	ScoreDelta float64                 `json:"autonomy_score_delta"`
+	Individual schema.IndividualMetric `json:"individual"`
+	Neighbor   schema.Metric           `json:"neighbor"`
+}
+
+type poiScoreEvent struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// scoreEvent is an event of a score stream. The key tells which score it is about.
+type scoreEvent struct {
+	key  string
+	name string
+	data interface{}
+}
+
+// profileScoreEvents returns events of the scores of a profile: the individual score with
+// the neighborhood metric and the score of each saved POI
+func profileScoreEvents(profile schema.Profile) []scoreEvent {
+	autonomyScore, autonomyScoreDelta := score.CalculateIndividualAutonomyScore(profile.IndividualMetric, profile.Metric)
+	events := []scoreEvent{
+		{
+			key:  scoreEventAutonomyScore,
+			name: scoreEventAutonomyScore,
+			data: autonomyScoreEvent{
+				Score:      autonomyScore,
+				ScoreDelta: autonomyScoreDelta,
+				Individual: profile.IndividualMetric,
+				Neighbor:   profile.Metric,
+			},
+		},
+	}
+
+	for _, poi := range profile.PointsOfInterest {
+		if !poi.Monitored {
+			continue
+		}
+		events = append(events, scoreEvent{
+			key:  scoreEventPOIScore + ":" + poi.ID.Hex(),
+			name: scoreEventPOIScore,
+			data: poiScoreEvent{ID: poi.ID.Hex(), Score: poi.Score},
+		})
+	}
+
+	return events
+}
+
+// scoreHub watches profiles and dispatches them to the streams of their accounts.
+// There is only one watch for all streams, which is started on the first subscription.
+type scoreHub struct {
+	watch func(ctx context.Context, handle func(schema.Profile)) error
+
+	sync.Mutex
+	subscribers map[string]map[chan schema.Profile]struct{}
+
+	start  sync.Once
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newScoreHub(watch func(ctx context.Context, handle func(schema.Profile)) error) *scoreHub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scoreHub{
+		watch:       watch,
+		subscribers: map[string]map[chan schema.Profile]struct{}{},
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// subscribe returns a channel of the latest profiles of an account. The channel is closed
+// by the returned function or when the hub is closed.
+func (h *scoreHub) subscribe(accountNumber string) (<-chan schema.Profile, func()) {
+	h.start.Do(func() {
+		go h.run()
+	})
+
+	ch := make(chan schema.Profile, 1)
+
+	h.Lock()
+	defer h.Unlock()
+
+	if h.ctx.Err() != nil {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if h.subscribers[accountNumber] == nil {
+		h.subscribers[accountNumber] = map[chan schema.Profile]struct{}{}
+	}
+	h.subscribers[accountNumber][ch] = struct{}{}
+
+	return ch, func() {
+		h.Lock()
+		defer h.Unlock()
+
+		if _, ok := h.subscribers[accountNumber][ch]; !ok {
+			return
+		}
+		delete(h.subscribers[accountNumber], ch)
+		if len(h.subscribers[accountNumber]) == 0 {
+			delete(h.subscribers, accountNumber)
+		}
+		close(ch)
+	}
+}
+
+// publish sends a profile to the streams of its account. A slow stream only gets the
+// latest profile since a profile contains all of the scores.
+func (h *scoreHub) publish(profile schema.Profile) {
+	h.Lock()
+	defer h.Unlock()
+
+	for ch := range h.subscribers[profile.AccountNumber] {
+		select {
+		case ch <- profile:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- profile
+		}
+	}
+}
+
+func (h *scoreHub) run() {
+	for {
+		if err := h.watch(h.ctx, h.publish); err != nil {
+			log.WithError(err).Error("fail to watch profiles for score streams")
+		}
+
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-time.After(scoreStreamRetryInterval):
+		}
+	}
+}
+
+// close stops watching and ends all streams
+func (h *scoreHub) close() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.cancel()
+	for accountNumber, channels := range h.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+		delete(h.subscribers, accountNumber)
+	}
+}
+
+// tokenRevoked tells whether a token has been revoked since it was authorized
+func (s *Server) tokenRevoked(id string) bool {
+	t, err := s.mongoStore.GetToken(id)
+	if err != nil {
+		if err == store.ErrTokenNotFound {
+			return true
+		}
+		log.WithError(err).WithField("token", id).Error("fail to check token of score stream")
+		return false
+	}
+	return t.Revoked
+}
+
+// streamScores pushes scores of the requester as server-sent events. The current scores
+// are sent once connected, and then a score is sent again only when it is changed.
+// The stream ends when the token of the requester expires or is revoked.
+func (s *Server) streamScores(c *gin.Context) {
+	account, ok := c.MustGet("account").(*schema.Account)
+	if !ok {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
+		return
+	}
+
+	// service credentials have no token
+	var expired <-chan time.Time
+	token, _ := c.Value("token").(*schema.Token)
+	if token != nil {
+		timer := time.NewTimer(time.Until(token.ExpiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	updates, unsubscribe := s.scoreHub.subscribe(account.AccountNumber)
+	defer unsubscribe()
+
+	profile, err := s.mongoStore.GetProfile(account.AccountNumber)
+	if err != nil {
+		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// payloads of the last sent events by their keys
+	sent := map[string]string{}
+	send := func(profile schema.Profile) {
+		current := map[string]string{}
+		for _, e := range profileScoreEvents(profile) {
+			data, err := json.Marshal(e.data)
+			if err != nil {
+				c.Error(err)
+				continue
+			}
+
+			current[e.key] = string(data)
+			if sent[e.key] != current[e.key] {
+				fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", e.name, data)
+			}
+		}
+		sent = current
+		c.Writer.Flush()
+	}
+
+	send(*profile)
+
+	heartbeat := time.NewTicker(scoreStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case p, ok := <-updates:
+			if !ok {
+				return
+			}
+			send(p)
+		case <-expired:
+			return
+		case <-heartbeat.C:
+			if token != nil && s.tokenRevoked(token.ID) {
+				return
+			}
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// readEvent reads an event of a server-sent event stream and skips comments
+func readEvent(r *bufio.Reader) (string, string, error) {
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", "", err
+		}
+
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data, nil
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamScores(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	poiID := primitive.NewObjectID()
+	profile := schema.Profile{
+		AccountNumber: "account-stream",
+		Metric:        schema.Metric{Score: 80, LastUpdate: 1591000000},
+		PointsOfInterest: []schema.ProfilePOI{
+			{ID: poiID, Score: 60, Monitored: true},
+			{ID: primitive.NewObjectID(), Score: 50},
+		},
+	}
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().GetProfile("account-stream").Return(&profile, nil)
+
+	changes := make(chan schema.Profile)
+	hub := newScoreHub(func(ctx context.Context, handle func(schema.Profile)) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case p := <-changes:
+				handle(p)
+			}
+		}
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("account", &schema.Account{AccountNumber: "account-stream"})
+	})
+	r.GET("/stream", (&Server{mongoStore: mongoStore, scoreHub: hub}).streamScores)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/stream")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := bufio.NewReader(resp.Body)
+
+	// current scores, without POIs which are not saved
+	name, data, err := readEvent(events)
+	assert.NoError(t, err)
+	assert.Equal(t, scoreEventAutonomyScore, name)
+	assert.Contains(t, data, `"autonomy_score":`)
+
+	name, data, err = readEvent(events)
+	assert.NoError(t, err)
+	assert.Equal(t, scoreEventPOIScore, name)
+	assert.JSONEq(t, `{"id":"`+poiID.Hex()+`","score":60}`, data)
+
+	// changes of other accounts are not sent
+	changes <- schema.Profile{AccountNumber: "account-other"}
+
+	// only changed scores are sent
+	updated := profile
+	updated.PointsOfInterest = []schema.ProfilePOI{{ID: poiID, Score: 40, Monitored: true}}
+	changes <- updated
+
+	name, data, err = readEvent(events)
+	assert.NoError(t, err)
+	assert.Equal(t, scoreEventPOIScore, name)
+	assert.JSONEq(t, `{"id":"`+poiID.Hex()+`","score":40}`, data)
+
+	// streams end when the hub is closed
+	hub.close()
+	_, _, err = readEvent(events)
+	assert.Error(t, err)
+}
+
+func TestStreamScoresEndWithToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defer func(interval time.Duration) {
+		scoreStreamHeartbeatInterval = interval
+	}(scoreStreamHeartbeatInterval)
+	scoreStreamHeartbeatInterval = 10 * time.Millisecond
+
+	profile := schema.Profile{AccountNumber: "account-stream"}
+	revoked := schema.Token{ID: "token-revoked", Revoked: true}
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().GetProfile("account-stream").Return(&profile, nil).Times(2)
+	mongoStore.EXPECT().GetToken("token-revoked").Return(&revoked, nil)
+	mongoStore.EXPECT().GetToken("token-expired").Return(&schema.Token{ID: "token-expired"}, nil).AnyTimes()
+
+	hub := newScoreHub(func(ctx context.Context, handle func(schema.Profile)) error {
+		<-ctx.Done()
+		return nil
+	})
+	defer hub.close()
+
+	tokens := map[string]*schema.Token{
+		"expired": {ID: "token-expired", ExpiresAt: time.Now().Add(50 * time.Millisecond)},
+		"revoked": {ID: "token-revoked", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("account", &schema.Account{AccountNumber: "account-stream"})
+		c.Set("token", tokens[c.Query("token")])
+	})
+	r.GET("/stream", (&Server{mongoStore: mongoStore, scoreHub: hub}).streamScores)
+
+	for name := range tokens {
+		w := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/stream?token="+name, nil))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			assert.Contains(t, w.Body.String(), "event: "+scoreEventAutonomyScore, name)
+		case <-time.After(time.Second):
+			t.Fatalf("stream of the %s token is not ended", name)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"github.com/getkin/kin-openapi/openapi3filter"
 	sentrygin "github.com/getsentry/sentry-go/gin"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -23,6 +24,7 @@ import (
 	"github.com/bitmark-inc/autonomy-api/external/cadence"
 	"github.com/bitmark-inc/autonomy-api/external/onesignal"
 	"github.com/bitmark-inc/autonomy-api/logmodule"
+	"github.com/bitmark-inc/autonomy-api/monitoring"
 	"github.com/bitmark-inc/autonomy-api/store"
 )
 
@@ -60,6 +62,21 @@ type Server struct {
 
 	// air quality index client
 	aqiClient aqi.AQI
+
+	// credentials of internal services keyed by service id
+	services map[string]serviceCredential
+
+	// router of the OpenAPI document to validate requests
+	openAPIRouter *openapi3filter.Router
+
+	// token buckets of route groups
+	rateLimiter *rateLimiter
+
+	// dispatcher of profile changes to score streams
+	scoreHub *scoreHub
+
+	// closed to stop the sweeper when the server is shut down
+	shutdown chan struct{}
 }
 
 // NewServer new instance of server
@@ -94,6 +111,11 @@ func NewServer(
 		oneSignalClient: onesignal.NewClient(httpClient),
 		cadenceClient:   cadence.NewClient(),
 		aqiClient:       aqiClient,
+		services:        loadServiceCredentials(),
+		openAPIRouter:   loadOpenAPIRouter(),
+		rateLimiter:     loadRateLimiter(mongoStore),
+		scoreHub:        newScoreHub(mongoStore.WatchProfiles),
+		shutdown:        make(chan struct{}),
 	}
 }
 
@@ -104,12 +126,15 @@ func (s *Server) Run(addr string) error {
 		Handler: s.setupRouter(),
 	}
 
+	go s.runSweeper()
+
 	return s.server.ListenAndServe()
 }
 
 func (s *Server) setupRouter() *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(monitoring.Gin())
 	r.Use(sentrygin.New(sentrygin.Options{
 		Repanic:         true,
 		WaitForDelivery: false,
@@ -123,6 +148,7 @@ func (s *Server) setupRouter() *gin.Engine {
 		AllowAllOrigins:  true,
 		MaxAge:           12 * time.Hour,
 	}))
+	r.Use(s.openAPIValidator())
 
 	webhookRoute := r.Group("/webhook")
 	webhookRoute.Use(logmodule.Ginrus("Webhook"))
@@ -131,20 +157,28 @@ func (s *Server) setupRouter() *gin.Engine {
 
 	apiRoute := r.Group("/api")
 	apiRoute.Use(logmodule.Ginrus("API"))
+	apiRoute.Use(languageMiddleware)
+	apiRoute.Use(s.rateLimit(rateLimitGroupAPI, rateLimitByIP))
 	apiRoute.GET("/information", s.information)
 
 	// api route other than `/information` will apply the following middleware
 	apiRoute.Use(s.clientVersionGateway())
 
 	apiRoute.POST("/auth", s.requestJWT)
+	apiRoute.POST("/auth/refresh", s.refreshJWT)
+	apiRoute.GET("/accounts/deletions/:deletionID", s.accountDeletionStatusByID)
 
 	// api route other than `/auth` will apply the following middleware
 	apiRoute.Use(s.authMiddleware())
 	apiRoute.Use(s.updateGeoPositionMiddleware)
+	apiRoute.Use(s.rateLimit(rateLimitGroupAPI, rateLimitByRequester))
+
+	apiRoute.DELETE("/auth", s.revokeJWT)
 
 	accountRoute := apiRoute.Group("/accounts")
 	{
 		accountRoute.POST("", s.accountRegister)
+		accountRoute.GET("/me/deletion", s.accountDeletionStatus)
 	}
 
 	accountRoute.Use(s.recognizeAccountMiddleware())
@@ -165,9 +199,13 @@ func (s *Server) setupRouter() *gin.Engine {
 		accountRoute.PUT("/me/profile_formula", s.updateProfileFormula)
 		accountRoute.DELETE("/me/profile_formula", s.resetProfileFormula)
 
-		// accountRoute.POST("/me/export", s.accountPrepareExport)
-		// accountRoute.GET("/me/export", s.accountExportStatus)
-		// accountRoute.GET("/me/export/download", s.accountDownloadExport)
+		accountRoute.POST("/me/export", s.rateLimit(rateLimitGroupExport, rateLimitByRequester), s.accountPrepareExport)
+		accountRoute.GET("/me/export", s.accountExportStatus)
+		accountRoute.GET("/me/export/download", s.accountDownloadExport)
+
+		accountRoute.GET("/me/location_history", s.getLocationHistory)
+		accountRoute.PUT("/me/location_history", s.updateLocationHistorySetting)
+		accountRoute.DELETE("/me/location_history", s.deleteLocationHistory)
 	}
 
 	helpRoute := apiRoute.Group("/helps")
@@ -182,16 +220,26 @@ func (s *Server) setupRouter() *gin.Engine {
 	secretRoute := r.Group("/secret")
 	secretRoute.Use(logmodule.Ginrus("Secret"))
 	secretRoute.Use(s.apikeyAuthentication(viper.GetString("server.apikey.admin")))
+	secretRoute.Use(s.adminAuditMiddleware())
 	{
-		// secretRoute.POST("/delete-accounts", s.adminAccountDelete)
+		secretRoute.GET("/accounts/:accountNumber", s.adminAccountDetail)
+		secretRoute.DELETE("/accounts/:accountNumber", s.adminAccountDelete)
+		secretRoute.POST("/accounts/:accountNumber/refresh", s.adminTriggerAccountUpdate)
+		secretRoute.POST("/points-of-interest/:poiID/refresh", s.adminTriggerPOIUpdate)
+
+		secretRoute.PATCH("/symptoms/:symptomID", s.adminUpdateSymptom)
+		secretRoute.POST("/symptoms/:symptomID/merge", s.adminMergeSymptom)
+		secretRoute.PATCH("/behaviors/:behaviorID", s.adminUpdateBehavior)
+		secretRoute.POST("/behaviors/:behaviorID/merge", s.adminMergeBehavior)
+
+		secretRoute.POST("/helps/expire", s.adminExpireHelps)
 	}
 
 	metricRoute := r.Group("/metrics")
 	metricRoute.Use(logmodule.Ginrus("Metric"))
 	metricRoute.Use(s.apikeyAuthentication(viper.GetString("server.apikey.metric")))
 	{
-		// What kind of metrics do we need?
-		// metricRoute.GET("/total-users", s.metricAccountCreation)
+		metricRoute.GET("", gin.WrapH(monitoring.Handler()))
 	}
 
 	// points of interest
@@ -217,9 +265,11 @@ func (s *Server) setupRouter() *gin.Engine {
 	autonomyProfile.Use(s.recognizeAccountMiddleware())
 	{
 		autonomyProfile.GET("", s.autonomyProfile)
+		autonomyProfile.GET("/stream", s.streamScores)
+		autonomyProfile.GET("/explanation", s.scoreExplanation)
 	}
 
-	apiRoute.POST("/scores", s.calculateScore)
+	apiRoute.POST("/scores", s.rateLimit(rateLimitGroupScore, rateLimitByIP, rateLimitByRequester), s.calculateScore)
 
 	r.GET("/healthz", s.healthz)
 
@@ -228,7 +278,7 @@ func (s *Server) setupRouter() *gin.Engine {
 	{
 		symptomRoute.POST("", s.createSymptom)
 		symptomRoute.GET("", s.getSymptoms)
-		symptomRoute.POST("/report", s.reportSymptoms)
+		symptomRoute.POST("/report", s.rateLimit(rateLimitGroupReport, rateLimitByIP, rateLimitByRequester), s.reportSymptoms)
 	}
 
 	behaviorRoute := apiRoute.Group("/behaviors")
@@ -236,7 +286,13 @@ func (s *Server) setupRouter() *gin.Engine {
 	{
 		behaviorRoute.POST("", s.createBehavior)
 		behaviorRoute.GET("", s.goodBehaviors)
-		behaviorRoute.POST("/report", s.reportBehaviors)
+		behaviorRoute.POST("/report", s.rateLimit(rateLimitGroupReport, rateLimitByIP, rateLimitByRequester), s.reportBehaviors)
+	}
+
+	reportRoute := apiRoute.Group("/reports")
+	reportRoute.Use(s.recognizeAccountMiddleware())
+	{
+		reportRoute.POST("/sync", s.rateLimit(rateLimitGroupReport, rateLimitByIP, rateLimitByRequester), s.syncReports)
 	}
 
 	historyRoute := apiRoute.Group("/history")
@@ -279,11 +335,19 @@ func (s *Server) setupRouter() *gin.Engine {
 		behaviorV2Route.GET("", s.getBehaviorsV2)
 	}
 
+	accountV2Route := apiV2Route.Group("/accounts")
+	accountV2Route.Use(s.recognizeAccountMiddleware())
+	{
+		accountV2Route.GET("/me/pois", s.listOwnPOIV2)
+	}
+
 	return r
 }
 
 // Shutdown to shutdown the server
 func (s *Server) Shutdown(ctx context.Context) error {
+	close(s.shutdown)
+	s.scoreHub.close()
 	s.mongoStore.Close()
 	return s.server.Shutdown(ctx)
 }
@@ -311,14 +375,14 @@ func (s *Server) healthz(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"status":  "OK",
 		"version": viper.GetString("server.version"),
 	})
 }
 
 func (s *Server) information(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"information": map[string]interface{}{
 			"server": map[string]interface{}{
 				"version":                viper.GetString("server.version"),
@@ -333,12 +397,36 @@ func (s *Server) information(c *gin.Context) {
 	})
 }
 
-func responseWithEncoding(c *gin.Context, code int, obj gin.H) {
-	acceptEncoding := c.GetHeader("Accept-Encoding")
-	switch acceptEncoding {
-	default:
-		c.JSON(code, obj)
+// responseWithEncoding renders a response in the format negotiated by the `Accept`
+// header, which is either JSON or MessagePack. The body is compressed with gzip
+// if it is large enough and the client accepts it.
+func responseWithEncoding(c *gin.Context, code int, obj interface{}) {
+	contentType := negotiateContentType(c.GetHeader("Accept"))
+
+	body, err := encodeBody(contentType, obj)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Vary", "Accept, Accept-Encoding")
+
+	if len(body) >= gzipMinLength && acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		compressed, err := gzipBytes(body)
+		if err != nil {
+			c.Error(err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Header("Content-Encoding", "gzip")
+		body = compressed
+	}
+
+	if contentType == mimeJSON {
+		contentType = "application/json; charset=utf-8"
 	}
+	c.Data(code, contentType, body)
 }
 
 func abortWithEncoding(c *gin.Context, code int, obj ErrorResponse, errors ...error) {
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+const (
+	serviceIDHeader        = "Service-Id"
+	serviceTimestampHeader = "Service-Timestamp"
+	serviceSignatureHeader = "Service-Signature"
+	serviceRequesterHeader = "requester"
+
+	serviceWildcard = "*"
+
+	// serviceTimestampSkew is the maximal difference between the timestamp of a service
+	// request and the time it arrives
+	serviceTimestampSkew = 5 * time.Minute
+)
+
+// serviceRequest is the part of a service request which is signed
+type serviceRequest struct {
+	Timestamp string
+	Method    string
+	Path      string
+	Query     string
+	Requester string
+	Body      []byte
+}
+
+// canonicalServiceQuery sorts parameters of a query string, so that the signature does not
+// depend on the order of them
+func canonicalServiceQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// canonical returns the string to sign of a service request:
+// "timestamp|method|path|sorted query|requester|hex(sha256(body))"
+func (r serviceRequest) canonical() string {
+	bodyHash := sha256.Sum256(r.Body)
+	return strings.Join([]string{
+		r.Timestamp,
+		r.Method,
+		r.Path,
+		canonicalServiceQuery(r.Query),
+		r.Requester,
+		hex.EncodeToString(bodyHash[:]),
+	}, "|")
+}
+
+// serviceCredential is a scoped API key for an internal service. A service
+// signs its requests with the secret and is only able to act for the accounts
+// and the routes listed here.
+type serviceCredential struct {
+	Secret   string   `mapstructure:"secret"`
+	Routes   []string `mapstructure:"routes"`
+	Accounts []string `mapstructure:"accounts"`
+}
+
+// loadServiceCredentials reads service credentials from `server.services`.
+// The key of each entry is the service id.
+func loadServiceCredentials() map[string]serviceCredential {
+	services := map[string]serviceCredential{}
+	if err := viper.UnmarshalKey("server.services", &services); err != nil {
+		log.WithError(err).Error("fail to load service credentials")
+		return map[string]serviceCredential{}
+	}
+	return services
+}
+
+// permits checks if a route (e.g. `GET /api/autonomy_profile`) and an account
+// are both in the allow-list of a service
+func (sc serviceCredential) permits(route, accountNumber string) bool {
+	return allowListContains(sc.Routes, route) && allowListContains(sc.Accounts, accountNumber)
+}
+
+func allowListContains(list []string, value string) bool {
+	for _, v := range list {
+		if v == serviceWildcard || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceSignature returns the hex encoded HMAC-SHA256 of a service request
+func serviceSignature(secret string, r serviceRequest) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.canonical()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyServiceSignature validates the signature and the timestamp of a service request. It
+// returns the time until when the timestamp is accepted.
+func verifyServiceSignature(sc serviceCredential, r serviceRequest, signature string, now time.Time) (time.Time, error) {
+	t, err := strconv.ParseInt(r.Timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid service timestamp")
+	}
+
+	signedAt := time.Unix(0, t*int64(time.Millisecond))
+	if math.Abs(float64(now.Sub(signedAt))) > float64(serviceTimestampSkew) {
+		return time.Time{}, fmt.Errorf("service timestamp too skewed")
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid service signature")
+	}
+
+	expected, _ := hex.DecodeString(serviceSignature(sc.Secret, r))
+	if !hmac.Equal(sig, expected) {
+		return time.Time{}, fmt.Errorf("invalid service signature")
+	}
+
+	return signedAt.Add(serviceTimestampSkew), nil
+}
+
+// serviceAuthentication authorizes an internal service to act for an account.
+// Header format:
+// - Service-Id: the id of a service in `server.services`
+// - Service-Timestamp: unix time in milliseconds
+// - Service-Signature: hex(HMAC-SHA256(secret, "timestamp|method|path|query|requester|sha256(body)"))
+//   where the query is sorted by keys and the hash of the body is hex encoded
+// - requester: the account number the service acts for
+// A signature is accepted only once. Every attempt is recorded into the audit log.
+func (s *Server) serviceAuthentication(c *gin.Context) {
+	serviceID := c.GetHeader(serviceIDHeader)
+	requester := c.GetHeader(serviceRequesterHeader)
+	route := c.Request.Method + " " + c.FullPath()
+
+	sc, ok := s.services[serviceID]
+	if !ok || requester == "" {
+		abortWithEncoding(c, http.StatusUnauthorized, errorInvalidServiceCredential)
+		s.auditRequest(c, "service:"+serviceID, "act_for_account", requester)
+		return
+	}
+
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(c.Request.Body); err != nil {
+			abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+			s.auditRequest(c, "service:"+serviceID, "act_for_account", requester)
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	signature := c.GetHeader(serviceSignatureHeader)
+	expiresAt, err := verifyServiceSignature(sc, serviceRequest{
+		Timestamp: c.GetHeader(serviceTimestampHeader),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Query:     c.Request.URL.RawQuery,
+		Requester: requester,
+		Body:      body,
+	}, signature, time.Now())
+	if err != nil {
+		abortWithEncoding(c, http.StatusUnauthorized, errorInvalidServiceCredential, err)
+		s.auditRequest(c, "service:"+serviceID, "act_for_account", requester)
+		return
+	}
+
+	// hex signatures are decoded in any case, so they are recorded in lower case
+	if err := s.mongoStore.UseServiceSignature(serviceID, strings.ToLower(signature), expiresAt); err != nil {
+		if err == store.ErrServiceSignatureReplayed {
+			abortWithEncoding(c, http.StatusUnauthorized, errorInvalidServiceCredential, err)
+		} else {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+		}
+		s.auditRequest(c, "service:"+serviceID, "act_for_account", requester)
+		return
+	}
+
+	if !sc.permits(route, requester) {
+		abortWithEncoding(c, http.StatusForbidden, errorServiceNotPermitted)
+		s.auditRequest(c, "service:"+serviceID, "act_for_account", requester)
+		return
+	}
+
+	c.Set("requester", requester)
+	c.Set("service", serviceID)
+	c.Next()
+
+	s.auditRequest(c, "service:"+serviceID, "act_for_account", requester)
+}
+
+// auditRequest records a privileged request into the audit log
+func (s *Server) auditRequest(c *gin.Context, actor, action, accountNumber string) {
+	entry := schema.AuditLog{
+		Actor:         actor,
+		Action:        action,
+		AccountNumber: accountNumber,
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		Status:        c.Writer.Status(),
+		ClientIP:      c.ClientIP(),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	log.WithFields(logrus.Fields{
+		"prefix":    "audit",
+		"actor":     entry.Actor,
+		"action":    entry.Action,
+		"requester": entry.AccountNumber,
+		"method":    entry.Method,
+		"path":      entry.Path,
+		"status":    entry.Status,
+	}).Info("audit")
+
+	if err := s.mongoStore.AddAuditLog(entry); err != nil {
+		log.WithError(err).Error("fail to save audit log")
+	}
+}
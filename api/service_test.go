@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/mocks"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+func TestVerifyServiceSignature(t *testing.T) {
+	sc := serviceCredential{Secret: "test-secret"}
+	now := time.Now()
+	r := serviceRequest{
+		Timestamp: strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+		Method:    "POST",
+		Path:      "/api/symptoms/report",
+		Query:     "lang=en&limit=10",
+		Requester: "account-a",
+		Body:      []byte(`{"symptoms":["cough"]}`),
+	}
+	signature := serviceSignature(sc.Secret, r)
+
+	expiresAt, err := verifyServiceSignature(sc, r, signature, now)
+	assert.NoError(t, err)
+	assert.True(t, expiresAt.After(now.Add(serviceTimestampSkew-time.Second)))
+
+	// the order of query parameters does not matter
+	reordered := r
+	reordered.Query = "limit=10&lang=en"
+	_, err = verifyServiceSignature(sc, reordered, signature, now)
+	assert.NoError(t, err)
+
+	// any signed field changed
+	for _, change := range []func(r *serviceRequest){
+		func(r *serviceRequest) { r.Method = "DELETE" },
+		func(r *serviceRequest) { r.Path = "/api/helps" },
+		func(r *serviceRequest) { r.Query = "lang=en&limit=100" },
+		func(r *serviceRequest) { r.Query = "" },
+		func(r *serviceRequest) { r.Requester = "account-b" },
+		func(r *serviceRequest) { r.Body = []byte(`{"symptoms":["fever"]}`) },
+		func(r *serviceRequest) { r.Body = nil },
+	} {
+		changed := r
+		change(&changed)
+		_, err := verifyServiceSignature(sc, changed, signature, now)
+		assert.Error(t, err)
+	}
+
+	// wrong secret
+	_, err = verifyServiceSignature(serviceCredential{Secret: "other"}, r, signature, now)
+	assert.Error(t, err)
+
+	// malformed values
+	malformed := r
+	malformed.Timestamp = "not-a-number"
+	_, err = verifyServiceSignature(sc, malformed, signature, now)
+	assert.Error(t, err)
+	_, err = verifyServiceSignature(sc, r, "zz", now)
+	assert.Error(t, err)
+
+	// too skewed
+	_, err = verifyServiceSignature(sc, r, signature, now.Add(6*time.Minute))
+	assert.Error(t, err)
+}
+
+func TestServiceAuthenticationRejectsReplay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sc := serviceCredential{Secret: "test-secret", Routes: []string{"*"}, Accounts: []string{"*"}}
+	body := `{"symptoms":["cough"]}`
+	r := serviceRequest{
+		Timestamp: strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10),
+		Method:    "POST",
+		Path:      "/api/symptoms/report",
+		Requester: "account-a",
+		Body:      []byte(body),
+	}
+	signature := serviceSignature(sc.Secret, r)
+
+	mongoStore := mocks.NewMockMongoStore(ctrl)
+	mongoStore.EXPECT().AddAuditLog(gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		mongoStore.EXPECT().UseServiceSignature("service-a", signature, gomock.Any()).Return(nil),
+		mongoStore.EXPECT().UseServiceSignature("service-a", signature, gomock.Any()).Return(store.ErrServiceSignatureReplayed),
+		mongoStore.EXPECT().UseServiceSignature("service-a", signature, gomock.Any()).Return(store.ErrServiceSignatureReplayed),
+	)
+
+	s := &Server{mongoStore: mongoStore, services: map[string]serviceCredential{"service-a": sc}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/symptoms/report", s.serviceAuthentication, func(c *gin.Context) {
+		// the body is still readable by handlers
+		b, _ := ioutil.ReadAll(c.Request.Body)
+		assert.Equal(t, body, string(b))
+		c.Status(http.StatusOK)
+	})
+
+	send := func(body, signature string) int {
+		req := httptest.NewRequest("POST", "/api/symptoms/report", bytes.NewBufferString(body))
+		req.Header.Set(serviceIDHeader, "service-a")
+		req.Header.Set(serviceTimestampHeader, r.Timestamp)
+		req.Header.Set(serviceSignatureHeader, signature)
+		req.Header.Set(serviceRequesterHeader, r.Requester)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// a different body is not covered by the signature
+	assert.Equal(t, http.StatusUnauthorized, send(`{"symptoms":["fever"]}`, signature))
+	assert.Equal(t, http.StatusOK, send(body, signature))
+	assert.Equal(t, http.StatusUnauthorized, send(body, signature))
+
+	// the same signature in another case is a replay too
+	assert.Equal(t, http.StatusUnauthorized, send(body, strings.ToUpper(signature)))
+}
+
+func TestServiceCredentialPermits(t *testing.T) {
+	sc := serviceCredential{
+		Routes:   []string{"GET /api/autonomy_profile"},
+		Accounts: []string{"account-a"},
+	}
+	assert.True(t, sc.permits("GET /api/autonomy_profile", "account-a"))
+	assert.False(t, sc.permits("POST /api/symptoms/report", "account-a"))
+	assert.False(t, sc.permits("GET /api/autonomy_profile", "account-b"))
+
+	sc = serviceCredential{
+		Routes:   []string{"*"},
+		Accounts: []string{"*"},
+	}
+	assert.True(t, sc.permits("POST /api/symptoms/report", "account-b"))
+
+	assert.False(t, serviceCredential{}.permits("GET /api/autonomy_profile", "account-a"))
+}
@@ -17,8 +17,7 @@ func (s *Server) listResources(c *gin.Context) {
 	}
 
 	var params struct {
-		Suggestion bool   `form:"suggestion"`
-		Language   string `form:"lang"`
+		Suggestion bool `form:"suggestion"`
 	}
 
 	if err := c.Bind(&params); err != nil {
@@ -26,10 +25,7 @@ func (s *Server) listResources(c *gin.Context) {
 		return
 	}
 
-	lang := "en"
-	if params.Language != "" {
-		lang = params.Language
-	}
+	lang := c.GetString("language")
 
 	if !params.Suggestion {
 		abortWithEncoding(c, http.StatusInternalServerError, errorResourceNotSupport)
@@ -41,5 +37,5 @@ func (s *Server) listResources(c *gin.Context) {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
 	}
-	c.JSON(200, gin.H{"resources": resources})
+	responseWithEncoding(c, 200, gin.H{"resources": resources})
 }
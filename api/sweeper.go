@@ -0,0 +1,58 @@
+package api
+
+import (
+	"time"
+
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+// sweepInterval is how often the server cleans up data left by background jobs
+const sweepInterval = 10 * time.Minute
+
+// runSweeper cleans up data left by background jobs until the server is shut down.
+// Sweeping is idempotent, so every instance of the server runs its own sweeper.
+func (s *Server) runSweeper() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(time.Now())
+
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) sweep(now time.Time) {
+	count, err := s.mongoStore.DeleteExpiredAccountExportArchives(now)
+	if err != nil {
+		log.WithError(err).Error("fail to remove expired export archives")
+	} else if count > 0 {
+		log.WithField("count", count).Info("expired export archives removed")
+	}
+
+	s.resumeStaleAccountDeletions(now)
+}
+
+// resumeStaleAccountDeletions resumes deletions which were interrupted, one at a time.
+// Each deletion is claimed before resuming so that it is not resumed by other servers.
+func (s *Server) resumeStaleAccountDeletions(now time.Time) {
+	for {
+		deletion, err := s.mongoStore.ClaimStaleAccountDeletion(now.Add(-accountDeletionTimeout))
+		if err != nil {
+			if err != store.ErrAccountDeletionNotFound {
+				log.WithError(err).Error("fail to claim stale account deletion")
+			}
+			return
+		}
+
+		logger := log.WithField("account_number", deletion.AccountNumber)
+		logger.Info("resume stale account deletion")
+		if err := s.deleteAccount(*deletion); err != nil {
+			logger.WithError(err).Error("fail to delete account")
+		}
+	}
+}
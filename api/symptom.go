@@ -33,7 +33,7 @@ func (s *Server) createSymptom(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"id": id,
 	})
 	return
@@ -42,19 +42,7 @@ func (s *Server) createSymptom(c *gin.Context) {
 func (s *Server) getSymptoms(c *gin.Context) {
 	a := c.MustGet("account")
 
-	var params struct {
-		Language string `form:"lang"`
-	}
-
-	if err := c.Bind(&params); err != nil {
-		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
-		return
-	}
-
-	lang := "en"
-	if params.Language != "" {
-		lang = params.Language
-	}
+	lang := c.GetString("language")
 
 	account, ok := a.(*schema.Account)
 	if !ok {
@@ -81,15 +69,14 @@ func (s *Server) getSymptoms(c *gin.Context) {
 		symptoms = append(symptoms, customized...)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"symptoms": symptoms})
+	responseWithEncoding(c, http.StatusOK, gin.H{"symptoms": symptoms})
 }
 
 func (s *Server) getSymptomsV2(c *gin.Context) {
 	a := c.MustGet("account")
 
 	var params struct {
-		Language string `form:"lang"`
-		All      bool   `form:"all"`
+		All bool `form:"all"`
 	}
 
 	if err := c.Bind(&params); err != nil {
@@ -97,10 +84,7 @@ func (s *Server) getSymptomsV2(c *gin.Context) {
 		return
 	}
 
-	lang := "en"
-	if params.Language != "" {
-		lang = params.Language
-	}
+	lang := c.GetString("language")
 
 	account, ok := a.(*schema.Account)
 	if !ok {
@@ -133,7 +117,7 @@ func (s *Server) getSymptomsV2(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		responseWithEncoding(c, http.StatusOK, gin.H{
 			"official_symptoms":   official,
 			"suggested_symptoms":  suggested,
 			"customized_symptoms": customized,
@@ -147,7 +131,7 @@ func (s *Server) getSymptomsV2(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	responseWithEncoding(c, http.StatusOK, gin.H{
 		"official_symptoms":     official,
 		"neighborhood_symptoms": customized,
 	})
@@ -161,6 +145,28 @@ func (s *Server) reportSymptoms(c *gin.Context) {
 		return
 	}
 
+	key, err := idempotencyKey(c)
+	if err != nil {
+		abortWithEncoding(c, http.StatusBadRequest, errorInvalidParameters, err)
+		return
+	}
+
+	windowStart := idempotencyWindowStart(time.Now())
+	if key != "" {
+		report, err := s.mongoStore.FindSymptomReportByIdempotencyKey(account.Profile.ID.String(), key, windowStart)
+		if err != nil {
+			abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
+			return
+		}
+
+		// a repeat submission gets the original response without saving or triggering anything
+		if report != nil {
+			official, _ := schema.SplitSymptoms(report.Symptoms)
+			s.respondSymptomReport(c, len(official), reportedLocation(report.Location))
+			return
+		}
+	}
+
 	loc := account.Profile.State.LastLocation
 	if nil == loc {
 		abortWithEncoding(c, http.StatusBadRequest, errorUnknownAccountLocation)
@@ -187,17 +193,26 @@ func (s *Server) reportSymptoms(c *gin.Context) {
 	}
 
 	data := schema.SymptomReportData{
-		ProfileID:     account.Profile.ID.String(),
-		AccountNumber: account.Profile.AccountNumber,
-		Symptoms:      symptoms,
-		Location:      schema.GeoJSON{Type: "Point", Coordinates: []float64{loc.Longitude, loc.Latitude}},
-		Timestamp:     time.Now().UTC().Unix(),
-	}
-	if err := s.mongoStore.SymptomReportSave(&data); err != nil {
+		ProfileID:      account.Profile.ID.String(),
+		AccountNumber:  account.Profile.AccountNumber,
+		Symptoms:       symptoms,
+		Location:       schema.GeoJSON{Type: "Point", Coordinates: []float64{loc.Longitude, loc.Latitude}},
+		Timestamp:      time.Now().UTC().Unix(),
+		IdempotencyKey: key,
+	}
+	original, err := s.mongoStore.SymptomReportSave(&data, windowStart)
+	if err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer)
 		return
 	}
 
+	// a concurrent repeat submission is only found when saving it
+	if original != nil {
+		official, _ := schema.SplitSymptoms(original.Symptoms)
+		s.respondSymptomReport(c, len(official), reportedLocation(original.Location))
+		return
+	}
+
 	if _, err := s.mongoStore.SyncProfileIndividualMetrics(account.Profile.ID.String()); err != nil {
 		abortWithEncoding(c, http.StatusInternalServerError, errorInternalServer, err)
 		return
@@ -245,17 +260,21 @@ func (s *Server) reportSymptoms(c *gin.Context) {
 	} else {
 		c.Error(err)
 	}
-	if len(official) <= 0 {
-		c.JSON(http.StatusOK, gin.H{"official": 0, "guide": []schema.NearbyTestCenter{}})
+	s.respondSymptomReport(c, len(official), *loc)
+}
+
+// respondSymptomReport responds the number of official symptoms in a report
+// and test centers near the reported location
+func (s *Server) respondSymptomReport(c *gin.Context, official int, loc schema.Location) {
+	if official <= 0 {
+		responseWithEncoding(c, http.StatusOK, gin.H{"official": 0, "guide": []schema.NearbyTestCenter{}})
 		return
 	}
-	centers, err := s.mongoStore.NearbyTestCenter(*loc, 10)
+	centers, err := s.mongoStore.NearbyTestCenter(loc, 10)
 	if err != nil {
 		c.Error(err)
-		c.JSON(http.StatusOK, gin.H{"official": len(official), "guide": []schema.NearbyTestCenter{}})
+		responseWithEncoding(c, http.StatusOK, gin.H{"official": official, "guide": []schema.NearbyTestCenter{}})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"official": len(official), "guide": centers})
-	return
-
+	responseWithEncoding(c, http.StatusOK, gin.H{"official": official, "guide": centers})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/getsentry/sentry-go"
@@ -15,6 +16,7 @@ import (
 
 	nudgeWorker "github.com/bitmark-inc/autonomy-api/background/nudge"
 	cadence "github.com/bitmark-inc/autonomy-api/external/cadence"
+	"github.com/bitmark-inc/autonomy-api/monitoring"
 	"github.com/bitmark-inc/autonomy-api/store"
 	"github.com/bitmark-inc/autonomy-api/utils"
 )
@@ -83,6 +85,7 @@ func main() {
 
 	opts := options.Client().ApplyURI(viper.GetString("mongo.conn"))
 	opts.SetMaxPoolSize(viper.GetUint64("mongo.pool"))
+	opts.SetMonitor(monitoring.MongoCommandMonitor())
 	mongoClient, err := mongo.NewClient(opts)
 	if nil != err {
 		logger.Panic("create mongo client with error", zap.Error(err))
@@ -99,6 +102,14 @@ func main() {
 	)
 
 	worker := nudgeWorker.NewNudgeWorker(viper.GetString("cadence.domain"), mongoStore)
+	if addr := viper.GetString("metrics.listen"); addr != "" {
+		go func() {
+			if err := http.ListenAndServe(addr, monitoring.APIKeyHandler(viper.GetString("server.apikey.metric"))); err != nil {
+				logger.Error("metrics server stopped", zap.Error(err))
+			}
+		}()
+	}
+
 	worker.Register()
 	worker.Start(cadence.BuildCadenceServiceClient(viper.GetString("cadence.conn")), logger)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/getsentry/sentry-go"
@@ -17,6 +18,9 @@ import (
 	scoreWorker "github.com/bitmark-inc/autonomy-api/background/score"
 	cadence "github.com/bitmark-inc/autonomy-api/external/cadence"
 	"github.com/bitmark-inc/autonomy-api/geo"
+	"github.com/bitmark-inc/autonomy-api/monitoring"
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
 	"github.com/bitmark-inc/autonomy-api/store"
 )
 
@@ -83,6 +87,7 @@ func main() {
 
 	opts := options.Client().ApplyURI(viper.GetString("mongo.conn"))
 	opts.SetMaxPoolSize(viper.GetUint64("mongo.pool"))
+	opts.SetMonitor(monitoring.MongoCommandMonitor())
 	mongoClient, err := mongo.NewClient(opts)
 	if nil != err {
 		logger.Panic("create mongo client with error", zap.Error(err))
@@ -105,12 +110,55 @@ func main() {
 		),
 	)
 
+	if err := score.SetFormulaConfig(score.FormulaConfig{
+		Version:        viper.GetString("score.formula.version"),
+		RolloutVersion: viper.GetString("score.formula.rollout.version"),
+		RolloutPercent: viper.GetInt("score.formula.rollout.percent"),
+	}); err != nil {
+		logger.Panic("set score formula with error", zap.Error(err))
+	}
+
+	var spikeConfig schema.SpikeConfig
+	var spikeAreas map[string]schema.SpikeConfig
+	if err := viper.UnmarshalKey("score.spike", &spikeConfig); err != nil {
+		logger.Panic("read symptom spike config with error", zap.Error(err))
+	}
+	if err := viper.UnmarshalKey("score.spike.areas", &spikeAreas); err != nil {
+		logger.Panic("read symptom spike config with error", zap.Error(err))
+	}
+	if err := score.SetSpikeConfig(spikeConfig, spikeAreas); err != nil {
+		logger.Panic("set symptom spike config with error", zap.Error(err))
+	}
+
+	if err := score.SetDecayConfig(score.DecayConfig{
+		HalfLife: viper.GetDuration("score.decay.half_life"),
+		Window:   viper.GetDuration("score.decay.window"),
+	}); err != nil {
+		logger.Panic("set report decay config with error", zap.Error(err))
+	}
+
+	var ratingConfig score.RatingConfig
+	if err := viper.UnmarshalKey("score.rating", &ratingConfig); err != nil {
+		logger.Panic("read poi rating config with error", zap.Error(err))
+	}
+	if err := score.SetRatingConfig(ratingConfig); err != nil {
+		logger.Panic("set poi rating config with error", zap.Error(err))
+	}
+
 	mongoStore := store.NewMongoStore(
 		mongoClient,
 		viper.GetString("mongo.database"),
 	)
 
 	worker := scoreWorker.NewScoreUpdateWorker(viper.GetString("cadence.domain"), mongoStore)
+	if addr := viper.GetString("metrics.listen"); addr != "" {
+		go func() {
+			if err := http.ListenAndServe(addr, monitoring.APIKeyHandler(viper.GetString("server.apikey.metric"))); err != nil {
+				logger.Error("metrics server stopped", zap.Error(err))
+			}
+		}()
+	}
+
 	worker.Register()
 	worker.Start(cadence.BuildCadenceServiceClient(viper.GetString("cadence.conn")), logger)
 }
@@ -7,12 +7,6 @@ import (
 	"github.com/spf13/viper"
 )
 
-// OneSignalLanguageCode is a mapping between onesignal language code and i18n language code
-var OneSignalLanguageCode = map[string]string{
-	"zh-Hant": "zh_tw",
-	"en":      "en",
-}
-
 // notifyAccountsByTemplate will consolidate account numbers and submit notification requests
 func (b *Background) NotifyAccountsByTemplate(accountNumbers []string, templateID string, data map[string]interface{}) error {
 	filters := []map[string]string{}
@@ -3,6 +3,7 @@ package nudge
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -12,6 +13,7 @@ import (
 	"github.com/bitmark-inc/autonomy-api/background"
 	"github.com/bitmark-inc/autonomy-api/external/onesignal"
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
 	"github.com/bitmark-inc/autonomy-api/utils"
 )
 
@@ -26,7 +28,7 @@ const HighRiskSymptomExpiry = 3 * 24 * time.Hour
 var now = time.Now
 
 func (n *NudgeWorker) getLastSymptomReport(accountNumber string) *schema.SymptomReportData {
-	symptoms, err := n.mongo.GetReportedSymptoms(accountNumber, now().Unix(), 1, "")
+	symptoms, err := n.mongo.GetReportedSymptoms(accountNumber, store.Cursor{Time: now()}, 1, "")
 	if err != nil {
 		return nil
 	}
@@ -123,7 +125,7 @@ func SymptomListingMessage(msgType string, sourceSymptoms []schema.Symptom) (map
 		return nil, nil, fmt.Errorf("no symptoms in list")
 	}
 
-	for key, lang := range background.OneSignalLanguageCode {
+	for key, lang := range utils.OneSignalLanguages() {
 		loc := utils.NewLocalizer(lang)
 
 		// translate heading
@@ -187,15 +189,34 @@ func (n *NudgeWorker) NotifySymptomFollowUpActivity(ctx context.Context, account
 	return n.mongo.UpdateAccountNudge(accountNumber, schema.NudgeSymptomFollowUp)
 }
 
-// NotifySymptomSpikeActivity send notifications to accounts who have symptoms spiked around [NSy_1]
+// NotifySymptomSpikeActivity send notifications to accounts who have symptoms spiked around [NSy_1].
+// It is kept for workflows scheduled before NotifySymptomSpikeWorkflowV2.
 func (n *NudgeWorker) NotifySymptomSpikeActivity(ctx context.Context, accountNumber string, symptoms []schema.Symptom) error {
+	return n.NotifySymptomSpikeActivityV2(ctx, accountNumber, symptoms, nil)
+}
+
+// NotifySymptomSpikeActivityV2 send notifications to accounts who have symptoms spiked around [NSy_1].
+// Symptoms are listed from the strongest spike and strengths are sent along with them.
+func (n *NudgeWorker) NotifySymptomSpikeActivityV2(ctx context.Context, accountNumber string, symptoms []schema.Symptom, spikes []schema.SymptomSpike) error {
 	logger := activity.GetLogger(ctx)
 
-	logger.Info("Prepare the message context for following up symptoms", zap.Any("symptoms", symptoms))
+	logger.Info("Prepare the message context for following up symptoms", zap.Any("symptoms", symptoms), zap.Any("spikes", spikes))
+
+	strengths := map[string]float64{}
+	for _, s := range spikes {
+		strengths[s.ID] = s.Strength
+	}
+
+	symptoms = append([]schema.Symptom{}, symptoms...)
+	sort.SliceStable(symptoms, func(i, j int) bool {
+		return strengths[symptoms[i].ID] > strengths[symptoms[j].ID]
+	})
 
 	var symptomsIDs = make([]string, 0)
+	var symptomStrengths = make([]float64, 0)
 	for _, s := range symptoms {
 		symptomsIDs = append(symptomsIDs, s.ID)
+		symptomStrengths = append(symptomStrengths, strengths[s.ID])
 	}
 
 	headings, contents, err := SymptomListingMessage("symptom_spike", symptoms)
@@ -209,6 +230,7 @@ func (n *NudgeWorker) NotifySymptomSpikeActivity(ctx context.Context, accountNum
 		map[string]interface{}{
 			"notification_type": "ACCOUNT_SYMPTOM_SPIKE",
 			"symptoms":          symptomsIDs,
+			"strengths":         symptomStrengths,
 		},
 	); err != nil {
 		if !onesignal.IsErrAllPlayersNotSubscribed(err) {
@@ -228,7 +250,7 @@ func (n *NudgeWorker) NotifyBehaviorNudgeActivity(ctx context.Context, accountNu
 	headings := map[string]string{}
 	contents := map[string]string{}
 
-	for key, lang := range background.OneSignalLanguageCode {
+	for key, lang := range utils.OneSignalLanguages() {
 		loc := utils.NewLocalizer(lang)
 
 		// translate heading
@@ -343,7 +365,7 @@ func (n *NudgeWorker) NotifyBehaviorFollowUpWhenSelfIsInHighRiskActivity(ctx con
 	headings := map[string]string{}
 	contents := map[string]string{}
 
-	for key, lang := range background.OneSignalLanguageCode {
+	for key, lang := range utils.OneSignalLanguages() {
 		loc := utils.NewLocalizer(lang)
 
 		// translate heading
@@ -20,6 +20,7 @@ import (
 	"github.com/bitmark-inc/autonomy-api/external/cadence"
 	"github.com/bitmark-inc/autonomy-api/mocks"
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
 	"github.com/bitmark-inc/autonomy-api/utils"
 )
 
@@ -27,8 +28,8 @@ type anyTimestamp struct {
 }
 
 func (t *anyTimestamp) Matches(x interface{}) bool {
-	_, ok := x.(int64)
-	return ok
+	c, ok := x.(store.Cursor)
+	return ok && c.ID == "" && !c.Time.IsZero()
 }
 
 func (t *anyTimestamp) String() string {
@@ -489,25 +490,48 @@ func (ts *NudgeActivityTestSuite) TestNotifySymptomFollowUpActivity() {
 	ts.NoError(err)
 }
 
-func (ts *NudgeActivityTestSuite) TestNotifySymptomSpikeActivity() {
+func (ts *NudgeActivityTestSuite) TestNotifySymptomSpikeActivityV2() {
 	symptoms := []schema.Symptom{
 		schema.COVID19Symptoms[0],
 		schema.COVID19Symptoms[1],
 	}
 
-	symptomsIDs := []string{}
+	spikes := []schema.SymptomSpike{
+		{ID: schema.COVID19Symptoms[1].ID, Count: 10, Baseline: 1, Strength: 5},
+		{ID: schema.COVID19Symptoms[0].ID, Count: 5, Baseline: 1, Strength: 3.5},
+	}
 
-	for _, s := range symptoms {
-		symptomsIDs = append(symptomsIDs, s.ID)
+	// symptoms are listed from the strongest spike
+	ts.notificationMock.EXPECT().NotifyAccountByText(
+		gomock.Eq(ts.testAccountNumber),
+		gomock.AssignableToTypeOf(map[string]string{}),
+		gomock.AssignableToTypeOf(map[string]string{}),
+		gomock.Eq(map[string]interface{}{
+			"notification_type": "ACCOUNT_SYMPTOM_SPIKE",
+			"symptoms":          []string{schema.COVID19Symptoms[1].ID, schema.COVID19Symptoms[0].ID},
+			"strengths":         []float64{5, 3.5},
+		})).
+		Return(nil).Times(1)
+
+	_, err := ts.env.ExecuteActivity(ts.worker.NotifySymptomSpikeActivityV2, ts.testAccountNumber, symptoms, spikes)
+	ts.NoError(err)
+}
+
+func (ts *NudgeActivityTestSuite) TestNotifySymptomSpikeActivity() {
+	symptoms := []schema.Symptom{
+		schema.COVID19Symptoms[0],
+		schema.COVID19Symptoms[1],
 	}
 
+	// symptoms of workflows scheduled before strengths are kept in order
 	ts.notificationMock.EXPECT().NotifyAccountByText(
 		gomock.Eq(ts.testAccountNumber),
 		gomock.AssignableToTypeOf(map[string]string{}),
 		gomock.AssignableToTypeOf(map[string]string{}),
 		gomock.Eq(map[string]interface{}{
 			"notification_type": "ACCOUNT_SYMPTOM_SPIKE",
-			"symptoms":          symptomsIDs,
+			"symptoms":          []string{schema.COVID19Symptoms[0].ID, schema.COVID19Symptoms[1].ID},
+			"strengths":         []float64{0, 0},
 		})).
 		Return(nil).Times(1)
 
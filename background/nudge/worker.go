@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
-	"github.com/uber-go/tally"
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/activity"
 	"go.uber.org/cadence/worker"
@@ -15,6 +14,7 @@ import (
 	"github.com/bitmark-inc/autonomy-api/background"
 	"github.com/bitmark-inc/autonomy-api/external/cadence"
 	"github.com/bitmark-inc/autonomy-api/external/onesignal"
+	"github.com/bitmark-inc/autonomy-api/monitoring"
 	"github.com/bitmark-inc/autonomy-api/store"
 )
 
@@ -41,6 +41,7 @@ func NewNudgeWorker(domain string, mongo store.MongoStore) *NudgeWorker {
 func (n *NudgeWorker) Register() {
 	workflow.RegisterWithOptions(n.SymptomFollowUpNudgeWorkflow, workflow.RegisterOptions{Name: "SymptomFollowUpNudgeWorkflow"})
 	workflow.RegisterWithOptions(n.NotifySymptomSpikeWorkflow, workflow.RegisterOptions{Name: "NotifySymptomSpikeWorkflow"})
+	workflow.RegisterWithOptions(n.NotifySymptomSpikeWorkflowV2, workflow.RegisterOptions{Name: "NotifySymptomSpikeWorkflowV2"})
 	workflow.RegisterWithOptions(n.NotifyBehaviorOnEnteringRiskAreaWorkflow, workflow.RegisterOptions{Name: "NotifyBehaviorOnEnteringRiskAreaWorkflow"})
 	workflow.RegisterWithOptions(n.AccountSelfReportedHighRiskFollowUpWorkflow, workflow.RegisterOptions{Name: "AccountSelfReportedHighRiskFollowUpWorkflow"})
 	workflow.RegisterWithOptions(n.NotifyBehaviorFollowUpOnEnteringSymptomSpikeAreaWorkflow, workflow.RegisterOptions{Name: "NotifyBehaviorFollowUpOnEnteringSymptomSpikeAreaWorkflow"})
@@ -48,6 +49,7 @@ func (n *NudgeWorker) Register() {
 	activity.RegisterWithOptions(n.SymptomsNeedFollowUpActivity, activity.RegisterOptions{Name: "SymptomsNeedFollowUpActivity"})
 	activity.RegisterWithOptions(n.NotifySymptomFollowUpActivity, activity.RegisterOptions{Name: "NotifySymptomFollowUpActivity"})
 	activity.RegisterWithOptions(n.NotifySymptomSpikeActivity, activity.RegisterOptions{Name: "NotifySymptomSpikeActivity"})
+	activity.RegisterWithOptions(n.NotifySymptomSpikeActivityV2, activity.RegisterOptions{Name: "NotifySymptomSpikeActivityV2"})
 	activity.RegisterWithOptions(n.NotifyBehaviorNudgeActivity, activity.RegisterOptions{Name: "NotifyBehaviorNudgeActivity"})
 	activity.RegisterWithOptions(n.GetNotificationReceiverActivity, activity.RegisterOptions{Name: "GetNotificationReceiverActivity"})
 	activity.RegisterWithOptions(n.CheckSelfHasHighRiskSymptomsAndNeedToFollowUpActivity, activity.RegisterOptions{Name: "HighRiskAccountFollowUpActivity"})
@@ -57,9 +59,12 @@ func (n *NudgeWorker) Register() {
 func (n *NudgeWorker) Start(service workflowserviceclient.Interface, logger *zap.Logger) {
 	// TaskListName identifies set of client workflows, activities, and workers.
 	// It could be your group or client or application name.
+	metricsScope, closer := monitoring.NewTallyScope(TaskListName)
+	defer closer.Close()
+
 	workerOptions := worker.Options{
 		Logger:        logger,
-		MetricsScope:  tally.NewTestScope(TaskListName, map[string]string{}),
+		MetricsScope:  metricsScope,
 		DataConverter: cadence.NewMsgPackDataConverter(),
 	}
 
@@ -62,7 +62,8 @@ func (n *NudgeWorker) SymptomFollowUpNudgeWorkflow(ctx workflow.Context, account
 }
 
 // NotifySymptomSpikeWorkflow is a workflow that deliver symptoms spike notifications to related
-// accounts base on given account number or poi ID
+// accounts base on given account number or poi ID. It is kept for workflows scheduled before
+// NotifySymptomSpikeWorkflowV2.
 func (n *NudgeWorker) NotifySymptomSpikeWorkflow(ctx workflow.Context, accountNumber string, poiID string, symptoms []schema.Symptom) error {
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 
@@ -88,6 +89,33 @@ func (n *NudgeWorker) NotifySymptomSpikeWorkflow(ctx workflow.Context, accountNu
 	return nil
 }
 
+// NotifySymptomSpikeWorkflowV2 is a workflow that deliver symptoms spike notifications to related
+// accounts base on given account number or poi ID. Spikes tell how strong each symptom spikes.
+func (n *NudgeWorker) NotifySymptomSpikeWorkflowV2(ctx workflow.Context, accountNumber string, poiID string, symptoms []schema.Symptom, spikes []schema.SymptomSpike) error {
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	logger := workflow.GetLogger(ctx)
+
+	receivers := make([]string, 0)
+	if err := workflow.ExecuteActivity(ctx, n.GetNotificationReceiverActivity, accountNumber, poiID).Get(ctx, &receivers); err != nil {
+		logger.Error("Fail to get notification receivers", zap.Error(err))
+		return err
+	}
+
+	logger.Info("notify symptom spike", zap.Any("receivers", receivers), zap.Any("symptoms", symptoms))
+
+	for _, accountNumber := range receivers {
+		err := workflow.ExecuteActivity(ctx, n.NotifySymptomSpikeActivityV2, accountNumber, symptoms, spikes).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Fail to notify user", zap.Error(err))
+			sentry.CaptureException(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
 // NotifyBehaviorFollowUpOnEnteringSymptomSpikeAreaWorkflow is a workflow to send behavior follow up to
 // accounts that enters a symptom spike area. [NB_3-1]
 func (n *NudgeWorker) NotifyBehaviorFollowUpOnEnteringSymptomSpikeAreaWorkflow(ctx workflow.Context, accountNumber string) error {
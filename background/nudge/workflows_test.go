@@ -148,6 +148,27 @@ func (ts *NudgeWorkflowTestSuite) TestNotifySymptomSpikeWorkflowByAccountNumber(
 	ts.NoError(ts.env.GetWorkflowError())
 }
 
+func (ts *NudgeWorkflowTestSuite) TestNotifySymptomSpikeWorkflowV2ByAccountNumber() {
+
+	symptoms := []schema.Symptom{}
+
+	ts.env.OnActivity(ts.worker.GetNotificationReceiverActivity, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, accountNumber, poiID string) ([]string, error) {
+			ts.Equal(ts.testAccountNumber, accountNumber)
+			return []string{ts.testAccountNumber}, nil
+		})
+
+	ts.env.OnActivity(ts.worker.NotifySymptomSpikeActivityV2, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, accountNumber string, symptoms []schema.Symptom, spikes []schema.SymptomSpike) error {
+			ts.Equal(ts.testAccountNumber, accountNumber)
+			return nil
+		})
+
+	ts.env.ExecuteWorkflow(ts.worker.NotifySymptomSpikeWorkflowV2, ts.testAccountNumber, "", symptoms, []schema.SymptomSpike{})
+	ts.True(ts.env.IsWorkflowCompleted())
+	ts.NoError(ts.env.GetWorkflowError())
+}
+
 func (ts *NudgeWorkflowTestSuite) TestSymptomFollowUpNudgeWorkflowOneNudge() {
 	symptoms := []schema.Symptom{
 		schema.COVID19Symptoms[0],
@@ -3,6 +3,7 @@ package score
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -28,6 +29,14 @@ type NotificationProfile struct {
 	RemindGoodBehavior    bool
 }
 
+// spikeRenotifyStrength is how much stronger a spike needs to be to notify again in the same day
+const spikeRenotifyStrength = 1
+
+// strongerSpike checks if the strongest spike becomes notably stronger
+func strongerSpike(lastSpikes, spikes []schema.SymptomSpike) bool {
+	return score.SpikeStrength(spikes)-score.SpikeStrength(lastSpikes) >= spikeRenotifyStrength
+}
+
 // CalculatePOIStateActivity calculates metrics by the location of a POI
 func (s *ScoreUpdateWorker) CalculatePOIStateActivity(ctx context.Context, id string) (*schema.Metric, error) {
 	logger := activity.GetLogger(ctx)
@@ -66,17 +75,28 @@ func (s *ScoreUpdateWorker) CalculatePOIStateActivity(ctx context.Context, id st
 	if err != nil {
 		return nil, err
 	}
-	metric := score.CalculateMetric(*rawMetrics, nil)
+	metric := score.CalculateMetric(score.DefaultFormula(), *rawMetrics, nil)
 
 	return &metric, nil
 }
 
+// CheckLocationSpikeActivity returns symptoms of spikes of a location, from the strongest one
 func (s *ScoreUpdateWorker) CheckLocationSpikeActivity(ctx context.Context, spikeSymptomTypes []string) ([]schema.Symptom, error) {
 	if len(spikeSymptomTypes) > 0 {
 		symptoms, err := s.mongo.FindSymptomsByIDs(spikeSymptomTypes)
 		if err != nil {
 			return nil, err
 		}
+
+		// keep the order of spikes
+		order := make(map[string]int, len(spikeSymptomTypes))
+		for i, id := range spikeSymptomTypes {
+			order[id] = i
+		}
+		sort.SliceStable(symptoms, func(i, j int) bool {
+			return order[symptoms[i].ID] < order[symptoms[j].ID]
+		})
+
 		return symptoms, nil
 	}
 
@@ -104,13 +124,13 @@ func (s *ScoreUpdateWorker) RefreshLocationStateActivity(ctx context.Context, ac
 			return nil, err
 		}
 
-		autonomyScore, _, autonomyScoreDelta := score.CalculatePOIAutonomyScore(resourceMetric.Resources, metric)
+		autonomyScore, _, autonomyScoreDelta := score.CalculatePOIAutonomyScore(resourceMetric.PlaceType, resourceMetric.Resources, metric)
 
 		if err := s.mongo.UpdatePOIMetric(id, metric, autonomyScore, autonomyScoreDelta); err != nil {
 			return nil, err
 		}
 
-		if err := s.mongo.AddScoreRecord(poiID, schema.ScoreRecordTypePOI, autonomyScore, time.Now().UTC().Unix()); err != nil {
+		if err := s.mongo.AddScoreRecord(poiID, schema.ScoreRecordTypePOI, autonomyScore, metric.FormulaVersion, time.Now().UTC().Unix()); err != nil {
 			sentry.CaptureException(err)
 		}
 
@@ -150,7 +170,8 @@ func (s *ScoreUpdateWorker) RefreshLocationStateActivity(ctx context.Context, ac
 
 			if currentSpikeLength := len(metric.Details.Symptoms.LastSpikeList); currentSpikeLength > 0 {
 				if accountToday.Sub(lastSpikeDay) == 0 { // spike in the same day
-					if currentSpikeLength > len(poi.Metric.Details.Symptoms.LastSpikeList) {
+					if currentSpikeLength > len(poi.Metric.Details.Symptoms.LastSpikeList) ||
+						strongerSpike(poi.Metric.Details.Symptoms.LastSpikes, metric.Details.Symptoms.LastSpikes) {
 						symptomsSpikeAccounts = append(symptomsSpikeAccounts, profile.AccountNumber)
 					}
 				} else {
@@ -187,7 +208,8 @@ func (s *ScoreUpdateWorker) RefreshLocationStateActivity(ctx context.Context, ac
 		}
 
 		if time.Since(profile.LastNudge[schema.NudgeBehaviorOnSymptomSpikeArea]) > 90*time.Minute { // 90 minutes of delay between nudges
-			if profile.Metric.SymptomDelta < 10 && metric.SymptomDelta >= 10 { // from a non-spike area to a spike area
+			// from a non-spike area to a spike area
+			if score.SpikeStrength(profile.Metric.Details.Symptoms.LastSpikes) == 0 && score.SpikeStrength(metric.Details.Symptoms.LastSpikes) > 0 {
 				remindGoodBehavior = true
 			}
 		}
@@ -197,7 +219,8 @@ func (s *ScoreUpdateWorker) RefreshLocationStateActivity(ctx context.Context, ac
 
 		if currentSpikeLength := len(metric.Details.Symptoms.LastSpikeList); currentSpikeLength > 0 {
 			if spikeDayDelta := accountToday.Sub(lastSpikeDay); spikeDayDelta == 0 { // spike in the same day
-				if currentSpikeLength > len(profile.Metric.Details.Symptoms.LastSpikeList) {
+				if currentSpikeLength > len(profile.Metric.Details.Symptoms.LastSpikeList) ||
+					strongerSpike(profile.Metric.Details.Symptoms.LastSpikes, metric.Details.Symptoms.LastSpikes) {
 					symptomsSpikeAccounts = append(symptomsSpikeAccounts, profile.AccountNumber)
 				}
 			} else if spikeDayDelta > 0 {
@@ -288,12 +311,12 @@ func (s *ScoreUpdateWorker) CalculateAccountStateActivity(ctx context.Context, a
 		return nil, err
 	}
 
-	metric := score.CalculateMetric(*rawMetrics, profile.ScoreCoefficient)
+	metric := score.CalculateMetric(score.AccountFormula(accountNumber), *rawMetrics, profile.ScoreCoefficient)
 
 	// FIXME: `profile.IndividualMetric` could be outdated
 	// for users who don't use the app for a long time
 	score, _ := score.CalculateIndividualAutonomyScore(profile.IndividualMetric, metric)
-	if err := s.mongo.AddScoreRecord(accountNumber, schema.ScoreRecordTypeIndividual, score, time.Now().UTC().Unix()); err != nil {
+	if err := s.mongo.AddScoreRecord(accountNumber, schema.ScoreRecordTypeIndividual, score, metric.FormulaVersion, time.Now().UTC().Unix()); err != nil {
 		sentry.CaptureException(err)
 	}
 
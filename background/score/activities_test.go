@@ -224,8 +224,12 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountNorm
 // marked `RemindGoodBehavior` when he enters a symptom spike area.
 func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountEnterSymptomSpikeArea() {
 	metricToUpdate := schema.Metric{
-		Score:        55,
-		SymptomDelta: 10,
+		Score: 55,
+		Details: schema.Details{
+			Symptoms: schema.SymptomDetail{
+				LastSpikes: []schema.SymptomSpike{{ID: "cough", Count: 6, Baseline: 1, Strength: 4}},
+			},
+		},
 	}
 
 	ts.mongoMock.
@@ -237,9 +241,7 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountEnte
 			LastNudge: schema.NudgeTime{
 				schema.NudgeBehaviorOnSymptomSpikeArea: time.Now().Add(-100 * time.Minute),
 			},
-			Metric: schema.Metric{
-				SymptomDelta: 5,
-			},
+			Metric: schema.Metric{},
 		}, nil)
 
 	ts.mongoMock.
@@ -263,8 +265,12 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountEnte
 // marked `RemindGoodBehavior` when he stays in a symptom spike area.
 func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountStayInSymptomSpikeArea() {
 	metricToUpdate := schema.Metric{
-		Score:        55,
-		SymptomDelta: 10,
+		Score: 55,
+		Details: schema.Details{
+			Symptoms: schema.SymptomDetail{
+				LastSpikes: []schema.SymptomSpike{{ID: "cough", Count: 6, Baseline: 1, Strength: 4}},
+			},
+		},
 	}
 
 	ts.mongoMock.
@@ -277,7 +283,11 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountStay
 				schema.NudgeBehaviorOnSymptomSpikeArea: time.Now().Add(-100 * time.Minute),
 			},
 			Metric: schema.Metric{
-				SymptomDelta: 10,
+				Details: schema.Details{
+					Symptoms: schema.SymptomDetail{
+						LastSpikes: []schema.SymptomSpike{{ID: "cough", Count: 5, Baseline: 1, Strength: 4}},
+					},
+				},
 			},
 		}, nil)
 
@@ -302,8 +312,12 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountStay
 // marked `RemindGoodBehavior` when he enters in a symptom spike area again within 90 minutes.
 func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountEnterSymptomSpikeAreaAgainWithin90Minutes() {
 	metricToUpdate := schema.Metric{
-		Score:        55,
-		SymptomDelta: 10,
+		Score: 55,
+		Details: schema.Details{
+			Symptoms: schema.SymptomDetail{
+				LastSpikes: []schema.SymptomSpike{{ID: "cough", Count: 6, Baseline: 1, Strength: 4}},
+			},
+		},
 	}
 
 	ts.mongoMock.
@@ -315,9 +329,7 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForAccountEnte
 			LastNudge: schema.NudgeTime{
 				schema.NudgeBehaviorOnSymptomSpikeArea: time.Now().Add(-10 * time.Minute),
 			},
-			Metric: schema.Metric{
-				SymptomDelta: 5,
-			},
+			Metric: schema.Metric{},
 		}, nil)
 
 	ts.mongoMock.
@@ -565,7 +577,7 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForPOINormalRu
 
 	ts.mongoMock.
 		EXPECT().
-		AddScoreRecord(gomock.Eq(ts.testPOIID), schema.ScoreRecordTypePOI, 0.0, gomock.AssignableToTypeOf(int64(0))).
+		AddScoreRecord(gomock.Eq(ts.testPOIID), schema.ScoreRecordTypePOI, 0.0, "", gomock.AssignableToTypeOf(int64(0))).
 		Return(nil)
 
 	ts.mongoMock.
@@ -622,11 +634,11 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForPOILastSymp
 	ts.mongoMock.
 		EXPECT().
 		GetPOIResourceMetric(gomock.Eq(poiID)).
-		Return(schema.POIRatingsMetric{Resources: []schema.POIResourceRating{{SumOfScore: 5, Score: 5, Ratings: 1}}}, nil)
+		Return(schema.POIRatingsMetric{Resources: []schema.POIResourceRating{{SumOfScore: 20, Score: 4, Ratings: 5}}}, nil)
 
 	ts.mongoMock.
 		EXPECT().
-		UpdatePOIMetric(gomock.Eq(poiID), gomock.AssignableToTypeOf(schema.Metric{}), 99.6, 100.0).
+		UpdatePOIMetric(gomock.Eq(poiID), gomock.AssignableToTypeOf(schema.Metric{}), 75.6, 34.99999999999999).
 		Return(nil)
 
 	ts.mongoMock.
@@ -688,6 +700,7 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForPOILastSymp
 			gomock.Eq(ts.testPOIID),
 			schema.ScoreRecordTypePOI,
 			gomock.AssignableToTypeOf(float64(1)),
+			gomock.AssignableToTypeOf(""),
 			gomock.AssignableToTypeOf(int64(1)),
 		).
 		Return(nil)
@@ -725,11 +738,11 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForPOILastSymp
 	ts.mongoMock.
 		EXPECT().
 		GetPOIResourceMetric(gomock.Eq(poiID)).
-		Return(schema.POIRatingsMetric{Resources: []schema.POIResourceRating{{SumOfScore: 5, Score: 5, Ratings: 1}}}, nil)
+		Return(schema.POIRatingsMetric{Resources: []schema.POIResourceRating{{SumOfScore: 20, Score: 4, Ratings: 5}}}, nil)
 
 	ts.mongoMock.
 		EXPECT().
-		UpdatePOIMetric(gomock.Eq(poiID), gomock.AssignableToTypeOf(schema.Metric{}), 99.6, 100.0).
+		UpdatePOIMetric(gomock.Eq(poiID), gomock.AssignableToTypeOf(schema.Metric{}), 75.6, 34.99999999999999).
 		Return(nil)
 
 	ts.mongoMock.
@@ -792,6 +805,7 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForPOILastSymp
 			gomock.Eq(ts.testPOIID),
 			schema.ScoreRecordTypePOI,
 			gomock.AssignableToTypeOf(float64(1)),
+			gomock.AssignableToTypeOf(""),
 			gomock.AssignableToTypeOf(int64(1)),
 		).
 		Return(nil)
@@ -821,11 +835,11 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForPOILastStat
 	ts.mongoMock.
 		EXPECT().
 		GetPOIResourceMetric(gomock.Eq(poiID)).
-		Return(schema.POIRatingsMetric{Resources: []schema.POIResourceRating{{SumOfScore: 5, Score: 5, Ratings: 1}}}, nil)
+		Return(schema.POIRatingsMetric{Resources: []schema.POIResourceRating{{SumOfScore: 20, Score: 4, Ratings: 5}}}, nil)
 
 	ts.mongoMock.
 		EXPECT().
-		UpdatePOIMetric(gomock.Eq(poiID), gomock.AssignableToTypeOf(schema.Metric{}), 99.6, 100.0).
+		UpdatePOIMetric(gomock.Eq(poiID), gomock.AssignableToTypeOf(schema.Metric{}), 75.6, 34.99999999999999).
 		Return(nil)
 
 	ts.mongoMock.
@@ -865,6 +879,7 @@ func (ts *ScoreActivityTestSuite) TestRefreshLocationStateActivityForPOILastStat
 			gomock.Eq(ts.testPOIID),
 			schema.ScoreRecordTypePOI,
 			gomock.AssignableToTypeOf(float64(1)),
+			gomock.AssignableToTypeOf(""),
 			gomock.AssignableToTypeOf(int64(1)),
 		).
 		Return(nil)
@@ -937,6 +952,7 @@ func (ts *ScoreActivityTestSuite) TestCalculateAccountStateActivityNormal() {
 			gomock.Eq(ts.testAccountNumber),
 			schema.ScoreRecordTypeIndividual,
 			gomock.Eq(85.0), // 0.8*100 + 0.2*25
+			"v1",
 			gomock.AssignableToTypeOf(int64(1)),
 		).
 		Return(nil)
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
-	"github.com/uber-go/tally"
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/activity"
 	"go.uber.org/cadence/worker"
@@ -15,6 +14,7 @@ import (
 	"github.com/bitmark-inc/autonomy-api/background"
 	"github.com/bitmark-inc/autonomy-api/external/cadence"
 	"github.com/bitmark-inc/autonomy-api/external/onesignal"
+	"github.com/bitmark-inc/autonomy-api/monitoring"
 	"github.com/bitmark-inc/autonomy-api/store"
 )
 
@@ -54,9 +54,12 @@ func (s *ScoreUpdateWorker) Register() {
 func (s *ScoreUpdateWorker) Start(service workflowserviceclient.Interface, logger *zap.Logger) {
 	// TaskListName identifies set of client workflows, activities, and workers.
 	// It could be your group or client or application name.
+	metricsScope, closer := monitoring.NewTallyScope(TaskListName)
+	defer closer.Close()
+
 	workerOptions := worker.Options{
 		Logger:        logger,
-		MetricsScope:  tally.NewTestScope(TaskListName, map[string]string{}),
+		MetricsScope:  metricsScope,
 		DataConverter: cadence.NewMsgPackDataConverter(),
 	}
 
@@ -24,6 +24,18 @@ var activityOptions = workflow.ActivityOptions{
 	HeartbeatTimeout:       time.Second * 20,
 }
 
+// symptomSpikeStrengthsChange is the change of notifying symptom spikes with their strengths
+const symptomSpikeStrengthsChange = "symptom-spike-strengths"
+
+// notifySymptomSpike starts the nudge workflow of symptom spikes. Workflows which started the
+// first version before keep starting it, so that their histories can still be replayed.
+func notifySymptomSpike(ctx workflow.Context, accountNumber string, symptoms []schema.Symptom, spikes []schema.SymptomSpike) workflow.ChildWorkflowFuture {
+	if workflow.GetVersion(ctx, symptomSpikeStrengthsChange, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return workflow.ExecuteChildWorkflow(ctx, "NotifySymptomSpikeWorkflow", accountNumber, "", symptoms)
+	}
+	return workflow.ExecuteChildWorkflow(ctx, "NotifySymptomSpikeWorkflowV2", accountNumber, "", symptoms, spikes)
+}
+
 func (s *ScoreUpdateWorker) POIStateUpdateWorkflow(ctx workflow.Context, id string) error {
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 	signalChan := workflow.GetSignalChannel(ctx, "poiCheckSignal")
@@ -85,7 +97,7 @@ func (s *ScoreUpdateWorker) POIStateUpdateWorkflow(ctx workflow.Context, id stri
 				WorkflowIDReusePolicy:        cadenceClient.WorkflowIDReusePolicyAllowDuplicate,
 			}
 
-			if err := workflow.ExecuteChildWorkflow(workflow.WithChildOptions(ctx, cwo), "NotifySymptomSpikeWorkflow", a, "", spikeSymptoms).Get(ctx, nil); err != nil {
+			if err := notifySymptomSpike(workflow.WithChildOptions(ctx, cwo), a, spikeSymptoms, metric.Details.Symptoms.LastSpikes).Get(ctx, nil); err != nil {
 				logger.Error("NotifySymptomSpikeWorkflow failed.", zap.Error(err))
 				sentry.CaptureException(err)
 			}
@@ -189,7 +201,7 @@ func (s *ScoreUpdateWorker) AccountStateUpdateWorkflow(ctx workflow.Context, acc
 				WorkflowIDReusePolicy:        cadenceClient.WorkflowIDReusePolicyAllowDuplicate,
 			}
 
-			if err := workflow.ExecuteChildWorkflow(workflow.WithChildOptions(ctx, cwo), "NotifySymptomSpikeWorkflow", a, "", spikeSymptoms).Get(ctx, nil); err != nil {
+			if err := notifySymptomSpike(workflow.WithChildOptions(ctx, cwo), a, spikeSymptoms, metric.Details.Symptoms.LastSpikes).Get(ctx, nil); err != nil {
 				logger.Error("NotifySymptomSpikeWorkflow failed.", zap.Error(err))
 				sentry.CaptureException(err)
 			}
@@ -129,7 +129,7 @@ func (ts *ScoreWorkflowTestSuite) TestAccountStateUpdateWorkflowStateChange() {
 }
 
 // TestAccountStateUpdateWorkflowNotifySpike validate whether
-// `NotifySymptomSpikeWorkflow` is triggered when there are new symptom spikes
+// `NotifySymptomSpikeWorkflowV2` is triggered when there are new symptom spikes
 // found for the account
 func (ts *ScoreWorkflowTestSuite) TestAccountStateUpdateWorkflowNotifySpike() {
 	ts.env.OnActivity(ts.worker.CalculateAccountStateActivity, mock.Anything, mock.Anything).Return(
@@ -156,8 +156,8 @@ func (ts *ScoreWorkflowTestSuite) TestAccountStateUpdateWorkflowNotifySpike() {
 			}, nil
 		})
 
-	ts.env.OnWorkflow("NotifySymptomSpikeWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
-		func(ctx workflow.Context, accountNumber string, poiID string, symptoms []schema.Symptom) error {
+	ts.env.OnWorkflow("NotifySymptomSpikeWorkflowV2", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx workflow.Context, accountNumber string, poiID string, symptoms []schema.Symptom, spikes []schema.SymptomSpike) error {
 			ts.Equal([]schema.Symptom{
 				schema.COVID19Symptoms[0],
 				schema.COVID19Symptoms[1],
@@ -170,7 +170,7 @@ func (ts *ScoreWorkflowTestSuite) TestAccountStateUpdateWorkflowNotifySpike() {
 	ts.env.AssertNumberOfCalls(ts.T(), "CalculateAccountStateActivity", 1)
 	ts.env.AssertNumberOfCalls(ts.T(), "RefreshLocationStateActivity", 1)
 	ts.env.AssertNumberOfCalls(ts.T(), "CheckLocationSpikeActivity", 1)
-	ts.env.AssertNumberOfCalls(ts.T(), "NotifySymptomSpikeWorkflow", 2)
+	ts.env.AssertNumberOfCalls(ts.T(), "NotifySymptomSpikeWorkflowV2", 2)
 	ts.True(ts.env.IsWorkflowCompleted())
 	ts.EqualError(ts.env.GetWorkflowError(), "ContinueAsNew")
 }
@@ -361,8 +361,8 @@ func (ts *ScoreWorkflowTestSuite) TestPOIStateUpdateWorkflowWithSymptomSpike() {
 			}, nil
 		})
 
-	ts.env.OnWorkflow("NotifySymptomSpikeWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
-		func(ctx workflow.Context, accountNumber string, poiID string, symptoms []schema.Symptom) error {
+	ts.env.OnWorkflow("NotifySymptomSpikeWorkflowV2", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx workflow.Context, accountNumber string, poiID string, symptoms []schema.Symptom, spikes []schema.SymptomSpike) error {
 			ts.Equal([]schema.Symptom{
 				schema.COVID19Symptoms[0],
 				schema.COVID19Symptoms[1],
@@ -375,7 +375,7 @@ func (ts *ScoreWorkflowTestSuite) TestPOIStateUpdateWorkflowWithSymptomSpike() {
 	ts.env.AssertNumberOfCalls(ts.T(), "CalculatePOIStateActivity", 1)
 	ts.env.AssertNumberOfCalls(ts.T(), "RefreshLocationStateActivity", 1)
 	ts.env.AssertNumberOfCalls(ts.T(), "CheckLocationSpikeActivity", 1)
-	ts.env.AssertNumberOfCalls(ts.T(), "NotifySymptomSpikeWorkflow", 2)
+	ts.env.AssertNumberOfCalls(ts.T(), "NotifySymptomSpikeWorkflowV2", 2)
 	ts.True(ts.env.IsWorkflowCompleted())
 	ts.EqualError(ts.env.GetWorkflowError(), "ContinueAsNew")
 }
@@ -383,3 +383,28 @@ func (ts *ScoreWorkflowTestSuite) TestPOIStateUpdateWorkflowWithSymptomSpike() {
 func TestScoreUpdateWorkflow(t *testing.T) {
 	suite.Run(t, new(ScoreWorkflowTestSuite))
 }
+
+// TestPOIStateUpdateWorkflowWithSymptomSpikeBeforeStrengths tests workflows which started
+// notifying symptom spikes before strengths were sent keep starting the first version
+func (ts *ScoreWorkflowTestSuite) TestPOIStateUpdateWorkflowWithSymptomSpikeBeforeStrengths() {
+	ts.env.OnGetVersion(symptomSpikeStrengthsChange, workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+
+	ts.env.OnActivity(ts.worker.CalculatePOIStateActivity, mock.Anything, mock.Anything).Return(twoSpikeMetric, nil)
+	ts.env.OnActivity(ts.worker.RefreshLocationStateActivity, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&NotificationProfile{SymptomsSpikeAccounts: []string{fakeAccount1}}, nil)
+	ts.env.OnActivity(ts.worker.CheckLocationSpikeActivity, mock.Anything, mock.Anything, mock.Anything).Return(
+		[]schema.Symptom{schema.COVID19Symptoms[0]}, nil)
+
+	ts.env.OnWorkflow("NotifySymptomSpikeWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx workflow.Context, accountNumber string, poiID string, symptoms []schema.Symptom) error {
+			ts.Equal(fakeAccount1, accountNumber)
+			ts.Equal([]schema.Symptom{schema.COVID19Symptoms[0]}, symptoms)
+			return nil
+		})
+
+	ts.env.ExecuteWorkflow(ts.worker.POIStateUpdateWorkflow, ts.testPOIID)
+
+	ts.env.AssertNumberOfCalls(ts.T(), "NotifySymptomSpikeWorkflow", 1)
+	ts.True(ts.env.IsWorkflowCompleted())
+	ts.EqualError(ts.env.GetWorkflowError(), "ContinueAsNew")
+}
@@ -63,3 +63,7 @@ func (c *CadenceClient) SignalWithStartWorkflow(ctx context.Context,
 	options client.StartWorkflowOptions, workflow interface{}, workflowArgs ...interface{}) (*workflow.Execution, error) {
 	return c.client.SignalWithStartWorkflow(ctx, workflowID, signalName, signalArg, options, workflow, workflowArgs...)
 }
+
+func (c *CadenceClient) CancelWorkflow(ctx context.Context, workflowID string, runID string) error {
+	return c.client.CancelWorkflow(ctx, workflowID, runID)
+}
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httputil"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+
+	"github.com/bitmark-inc/autonomy-api/monitoring"
 )
 
 const ErrMsgAllPlayersNotSubscribed = "All included players are not subscribed"
@@ -101,7 +104,11 @@ func (os *OneSignalClient) createRequest(ctx context.Context, method, path strin
 	return req, nil
 }
 
-func (os *OneSignalClient) SendNotification(ctx context.Context, reqBody *NotificationRequest) error {
+func (os *OneSignalClient) SendNotification(ctx context.Context, reqBody *NotificationRequest) (err error) {
+	defer func(start time.Time) {
+		monitoring.ObserveNotification(start, err)
+	}(time.Now())
+
 	req, err := os.createRequest(ctx, "POST", "/api/v1/notifications", reqBody)
 
 	if err != nil {
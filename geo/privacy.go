@@ -0,0 +1,224 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const (
+	CoarsenByGrid    = "grid"
+	CoarsenByGeohash = "geohash"
+
+	metersPerDegree = 111320.0
+
+	geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+)
+
+var ErrUnknownCoarsenMethod = fmt.Errorf("unknown location coarsen method")
+
+// LocationCoarsener reduces the precision of a location before it is persisted,
+// so that a stored location could not be traced back to a specific address.
+type LocationCoarsener interface {
+	Coarsen(schema.Location) schema.Location
+}
+
+// NewLocationCoarsener returns a coarsener of a method. `size` is the edge length in meters
+// of a grid cell for `grid` or the number of characters for `geohash`. If `jitter` is
+// positive, a coarsened location is moved randomly within the distance (in meters).
+func NewLocationCoarsener(method string, size float64, jitter float64) (LocationCoarsener, error) {
+	switch method {
+	case CoarsenByGrid:
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid grid size: %f", size)
+		}
+		return &GridCoarsener{CellSize: size, Jitter: jitter}, nil
+	case CoarsenByGeohash:
+		if size < 1 || size > 12 {
+			return nil, fmt.Errorf("invalid geohash precision: %f", size)
+		}
+		return &GeohashCoarsener{Precision: int(size), Jitter: jitter}, nil
+	default:
+		return nil, ErrUnknownCoarsenMethod
+	}
+}
+
+// GridCoarsener snaps a location to the center of a grid cell. The error of a snapped
+// location is at most half of the diagonal of a cell.
+type GridCoarsener struct {
+	CellSize float64
+	Jitter   float64
+
+	// Rand is the source of jitter, which is the global source if it is nil.
+	// It should be nil if the coarsener is used concurrently.
+	Rand *rand.Rand
+}
+
+func (g *GridCoarsener) Coarsen(loc schema.Location) schema.Location {
+	latStep := g.CellSize / metersPerDegree
+	lat := snapToCenter(loc.Latitude, latStep)
+
+	// cells are narrower in degrees of longitude as they get close to poles
+	lngStep := g.CellSize / (metersPerDegree * math.Max(math.Cos(lat*math.Pi/180), 1e-6))
+	lng := snapToCenter(loc.Longitude, lngStep)
+
+	loc.Latitude, loc.Longitude = jitter(g.Rand, clampLatitude(lat), normalizeLongitude(lng), g.Jitter)
+	return loc
+}
+
+// MaxError returns the maximal distance in meters between a location and its coarsened one
+func (g *GridCoarsener) MaxError() float64 {
+	return g.CellSize*math.Sqrt2/2 + math.Max(g.Jitter, 0)
+}
+
+// GeohashCoarsener snaps a location to the center of its geohash cell
+type GeohashCoarsener struct {
+	Precision int
+	Jitter    float64
+
+	// Rand is the source of jitter, which is the global source if it is nil.
+	// It should be nil if the coarsener is used concurrently.
+	Rand *rand.Rand
+}
+
+func (g *GeohashCoarsener) Coarsen(loc schema.Location) schema.Location {
+	lat, lng := GeohashCenter(Geohash(loc.Latitude, loc.Longitude, g.Precision))
+	loc.Latitude, loc.Longitude = jitter(g.Rand, lat, lng, g.Jitter)
+	return loc
+}
+
+// MaxError returns the maximal distance in meters between a location and its coarsened one
+func (g *GeohashCoarsener) MaxError() float64 {
+	latBits := (5 * g.Precision) / 2
+	lngBits := 5*g.Precision - latBits
+	height := 180 / math.Pow(2, float64(latBits)) * metersPerDegree
+	width := 360 / math.Pow(2, float64(lngBits)) * metersPerDegree
+	return math.Hypot(height, width)/2 + math.Max(g.Jitter, 0)
+}
+
+// Geohash encodes a location into a geohash of the given number of characters
+func Geohash(lat, lng float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	hash := make([]byte, 0, precision)
+	bits, ch := 0, 0
+	even := true
+	for len(hash) < precision {
+		if even {
+			ch = ch<<1 | bisect(&lngRange, lng)
+		} else {
+			ch = ch<<1 | bisect(&latRange, lat)
+		}
+		even = !even
+
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+
+	return string(hash)
+}
+
+// GeohashCenter decodes a geohash into the center of its cell
+func GeohashCenter(hash string) (float64, float64) {
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	even := true
+	for i := 0; i < len(hash); i++ {
+		ch := indexOfGeohashChar(hash[i])
+		for mask := 16; mask > 0; mask >>= 1 {
+			r := &latRange
+			if even {
+				r = &lngRange
+			}
+			mid := (r[0] + r[1]) / 2
+			if ch&mask != 0 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+
+	return (latRange[0] + latRange[1]) / 2, (lngRange[0] + lngRange[1]) / 2
+}
+
+func bisect(r *[2]float64, value float64) int {
+	mid := (r[0] + r[1]) / 2
+	if value >= mid {
+		r[0] = mid
+		return 1
+	}
+	r[1] = mid
+	return 0
+}
+
+func indexOfGeohashChar(c byte) int {
+	for i := 0; i < len(geohashAlphabet); i++ {
+		if geohashAlphabet[i] == c {
+			return i
+		}
+	}
+	return 0
+}
+
+func snapToCenter(value, step float64) float64 {
+	return (math.Floor(value/step) + 0.5) * step
+}
+
+func clampLatitude(lat float64) float64 {
+	return math.Max(-90, math.Min(90, lat))
+}
+
+func normalizeLongitude(lng float64) float64 {
+	for lng > 180 {
+		lng -= 360
+	}
+	for lng < -180 {
+		lng += 360
+	}
+	return lng
+}
+
+// jitter moves a location to a random position within a distance in meters
+func jitter(r *rand.Rand, lat, lng, distance float64) (float64, float64) {
+	if distance <= 0 {
+		return lat, lng
+	}
+
+	random := rand.Float64
+	if r != nil {
+		random = r.Float64
+	}
+
+	// uniformly distributed in the disc
+	d := distance * math.Sqrt(random())
+	bearing := 2 * math.Pi * random()
+
+	lat += d * math.Cos(bearing) / metersPerDegree
+	lng += d * math.Sin(bearing) / (metersPerDegree * math.Max(math.Cos(lat*math.Pi/180), 1e-6))
+
+	return clampLatitude(lat), normalizeLongitude(lng)
+}
+
+var defaultCoarsener LocationCoarsener
+
+func SetLocationCoarsener(coarsener LocationCoarsener) {
+	defaultCoarsener = coarsener
+}
+
+// CoarsenLocation coarsens a location by the default coarsener. The location
+// is returned as it is if no coarsener is set.
+func CoarsenLocation(loc schema.Location) schema.Location {
+	if defaultCoarsener == nil {
+		return loc
+	}
+
+	return defaultCoarsener.Coarsen(loc)
+}
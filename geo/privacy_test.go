@@ -0,0 +1,131 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/consts"
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+var privacyTestCenter = schema.Location{Latitude: 25.0478, Longitude: 121.5170}
+
+func TestGeohash(t *testing.T) {
+	assert.Equal(t, "ezs42", Geohash(42.605, -5.603, 5))
+	assert.Equal(t, "wsqqmpv", Geohash(25.0478, 121.5170, 7))
+
+	lat, lng := GeohashCenter("ezs42")
+	assert.InDelta(t, 42.605, lat, 0.03)
+	assert.InDelta(t, -5.603, lng, 0.03)
+}
+
+func TestCoarsenerMaxError(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	coarseners := []interface {
+		LocationCoarsener
+		MaxError() float64
+	}{
+		&GridCoarsener{CellSize: 200},
+		&GridCoarsener{CellSize: 200, Jitter: 100},
+		&GeohashCoarsener{Precision: 7},
+		&GeohashCoarsener{Precision: 8, Jitter: 50},
+	}
+
+	for _, c := range coarseners {
+		for i := 0; i < 1000; i++ {
+			loc := schema.Location{
+				Latitude:  r.Float64()*160 - 80,
+				Longitude: r.Float64()*360 - 180,
+			}
+			coarsened := c.Coarsen(loc)
+			distance := utils.GreatCircleDistance(loc.Latitude, loc.Longitude, coarsened.Latitude, coarsened.Longitude)
+			assert.LessOrEqual(t, distance, c.MaxError()*1.01, "%T %v", c, loc)
+		}
+	}
+}
+
+func TestCoarsenerIsStable(t *testing.T) {
+	// locations in the same cell are stored as the same location
+	for _, c := range []LocationCoarsener{&GridCoarsener{CellSize: 200}, &GeohashCoarsener{Precision: 7}} {
+		coarsened := c.Coarsen(privacyTestCenter)
+		assert.Equal(t, coarsened, c.Coarsen(coarsened), "%T", c)
+	}
+}
+
+func TestCoarsenLocationWithoutCoarsener(t *testing.T) {
+	SetLocationCoarsener(nil)
+	assert.Equal(t, privacyTestCenter, CoarsenLocation(privacyTestCenter))
+
+	SetLocationCoarsener(&GridCoarsener{CellSize: 1000})
+	defer SetLocationCoarsener(nil)
+	assert.NotEqual(t, privacyTestCenter, CoarsenLocation(privacyTestCenter))
+}
+
+// TestCoarsenerNearbyError quantifies how coarsening changes the reports found by the
+// `$geoNear` stage of `CollectRawMetrics`. Reports are spread uniformly around a location
+// and counted within `NEARBY_DISTANCE_RANGE` before and after they are coarsened.
+// Only reports close to the boundary could be counted wrongly.
+func TestCoarsenerNearbyError(t *testing.T) {
+	testCases := []struct {
+		coarsener          LocationCoarsener
+		maxCountError      float64 // relative error of the number of nearby reports
+		maxMisclassifyRate float64 // ratio of nearby reports which are no longer nearby, or vice versa
+	}{
+		{&GridCoarsener{CellSize: 100}, 0.02, 0.06},
+		{&GridCoarsener{CellSize: 200}, 0.03, 0.12},
+		{&GridCoarsener{CellSize: 200, Jitter: 100}, 0.03, 0.16},
+		{&GeohashCoarsener{Precision: 7}, 0.03, 0.09},
+		// cells comparable to the radius bias the number of nearby reports
+		{&GridCoarsener{CellSize: 500}, 0.15, 0.30},
+	}
+
+	radius := float64(consts.NEARBY_DISTANCE_RANGE)
+	for _, tc := range testCases {
+		r := rand.New(rand.NewSource(42))
+
+		// jitter must be independent of the locations
+		switch c := tc.coarsener.(type) {
+		case *GridCoarsener:
+			c.Rand = rand.New(rand.NewSource(7))
+		case *GeohashCoarsener:
+			c.Rand = rand.New(rand.NewSource(7))
+		}
+
+		exactCount, coarsenedCount, misclassified := 0, 0, 0
+		for i := 0; i < 20000; i++ {
+			// uniformly distributed within twice of the radius
+			d := 2 * radius * math.Sqrt(r.Float64())
+			bearing := 2 * math.Pi * r.Float64()
+			loc := schema.Location{
+				Latitude:  privacyTestCenter.Latitude + d*math.Cos(bearing)/metersPerDegree,
+				Longitude: privacyTestCenter.Longitude + d*math.Sin(bearing)/(metersPerDegree*math.Cos(privacyTestCenter.Latitude*math.Pi/180)),
+			}
+			coarsened := tc.coarsener.Coarsen(loc)
+
+			exact := utils.GreatCircleDistance(privacyTestCenter.Latitude, privacyTestCenter.Longitude, loc.Latitude, loc.Longitude) <= radius
+			near := utils.GreatCircleDistance(privacyTestCenter.Latitude, privacyTestCenter.Longitude, coarsened.Latitude, coarsened.Longitude) <= radius
+
+			if exact {
+				exactCount++
+			}
+			if near {
+				coarsenedCount++
+			}
+			if exact != near {
+				misclassified++
+			}
+		}
+
+		countError := math.Abs(float64(coarsenedCount-exactCount)) / float64(exactCount)
+		misclassifyRate := float64(misclassified) / float64(exactCount)
+		t.Logf("%T %+v: nearby %d -> %d, count error %.2f%%, misclassified %.2f%%", tc.coarsener, tc.coarsener, exactCount, coarsenedCount, countError*100, misclassifyRate*100)
+
+		assert.LessOrEqual(t, countError, tc.maxCountError, "%T %+v", tc.coarsener, tc.coarsener)
+		assert.LessOrEqual(t, misclassifyRate, tc.maxMisclassifyRate, "%T %+v", tc.coarsener, tc.coarsener)
+	}
+}
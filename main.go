@@ -28,6 +28,9 @@ import (
 	"github.com/bitmark-inc/autonomy-api/api"
 	"github.com/bitmark-inc/autonomy-api/external/aqi"
 	"github.com/bitmark-inc/autonomy-api/geo"
+	"github.com/bitmark-inc/autonomy-api/monitoring"
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
 	"github.com/bitmark-inc/autonomy-api/store"
 	"github.com/bitmark-inc/autonomy-api/utils"
 
@@ -123,8 +126,9 @@ func main() {
 	initLog()
 
 	utils.InitI18NBundle()
-	utils.PanicIfError(store.LoadDefaultPOIResources("en"))
-	utils.PanicIfError(store.LoadDefaultPOIResources("zh_tw"))
+	for _, lang := range utils.Languages() {
+		utils.PanicIfError(store.LoadDefaultPOIResources(lang))
+	}
 
 	httpClient := &http.Client{
 		Timeout: 10 * time.Second,
@@ -173,10 +177,12 @@ func main() {
 	if err != nil {
 		log.Panic(err)
 	}
+	monitoring.RegisterGormCallbacks(ormDB)
 
 	// initialise mongodb connections
 	opts := options.Client().ApplyURI(viper.GetString("mongo.conn"))
 	opts.SetMaxPoolSize(viper.GetUint64("mongo.pool"))
+	opts.SetMonitor(monitoring.MongoCommandMonitor())
 	mongoClient, err := mongo.NewClient(opts)
 	if nil != err {
 		log.Panicf("create mongo client with error: %s", err)
@@ -201,6 +207,51 @@ func main() {
 
 	geo.SetLocationSearcher(geo.NewNominatimSearcher(viper.GetString("nominatim.endpoint")))
 
+	if method := viper.GetString("privacy.location.method"); method != "" {
+		coarsener, err := geo.NewLocationCoarsener(method,
+			viper.GetFloat64("privacy.location.size"),
+			viper.GetFloat64("privacy.location.jitter"))
+		if err != nil {
+			log.Panicf("init location coarsener with error: %s", err)
+		}
+		geo.SetLocationCoarsener(coarsener)
+	}
+
+	if err := score.SetFormulaConfig(score.FormulaConfig{
+		Version:        viper.GetString("score.formula.version"),
+		RolloutVersion: viper.GetString("score.formula.rollout.version"),
+		RolloutPercent: viper.GetInt("score.formula.rollout.percent"),
+	}); err != nil {
+		log.Panicf("set score formula with error: %s", err)
+	}
+
+	var spikeConfig schema.SpikeConfig
+	var spikeAreas map[string]schema.SpikeConfig
+	if err := viper.UnmarshalKey("score.spike", &spikeConfig); err != nil {
+		log.Panicf("read symptom spike config with error: %s", err)
+	}
+	if err := viper.UnmarshalKey("score.spike.areas", &spikeAreas); err != nil {
+		log.Panicf("read symptom spike config with error: %s", err)
+	}
+	if err := score.SetSpikeConfig(spikeConfig, spikeAreas); err != nil {
+		log.Panicf("set symptom spike config with error: %s", err)
+	}
+
+	if err := score.SetDecayConfig(score.DecayConfig{
+		HalfLife: viper.GetDuration("score.decay.half_life"),
+		Window:   viper.GetDuration("score.decay.window"),
+	}); err != nil {
+		log.Panicf("set report decay config with error: %s", err)
+	}
+
+	var ratingConfig score.RatingConfig
+	if err := viper.UnmarshalKey("score.rating", &ratingConfig); err != nil {
+		log.Panicf("read poi rating config with error: %s", err)
+	}
+	if err := score.SetRatingConfig(ratingConfig); err != nil {
+		log.Panicf("set poi rating config with error: %s", err)
+	}
+
 	aqiClient := aqi.New(viper.GetString("aqi.key"), "")
 
 	// Init http server
@@ -0,0 +1,100 @@
+// Package monitoring collects runtime metrics of the API server and the
+// background workers and exposes them in the Prometheus text format.
+package monitoring
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "autonomy"
+
+// Registry holds all metrics exposed by this process
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	storeOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "store_operation_duration_seconds",
+		Help:      "Latency of database operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"database", "operation", "collection", "result"})
+
+	notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Number of notifications sent to OneSignal by result.",
+	}, []string{"result"})
+
+	notificationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "notification_duration_seconds",
+		Help:      "Latency of sending notifications to OneSignal.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		storeOperationDuration,
+		notifications,
+		notificationDuration,
+	)
+}
+
+// Handler returns an http handler which serves metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// APIKeyHandler serves metrics only to requests with the API key in the `Api-Token` header,
+// as the API server does for its metrics route. Every request is forbidden without a key.
+func APIKeyHandler(key string) http.Handler {
+	handler := Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiToken := r.Header.Get("Api-Token")
+		if apiToken == "" || apiToken != key {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ObserveStoreOperation records the latency of a database operation
+func ObserveStoreOperation(database, operation, collection string, duration time.Duration, err error) {
+	storeOperationDuration.WithLabelValues(database, operation, collection, result(err)).Observe(duration.Seconds())
+}
+
+// ObserveNotification records the result of sending a notification
+func ObserveNotification(start time.Time, err error) {
+	notifications.WithLabelValues(result(err)).Inc()
+	notificationDuration.Observe(time.Since(start).Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
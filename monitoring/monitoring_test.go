@@ -0,0 +1,92 @@
+package monitoring
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Gin())
+	r.GET("/items/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/not-found"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/items/:id", "204")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveStoreOperation("mongo", "find", "profile", 10*time.Millisecond, nil)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(),
+		`autonomy_store_operation_duration_seconds_count{collection="profile",database="mongo",operation="find",result="success"} 1`))
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	request := func(handler http.Handler, key string) int {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		if key != "" {
+			r.Header.Set("Api-Token", key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(APIKeyHandler("metric-key"), "metric-key"))
+	assert.Equal(t, http.StatusForbidden, request(APIKeyHandler("metric-key"), "other-key"))
+	assert.Equal(t, http.StatusForbidden, request(APIKeyHandler("metric-key"), ""))
+	assert.Equal(t, http.StatusForbidden, request(APIKeyHandler(""), ""))
+}
+
+func TestTallyReporter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	r := newTallyReporter(registry)
+
+	r.ReportCounter("cadence-worker.poll", map[string]string{"task-list": "a"}, 2)
+	r.ReportCounter("cadence-worker.poll", map[string]string{"task-list": "a"}, 3)
+	r.ReportGauge("cadence-worker.pending", nil, 7)
+	r.ReportTimer("cadence-worker.latency", nil, 2*time.Second)
+
+	assert.Equal(t, float64(5), testutil.ToFloat64(r.counters["cadence_worker_poll"].WithLabelValues("a")))
+	assert.Equal(t, float64(7), testutil.ToFloat64(r.gauges["cadence_worker_pending"].WithLabelValues()))
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	var histogramCount uint64
+	for _, f := range families {
+		if f.GetName() == "cadence_worker_latency_seconds" {
+			histogramCount = f.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	assert.Equal(t, uint64(1), histogramCount)
+
+	// metrics reported with inconsistent tags are dropped instead of panicking
+	assert.NotPanics(t, func() {
+		r.ReportCounter("cadence-worker.poll", map[string]string{"other": "b"}, 1)
+	})
+	assert.Equal(t, float64(5), testutil.ToFloat64(r.counters["cadence_worker_poll"].WithLabelValues("a")))
+}
+
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "autonomy_score_tasks", sanitizeName("autonomy-score-tasks"))
+	assert.Equal(t, "cadence_worker_poll", sanitizeName("cadence.worker.poll"))
+}
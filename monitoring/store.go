@@ -0,0 +1,80 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoCommandMonitor returns a command monitor which measures every command
+// sent to mongodb. Use it with `options.Client().SetMonitor`.
+func MongoCommandMonitor() *event.CommandMonitor {
+	var collections sync.Map
+
+	finish := func(requestID int64, command string, duration time.Duration, err error) {
+		collection := ""
+		if v, ok := collections.Load(requestID); ok {
+			collection = v.(string)
+			collections.Delete(requestID)
+		}
+		ObserveStoreOperation("mongo", command, collection, duration, err)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			// the first element of a command is its name and the collection
+			if collection, ok := evt.Command.Index(0).Value().StringValueOK(); ok {
+				collections.Store(evt.RequestID, collection)
+			} else {
+				collections.Store(evt.RequestID, "")
+			}
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finish(evt.RequestID, evt.CommandName, time.Duration(evt.DurationNanos), nil)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finish(evt.RequestID, evt.CommandName, time.Duration(evt.DurationNanos), errCommandFailed)
+		},
+	}
+}
+
+var errCommandFailed = errors.New("command failed")
+
+const gormStartKey = "monitoring:start_time"
+
+// RegisterGormCallbacks measures create, query, update and delete operations of gorm
+func RegisterGormCallbacks(db *gorm.DB) {
+	before := func(scope *gorm.Scope) {
+		scope.Set(gormStartKey, time.Now())
+	}
+
+	after := func(operation string) func(scope *gorm.Scope) {
+		return func(scope *gorm.Scope) {
+			v, ok := scope.Get(gormStartKey)
+			if !ok {
+				return
+			}
+			start, ok := v.(time.Time)
+			if !ok {
+				return
+			}
+			ObserveStoreOperation("postgres", operation, scope.TableName(), time.Since(start), scope.DB().Error)
+		}
+	}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("monitoring:before_create", before)
+	callback.Create().After("gorm:create").Register("monitoring:after_create", after("create"))
+	callback.Query().Before("gorm:query").Register("monitoring:before_query", before)
+	callback.Query().After("gorm:query").Register("monitoring:after_query", after("query"))
+	callback.Update().Before("gorm:update").Register("monitoring:before_update", before)
+	callback.Update().After("gorm:update").Register("monitoring:after_update", after("update"))
+	callback.Delete().Before("gorm:delete").Register("monitoring:before_delete", before)
+	callback.Delete().After("gorm:delete").Register("monitoring:after_delete", after("delete"))
+	callback.RowQuery().Before("gorm:row_query").Register("monitoring:before_row_query", before)
+	callback.RowQuery().After("gorm:row_query").Register("monitoring:after_row_query", after("row_query"))
+}
@@ -0,0 +1,165 @@
+package monitoring
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
+)
+
+// NewTallyScope returns a tally scope whose metrics are exported through Registry.
+// It is used as the metrics scope of cadence workers.
+func NewTallyScope(prefix string) (tally.Scope, io.Closer) {
+	return tally.NewRootScope(tally.ScopeOptions{
+		Prefix:    sanitizeName(prefix),
+		Separator: "_",
+		Reporter:  newTallyReporter(Registry),
+	}, time.Second)
+}
+
+type tallyCapabilities struct{}
+
+func (tallyCapabilities) Reporting() bool { return true }
+func (tallyCapabilities) Tagging() bool   { return true }
+
+// tallyReporter is a tally.StatsReporter which reports into prometheus collectors.
+// Collectors are created lazily since tally does not declare metrics in advance.
+type tallyReporter struct {
+	sync.Mutex
+	registerer prometheus.Registerer
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+func newTallyReporter(registerer prometheus.Registerer) *tallyReporter {
+	return &tallyReporter{
+		registerer: registerer,
+		counters:   map[string]*prometheus.CounterVec{},
+		gauges:     map[string]*prometheus.GaugeVec{},
+		histograms: map[string]*prometheus.HistogramVec{},
+	}
+}
+
+func (r *tallyReporter) Capabilities() tally.Capabilities {
+	return tallyCapabilities{}
+}
+
+func (r *tallyReporter) Flush() {}
+
+func (r *tallyReporter) ReportCounter(name string, tags map[string]string, value int64) {
+	labelNames, labels := sanitizeTags(tags)
+	name = sanitizeName(name)
+
+	r.Lock()
+	vec, ok := r.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: name}, labelNames)
+		if !r.register(name, vec) {
+			r.Unlock()
+			return
+		}
+		r.counters[name] = vec
+	}
+	r.Unlock()
+
+	if c, err := vec.GetMetricWith(labels); err == nil {
+		c.Add(float64(value))
+	}
+}
+
+func (r *tallyReporter) ReportGauge(name string, tags map[string]string, value float64) {
+	labelNames, labels := sanitizeTags(tags)
+	name = sanitizeName(name)
+
+	r.Lock()
+	vec, ok := r.gauges[name]
+	if !ok {
+		vec = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: name}, labelNames)
+		if !r.register(name, vec) {
+			r.Unlock()
+			return
+		}
+		r.gauges[name] = vec
+	}
+	r.Unlock()
+
+	if g, err := vec.GetMetricWith(labels); err == nil {
+		g.Set(value)
+	}
+}
+
+func (r *tallyReporter) ReportTimer(name string, tags map[string]string, interval time.Duration) {
+	r.observe(name+"_seconds", tags, interval.Seconds(), 1)
+}
+
+func (r *tallyReporter) ReportHistogramValueSamples(name string, tags map[string]string, buckets tally.Buckets, bucketLowerBound, bucketUpperBound float64, samples int64) {
+	r.observe(name, tags, bucketUpperBound, samples)
+}
+
+func (r *tallyReporter) ReportHistogramDurationSamples(name string, tags map[string]string, buckets tally.Buckets, bucketLowerBound, bucketUpperBound time.Duration, samples int64) {
+	r.observe(name+"_seconds", tags, bucketUpperBound.Seconds(), samples)
+}
+
+// observe records `samples` observations of a value into a histogram
+func (r *tallyReporter) observe(name string, tags map[string]string, value float64, samples int64) {
+	labelNames, labels := sanitizeTags(tags)
+	name = sanitizeName(name)
+
+	r.Lock()
+	vec, ok := r.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: name, Buckets: prometheus.DefBuckets}, labelNames)
+		if !r.register(name, vec) {
+			r.Unlock()
+			return
+		}
+		r.histograms[name] = vec
+	}
+	r.Unlock()
+
+	o, err := vec.GetMetricWith(labels)
+	if err != nil {
+		return
+	}
+	for i := int64(0); i < samples; i++ {
+		o.Observe(value)
+	}
+}
+
+func (r *tallyReporter) register(name string, c prometheus.Collector) bool {
+	if err := r.registerer.Register(c); err != nil {
+		logrus.WithField("prefix", "monitoring").WithError(err).WithField("metric", name).Warn("fail to register tally metric")
+		return false
+	}
+	return true
+}
+
+// sanitizeName converts a tally name into a valid prometheus metric or label name
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == ':':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func sanitizeTags(tags map[string]string) ([]string, prometheus.Labels) {
+	names := make([]string, 0, len(tags))
+	labels := prometheus.Labels{}
+	for k, v := range tags {
+		k = sanitizeName(k)
+		names = append(names, k)
+		labels[k] = v
+	}
+	sort.Strings(names)
+	return names, labels
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit is the budget of a token bucket. A bucket holds at most `Burst` tokens
+// and is refilled with `Requests` tokens every `Period`.
+type Limit struct {
+	Requests int           `mapstructure:"requests"`
+	Period   time.Duration `mapstructure:"period"`
+	Burst    int           `mapstructure:"burst"`
+}
+
+// Unlimited returns true if the limit does not restrict anything
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+// Capacity returns the maximum number of tokens in a bucket
+func (l Limit) Capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate returns the number of tokens refilled per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Bucket is the state of a token bucket
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewBucket returns a full bucket
+func NewBucket(l Limit, now time.Time) Bucket {
+	return Bucket{Tokens: l.Capacity(), UpdatedAt: now}
+}
+
+// Refill returns the bucket refilled with tokens accumulated until `now`
+func (b Bucket) Refill(l Limit, now time.Time) Bucket {
+	elapsed := now.Sub(b.UpdatedAt).Seconds()
+	if elapsed <= 0 {
+		return b
+	}
+
+	return Bucket{
+		Tokens:    math.Min(l.Capacity(), b.Tokens+elapsed*l.rate()),
+		UpdatedAt: now,
+	}
+}
+
+// Take takes a token from the bucket. It returns the new state of the bucket and whether
+// a token is taken. If the bucket is empty, it also returns how long it takes to refill a token.
+func (b Bucket) Take(l Limit, now time.Time) (Bucket, bool, time.Duration) {
+	if l.Unlimited() {
+		return b, true, 0
+	}
+
+	b = b.Refill(l, now)
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return b, true, 0
+	}
+
+	retryAfter := time.Duration((1 - b.Tokens) / l.rate() * float64(time.Second))
+	return b, false, retryAfter
+}
+
+// Refund puts a taken token back into the bucket
+func (b Bucket) Refund(l Limit, now time.Time) Bucket {
+	if l.Unlimited() {
+		return b
+	}
+
+	b = b.Refill(l, now)
+	b.Tokens = math.Min(l.Capacity(), b.Tokens+1)
+	return b
+}
+
+// FullAt returns the time when the bucket will be full again
+func (b Bucket) FullAt(l Limit) time.Time {
+	if l.Unlimited() {
+		return b.UpdatedAt
+	}
+
+	missing := l.Capacity() - b.Tokens
+	return b.UpdatedAt.Add(time.Duration(missing / l.rate() * float64(time.Second)))
+}
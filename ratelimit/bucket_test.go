@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketTake(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	b := NewBucket(limit, now)
+
+	b, allowed, _ := b.Take(limit, now)
+	assert.True(t, allowed)
+	b, allowed, _ = b.Take(limit, now)
+	assert.True(t, allowed)
+
+	b, allowed, retryAfter := b.Take(limit, now)
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	// a token is refilled every 30 seconds
+	b, allowed, retryAfter = b.Take(limit, now.Add(20*time.Second))
+	assert.False(t, allowed)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	_, allowed, _ = b.Take(limit, now.Add(30*time.Second))
+	assert.True(t, allowed)
+}
+
+func TestBucketBurst(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Second, Burst: 5}
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	b := NewBucket(limit, now)
+	for i := 0; i < 5; i++ {
+		var allowed bool
+		b, allowed, _ = b.Take(limit, now)
+		assert.True(t, allowed)
+	}
+	_, allowed, _ := b.Take(limit, now)
+	assert.False(t, allowed)
+
+	// a bucket never holds more tokens than the burst
+	b = b.Refill(limit, now.Add(time.Hour))
+	assert.Equal(t, float64(5), b.Tokens)
+	assert.Equal(t, now.Add(time.Hour), b.FullAt(limit))
+}
+
+func TestBucketRefund(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	b := NewBucket(limit, now)
+	b, _, _ = b.Take(limit, now)
+	b = b.Refund(limit, now)
+	assert.Equal(t, float64(2), b.Tokens)
+
+	// a bucket never holds more tokens than its capacity
+	b = b.Refund(limit, now)
+	assert.Equal(t, float64(2), b.Tokens)
+}
+
+func TestBucketUnlimited(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	b := NewBucket(Limit{}, now)
+	for i := 0; i < 100; i++ {
+		var allowed bool
+		b, allowed, _ = b.Take(Limit{}, now)
+		assert.True(t, allowed)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Minute}
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	s := NewMemoryStore()
+
+	allowed, _, err := s.Take("a", limit, now)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, retryAfter, err := s.Take("a", limit, now)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	// buckets are independent
+	allowed, _, err = s.Take("b", limit, now)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// a refunded token could be taken again
+	assert.NoError(t, s.Refund("a", limit, now))
+	allowed, _, err = s.Take("a", limit, now)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// full buckets are swept
+	allowed, _, _ = s.Take("c", limit, now.Add(time.Hour))
+	assert.True(t, allowed)
+	assert.Len(t, s.buckets, 1)
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const sweepInterval = 10 * time.Minute
+
+// Store keeps the states of token buckets
+type Store interface {
+	// Take takes a token from the bucket of `key`. It returns whether the request is
+	// allowed and how long the requester should wait if it is not.
+	Take(key string, limit Limit, now time.Time) (bool, time.Duration, error)
+
+	// Refund puts a token taken by `Take` back into the bucket of `key`
+	Refund(key string, limit Limit, now time.Time) error
+}
+
+// StoreFunc is an adapter to use an ordinary function as a Store. Tokens taken by
+// the function are not refunded.
+type StoreFunc func(key string, limit Limit, now time.Time) (bool, time.Duration, error)
+
+// Take calls f(key, limit, now)
+func (f StoreFunc) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	return f(key, limit, now)
+}
+
+// Refund does nothing
+func (f StoreFunc) Refund(key string, limit Limit, now time.Time) error {
+	return nil
+}
+
+type bucketEntry struct {
+	bucket Bucket
+	limit  Limit
+}
+
+// MemoryStore keeps buckets in the memory of a single process
+type MemoryStore struct {
+	sync.Mutex
+	buckets   map[string]bucketEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]bucketEntry),
+	}
+}
+
+func (m *MemoryStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.sweep(now)
+
+	entry, ok := m.buckets[key]
+	if !ok {
+		entry.bucket = NewBucket(limit, now)
+	}
+
+	bucket, allowed, retryAfter := entry.bucket.Take(limit, now)
+	m.buckets[key] = bucketEntry{bucket: bucket, limit: limit}
+
+	return allowed, retryAfter, nil
+}
+
+func (m *MemoryStore) Refund(key string, limit Limit, now time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if entry, ok := m.buckets[key]; ok {
+		m.buckets[key] = bucketEntry{bucket: entry.bucket.Refund(limit, now), limit: limit}
+	}
+	return nil
+}
+
+// sweep removes buckets which are full since they are the same as new ones
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, entry := range m.buckets {
+		if !now.Before(entry.bucket.FullAt(entry.limit)) {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,28 @@
+package schema
+
+import "time"
+
+const (
+	AuditLogCollection         = "auditLog"
+	ServiceSignatureCollection = "serviceSignature"
+)
+
+// AuditLog records a privileged operation, such as a service acting on
+// behalf of an account or an administrator operation.
+type AuditLog struct {
+	Actor         string    `bson:"actor" json:"actor"`
+	Action        string    `bson:"action" json:"action"`
+	AccountNumber string    `bson:"account_number,omitempty" json:"account_number,omitempty"`
+	Method        string    `bson:"method" json:"method"`
+	Path          string    `bson:"path" json:"path"`
+	Status        int       `bson:"status" json:"status"`
+	ClientIP      string    `bson:"client_ip" json:"client_ip"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+}
+
+// ServiceSignature records a signature of a service request which has been accepted, so
+// that the request can not be replayed before its timestamp is too skewed
+type ServiceSignature struct {
+	ID        string    `bson:"_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
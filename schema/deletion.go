@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const AccountDeletionCollection = "accountDeletion"
+
+type DeletionStatus string
+
+const (
+	DeletionInProgress DeletionStatus = "deleting"
+	DeletionCompleted  DeletionStatus = "completed"
+	DeletionFailed     DeletionStatus = "failed"
+)
+
+type DeletionStep string
+
+const (
+	DeletionStepWorkflows       DeletionStep = "workflows"
+	DeletionStepHelpRequests    DeletionStep = "help_requests"
+	DeletionStepAccount         DeletionStep = "account"
+	DeletionStepPOIRatings      DeletionStep = "poi_ratings"
+	DeletionStepReports         DeletionStep = "reports"
+	DeletionStepScoreHistory    DeletionStep = "score_history"
+	DeletionStepLocationHistory DeletionStep = "location_history"
+	DeletionStepExports         DeletionStep = "exports"
+	DeletionStepProfile         DeletionStep = "profile"
+	DeletionStepTokens          DeletionStep = "tokens"
+)
+
+// DeletionSteps are all steps of deleting an account in the order of execution
+var DeletionSteps = []DeletionStep{
+	DeletionStepWorkflows,
+	DeletionStepHelpRequests,
+	DeletionStepAccount,
+	DeletionStepPOIRatings,
+	DeletionStepReports,
+	DeletionStepScoreHistory,
+	DeletionStepLocationHistory,
+	DeletionStepExports,
+	DeletionStepProfile,
+	DeletionStepTokens,
+}
+
+// AccountDeletion tracks the progress of deleting an account. The profile ID and
+// POIs are kept since the profile itself is removed during the deletion. A deletion
+// in progress which is not updated for a while is resumed by the server. The progress is
+// queried by `ID` after tokens of the account are revoked.
+type AccountDeletion struct {
+	ID               string               `bson:"deletion_id,omitempty" json:"id"`
+	AccountNumber    string               `bson:"_id" json:"-"`
+	ProfileID        string               `bson:"profile_id" json:"-"`
+	PointsOfInterest []primitive.ObjectID `bson:"points_of_interest" json:"-"`
+	Status           DeletionStatus       `bson:"status" json:"status"`
+	CompletedSteps   []DeletionStep       `bson:"completed_steps" json:"completed_steps"`
+	Error            string               `bson:"error,omitempty" json:"-"`
+	StartedAt        time.Time            `bson:"started_at" json:"started_at"`
+	UpdatedAt        time.Time            `bson:"updated_at" json:"-"`
+	CompletedAt      *time.Time           `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt        *time.Time           `bson:"expires_at,omitempty" json:"-"`
+}
+
+// IsStepCompleted tells if a step has been done in a previous run
+func (d AccountDeletion) IsStepCompleted(step DeletionStep) bool {
+	for _, s := range d.CompletedSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
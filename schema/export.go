@@ -0,0 +1,32 @@
+package schema
+
+import "time"
+
+const (
+	AccountExportCollection = "accountExport"
+
+	// AccountExportArchiveBucket is the GridFS bucket of export archives
+	AccountExportArchiveBucket = "accountExportArchive"
+)
+
+type ExportStatus string
+
+const (
+	ExportPending    ExportStatus = "pending"
+	ExportProcessing ExportStatus = "processing"
+	ExportCompleted  ExportStatus = "completed"
+	ExportFailed     ExportStatus = "failed"
+)
+
+// AccountExport is a request of exporting personal data. The archive is
+// encrypted to the account's encryption public key and saved in GridFS with
+// the id of the export.
+type AccountExport struct {
+	ID            string       `bson:"_id" json:"id"`
+	AccountNumber string       `bson:"account_number" json:"-"`
+	Status        ExportStatus `bson:"status" json:"status"`
+	ArchiveSize   int64        `bson:"archive_size,omitempty" json:"archive_size,omitempty"`
+	CreatedAt     time.Time    `bson:"created_at" json:"created_at"`
+	CompletedAt   *time.Time   `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt     time.Time    `bson:"expires_at" json:"expires_at"`
+}
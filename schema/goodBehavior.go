@@ -2,6 +2,8 @@ package schema
 
 import (
 	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type GoodBehaviorType string
@@ -27,9 +29,8 @@ var DefaultBehaviorWeightMatrix = map[GoodBehaviorType]BehaviorWeight{
 }
 
 const (
-	BehaviorCollection          = "behaviors"
-	BehaviorReportCollection    = "behaviorReport"
-	TotalOfficialBehaviorWeight = float64(6)
+	BehaviorCollection       = "behaviors"
+	BehaviorReportCollection = "behaviorReport"
 )
 
 type BehaviorSource string
@@ -74,11 +75,13 @@ var OfficialBehaviors = []Behavior{
 
 // BehaviorReportData the struct to store citizen data and score
 type BehaviorReportData struct {
-	ProfileID     string     `json:"profile_id" bson:"profile_id"`
-	AccountNumber string     `json:"account_number" bson:"account_number"`
-	Behaviors     []Behavior `json:"behaviors" bson:"behaviors"`
-	Location      GeoJSON    `json:"location" bson:"location"`
-	Timestamp     int64      `json:"ts" bson:"ts"`
+	ID             primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	ProfileID      string             `json:"profile_id" bson:"profile_id"`
+	AccountNumber  string             `json:"account_number" bson:"account_number"`
+	Behaviors      []Behavior         `json:"behaviors" bson:"behaviors"`
+	Location       GeoJSON            `json:"location" bson:"location"`
+	Timestamp      int64              `json:"ts" bson:"ts"`
+	IdempotencyKey string             `json:"-" bson:"idempotency_key,omitempty"`
 }
 
 func (b *BehaviorReportData) MarshalJSON() ([]byte, error) {
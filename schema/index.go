@@ -56,6 +56,14 @@ func (m *MongoDBIndexer) IndexAll() {
 	panicIfError(m.IndexSymptomReportCollection())
 	panicIfError(m.IndexCDSConfirmCollection())
 	panicIfError(m.IndexGuideCollection())
+	panicIfError(m.IndexTokenCollection())
+	panicIfError(m.IndexAuditLogCollection())
+	panicIfError(m.IndexServiceSignatureCollection())
+	panicIfError(m.IndexAccountExportCollection())
+	panicIfError(m.IndexAccountDeletionCollection())
+	panicIfError(m.IndexRateLimitCollection())
+	panicIfError(m.IndexLocationHistoryCollection())
+	panicIfError(m.IndexPopulationCollection())
 }
 
 func (m *MongoDBIndexer) IndexProfileCollection() error {
@@ -109,6 +117,29 @@ func (m *MongoDBIndexer) IndexBehaviorReportCollection() error {
 		return err
 	}
 
+	if err := m.createIndex(BehaviorReportCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{"profile_id", 1},
+			{"idempotency_key", 1},
+		},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+			"idempotency_key": bson.M{"$exists": true},
+		}),
+	}); err != nil {
+		return err
+	}
+
+	// for pages of report history
+	if err := m.createIndex(BehaviorReportCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{"account_number", 1},
+			{"ts", -1},
+			{"_id", -1},
+		},
+	}); err != nil {
+		return err
+	}
+
 	return m.createIndex(BehaviorReportCollection, mongo.IndexModel{
 		Keys: bson.M{
 			"location": "2dsphere",
@@ -135,6 +166,29 @@ func (m *MongoDBIndexer) IndexSymptomReportCollection() error {
 		return err
 	}
 
+	if err := m.createIndex(SymptomReportCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{"profile_id", 1},
+			{"idempotency_key", 1},
+		},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+			"idempotency_key": bson.M{"$exists": true},
+		}),
+	}); err != nil {
+		return err
+	}
+
+	// for pages of report history
+	if err := m.createIndex(SymptomReportCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{"account_number", 1},
+			{"ts", -1},
+			{"_id", -1},
+		},
+	}); err != nil {
+		return err
+	}
+
 	return m.createIndex(SymptomReportCollection, mongo.IndexModel{
 		Keys: bson.M{
 			"location": "2dsphere",
@@ -189,6 +243,18 @@ func (m *MongoDBIndexer) IndexCDSConfirmCollection() error {
 	}
 	return nil
 }
+
+func (m *MongoDBIndexer) IndexPopulationCollection() error {
+	return m.createIndex(PopulationCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "country", Value: 1},
+			{Key: "state", Value: 1},
+			{Key: "county", Value: 1},
+		},
+		Options: options.Index().SetUnique(true).SetName("unique_population"),
+	})
+}
+
 func (m *MongoDBIndexer) IndexGuideCollection() error {
 	return m.createIndex(TestCenterCollection, mongo.IndexModel{
 		Keys: bson.M{
@@ -196,3 +262,122 @@ func (m *MongoDBIndexer) IndexGuideCollection() error {
 		},
 	})
 }
+
+func (m *MongoDBIndexer) IndexTokenCollection() error {
+	if err := m.createIndex(TokenCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"account_number": 1,
+		},
+	}); err != nil {
+		return err
+	}
+
+	// expired tokens are useless so let mongodb purge them
+	return m.createIndex(TokenCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+}
+
+func (m *MongoDBIndexer) IndexAuditLogCollection() error {
+	return m.createIndex(AuditLogCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "actor", Value: 1},
+			{Key: "created_at", Value: -1},
+		},
+	})
+}
+
+func (m *MongoDBIndexer) IndexServiceSignatureCollection() error {
+	// signatures out of the clock-skew window are rejected anyway
+	return m.createIndex(ServiceSignatureCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+}
+
+func (m *MongoDBIndexer) IndexAccountExportCollection() error {
+	if err := m.createIndex(AccountExportCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "account_number", Value: 1},
+			{Key: "created_at", Value: -1},
+		},
+	}); err != nil {
+		return err
+	}
+
+	if err := m.createIndex(AccountExportCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}); err != nil {
+		return err
+	}
+
+	// archives in GridFS are removed by the server since chunks could not expire by TTL
+	return m.createIndex(AccountExportArchiveBucket+".files", mongo.IndexModel{
+		Keys: bson.M{
+			"metadata.expires_at": 1,
+		},
+	})
+}
+
+func (m *MongoDBIndexer) IndexAccountDeletionCollection() error {
+	if err := m.createIndex(AccountDeletionCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "updated_at", Value: 1},
+		},
+	}); err != nil {
+		return err
+	}
+
+	if err := m.createIndex(AccountDeletionCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"deletion_id": 1,
+		},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	}); err != nil {
+		return err
+	}
+
+	return m.createIndex(AccountDeletionCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+}
+
+func (m *MongoDBIndexer) IndexRateLimitCollection() error {
+	return m.createIndex(RateLimitCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+}
+
+func (m *MongoDBIndexer) IndexLocationHistoryCollection() error {
+	if err := m.createIndex(LocationHistoryCollection, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "account_number", Value: 1},
+			{Key: "recorded_at", Value: -1},
+		},
+	}); err != nil {
+		return err
+	}
+
+	// points out of the retention window are purged by mongodb
+	return m.createIndex(LocationHistoryCollection, mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+}
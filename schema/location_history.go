@@ -0,0 +1,19 @@
+package schema
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const LocationHistoryCollection = "locationHistory"
+
+// LocationHistory is a point of the trail of an account. Points are only recorded
+// for accounts which opt in and are purged once they are out of the retention window.
+type LocationHistory struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	AccountNumber string             `bson:"account_number"`
+	Location      GeoJSON            `bson:"location"`
+	RecordedAt    time.Time          `bson:"recorded_at"`
+	ExpiresAt     time.Time          `bson:"expires_at"`
+}
@@ -4,30 +4,72 @@ import (
 	"time"
 )
 
+const (
+	GrowthTrendGrowing   = "growing"
+	GrowthTrendStable    = "stable"
+	GrowthTrendDeclining = "declining"
+)
+
+// EstimateInterval is an estimated value with its 95% confidence interval
+type EstimateInterval struct {
+	Estimate float64 `json:"estimate" bson:"estimate"`
+	Lower    float64 `json:"lower" bson:"lower"`
+	Upper    float64 `json:"upper" bson:"upper"`
+}
+
+// ConfirmGrowth is the estimated growth of daily confirmed cases. The outbreak is growing or
+// declining only if the whole confidence interval of the growth rate is above or below zero.
+// Doubling and halving times are in days.
+type ConfirmGrowth struct {
+	Trend              string            `json:"trend" bson:"trend"`
+	GrowthRate         EstimateInterval  `json:"growth_rate" bson:"growth_rate"`
+	ReproductionNumber EstimateInterval  `json:"reproduction_number" bson:"reproduction_number"`
+	DoublingTime       *EstimateInterval `json:"doubling_time,omitempty" bson:"doubling_time,omitempty"`
+	HalvingTime        *EstimateInterval `json:"halving_time,omitempty" bson:"halving_time,omitempty"`
+}
+
 type ConfirmDetail struct {
 	ContinuousData []CDSScoreDataSet `json:"-" bson:"data"`
+	Population     float64           `json:"population" bson:"population"`
 	Score          float64           `json:"score" bson:"score"`
 	ScoreYesterday float64           `json:"score_yesterday" bson:"score_yesterday"`
+	Growth         *ConfirmGrowth    `json:"growth,omitempty" bson:"growth,omitempty"`
 }
 
+// BehaviorDetail is the behavior part of a metric. Report times and distributions are
+// weighted by ages of reports at now and a day ago, while `TodayDistribution` and
+// `YesterdayDistribution` are counts of the last 24 hours and the 24 hours before, which are
+// saved to explain changes of scores.
 type BehaviorDetail struct {
-	Score                 float64        `json:"score" bson:"score"`
-	ScoreYesterday        float64        `json:"score_yesterday" bson:"score_yesterday"`
-	ReportTimes           int            `json:"-" bson:"-"`
-	ReportTimesYesterday  int            `json:"-" bson:"-"`
-	TodayDistribution     map[string]int `json:"-" bson:"-"`
-	YesterdayDistribution map[string]int `json:"-" bson:"-"`
+	Score                 float64            `json:"score" bson:"score"`
+	ScoreYesterday        float64            `json:"score_yesterday" bson:"score_yesterday"`
+	ReportTimes           float64            `json:"-" bson:"-"`
+	ReportTimesYesterday  float64            `json:"-" bson:"-"`
+	Distribution          map[string]float64 `json:"-" bson:"-"`
+	DistributionYesterday map[string]float64 `json:"-" bson:"-"`
+	TodayDistribution     map[string]int     `json:"-" bson:"today_distribution,omitempty"`
+	YesterdayDistribution map[string]int     `json:"-" bson:"yesterday_distribution,omitempty"`
 }
 
+// SymptomDetail is the symptom part of a metric. Numbers of people and distributions are
+// weighted by ages of reports at now and a day ago. `TodayData` and `YesterdayData` are
+// counts of the last 24 hours and the 24 hours before, and `BaselineData` are the ones of
+// the 24-hour windows before now, from the oldest one, for detecting spikes. Counts of the
+// last 24 hours and the 24 hours before are saved to explain changes of scores.
 type SymptomDetail struct {
-	Score                float64            `json:"score" bson:"score"`
-	ScoreYesterday       float64            `json:"score_yesterday" bson:"score_yesterday"`
-	TotalPeople          float64            `json:"-" bson:"-"`
-	TotalPeopleYesterday float64            `json:"-" bson:"-"`
-	TodayData            NearestSymptomData `json:"-"  bson:"-"`
-	YesterdayData        NearestSymptomData `json:"-"  bson:"-"`
-	LastSpikeUpdate      time.Time          `json:"-" bson:"last_spike_update"`
-	LastSpikeList        []string           `json:"-" bson:"last_spike_types"`
+	Score                 float64              `json:"score" bson:"score"`
+	ScoreYesterday        float64              `json:"score_yesterday" bson:"score_yesterday"`
+	TotalPeople           float64              `json:"-" bson:"-"`
+	TotalPeopleYesterday  float64              `json:"-" bson:"-"`
+	Distribution          map[string]float64   `json:"-" bson:"-"`
+	DistributionYesterday map[string]float64   `json:"-" bson:"-"`
+	TodayData             NearestSymptomData   `json:"-" bson:"today_data"`
+	YesterdayData         NearestSymptomData   `json:"-" bson:"yesterday_data"`
+	BaselineData          []NearestSymptomData `json:"-" bson:"-"`
+	SpikeConfig           SpikeConfig          `json:"-" bson:"-"`
+	LastSpikeUpdate       time.Time            `json:"-" bson:"last_spike_update"`
+	LastSpikeList         []string             `json:"-" bson:"last_spike_types"`
+	LastSpikes            []SymptomSpike       `json:"-" bson:"last_spikes"`
 }
 
 type NearestSymptomData struct {
@@ -51,15 +93,17 @@ type IndividualMetric struct {
 }
 
 type Metric struct {
-	ConfirmedCount float64 `json:"confirm" bson:"confirm"`
-	ConfirmedDelta float64 `json:"confirm_delta" bson:"confirm_delta"`
-	SymptomCount   float64 `json:"symptom" bson:"symptoms"`
-	SymptomDelta   float64 `json:"symptom_delta" bson:"symptoms_delta"`
-	BehaviorCount  float64 `json:"behavior" bson:"behavior"`
-	BehaviorDelta  float64 `json:"behavior_delta" bson:"behavior_delta"`
-	Score          float64 `json:"score" bson:"score"`
-	ScoreDelta     float64 `json:"score_delta" bson:"score_delta"`
-	ScoreYesterday float64 `json:"-" bson:"score_yesterday"`
-	LastUpdate     int64   `json:"-" bson:"last_update"`
-	Details        Details `json:"-" bson:"details"`
+	ConfirmedCount  float64        `json:"confirm" bson:"confirm"`
+	ConfirmedDelta  float64        `json:"confirm_delta" bson:"confirm_delta"`
+	ConfirmedGrowth *ConfirmGrowth `json:"confirm_growth,omitempty" bson:"confirm_growth,omitempty"`
+	SymptomCount    float64        `json:"symptom" bson:"symptoms"`
+	SymptomDelta    float64        `json:"symptom_delta" bson:"symptoms_delta"`
+	BehaviorCount   float64        `json:"behavior" bson:"behavior"`
+	BehaviorDelta   float64        `json:"behavior_delta" bson:"behavior_delta"`
+	Score           float64        `json:"score" bson:"score"`
+	ScoreDelta      float64        `json:"score_delta" bson:"score_delta"`
+	ScoreYesterday  float64        `json:"-" bson:"score_yesterday"`
+	LastUpdate      int64          `json:"-" bson:"last_update"`
+	Details         Details        `json:"-" bson:"details"`
+	FormulaVersion  string         `json:"formula_version" bson:"formula_version"`
 }
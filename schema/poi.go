@@ -11,20 +11,21 @@ const (
 )
 
 type POI struct {
-	ID              primitive.ObjectID `bson:"_id"`
-	Location        *GeoJSON           `bson:"location"`
-	Address         string             `bson:"address"`
-	Alias           string             `bson:"alias"`
-	Score           float64            `bson:"autonomy_score"`
-	ScoreDelta      float64            `bson:"autonomy_score_delta"`
-	Metric          Metric             `bson:"metric"`
-	Country         string             `bson:"country" json:"-"`
-	State           string             `bson:"state" json:"-"`
-	County          string             `bson:"county" json:"-"`
-	PlaceType       string             `bson:"place_type" json:"-"`
-	Distance        *float64           `bson:"distance,omitempty"`
-	ResourceScore   *float64           `bson:"resource_score,omitempty"`
-	ResourceRatings POIRatingsMetric   `bson:"resource_ratings" json:"-"`
+	ID                 primitive.ObjectID `bson:"_id"`
+	Location           *GeoJSON           `bson:"location"`
+	Address            string             `bson:"address"`
+	Alias              string             `bson:"alias"`
+	Score              float64            `bson:"autonomy_score"`
+	ScoreDelta         float64            `bson:"autonomy_score_delta"`
+	Metric             Metric             `bson:"metric"`
+	Country            string             `bson:"country" json:"-"`
+	State              string             `bson:"state" json:"-"`
+	County             string             `bson:"county" json:"-"`
+	PlaceType          string             `bson:"place_type" json:"-"`
+	Distance           *float64           `bson:"distance,omitempty"`
+	ResourceScore      *float64           `bson:"resource_score,omitempty"`
+	ResourceConfidence *float64           `bson:"resource_confidence,omitempty"`
+	ResourceRatings    POIRatingsMetric   `bson:"resource_ratings" json:"-"`
 }
 
 type ProfilePOI struct {
@@ -42,8 +43,9 @@ type ProfilePOI struct {
 // POIDetail is a client response **ONLY** structure since the data come
 // from both schema Profile.PointsOfInterest & POI
 type POIDetail struct {
-	ProfilePOI    `bson:",inline"`
-	Location      *Location `json:"location"`
-	Distance      *float64  `json:"distance,omitempty"`
-	ResourceScore *float64  `json:"resource_score,omitempty"`
+	ProfilePOI         `bson:",inline"`
+	Location           *Location `json:"location"`
+	Distance           *float64  `json:"distance,omitempty"`
+	ResourceScore      *float64  `json:"resource_score,omitempty"`
+	ResourceConfidence *float64  `json:"resource_confidence,omitempty"`
 }
@@ -6,9 +6,11 @@ type Resource struct {
 	Important bool   `json:"-" bson:"-"`
 }
 
+// RatingResource is a rating of a resource by an account. `RatedAt` is when it was rated.
 type RatingResource struct {
 	Resource `json:"resource" bson:"resource"`
 	Score    float64 `json:"score" bson:"score"`
+	RatedAt  int64   `json:"-" bson:"rated_at,omitempty"`
 }
 
 type ProfileRatingsMetric struct {
@@ -16,17 +18,34 @@ type ProfileRatingsMetric struct {
 	LastUpdate int64            `json:"-" bson:"last_update"`
 }
 
+// WeightedRatings are ratings weighted by their ages at a time
+type WeightedRatings struct {
+	Sum   float64 `json:"-" bson:"sum"`
+	Count float64 `json:"-" bson:"count"`
+	At    int64   `json:"-" bson:"at"`
+}
+
+// POIResourceRating is the aggregation of ratings of a resource of a POI. `Score` is the
+// plain average of ratings, while `SmoothedScore` and `Confidence` are calculated from the
+// weighted ratings and the prior of the resource when the rating is responded. `History`
+// are the weighted ratings before each update of the last day, from the oldest, which starts
+// with the latest ones more than a day before the last update.
 type POIResourceRating struct {
-	Resource       `json:"resource" bson:"resource"`
-	SumOfScore     float64 `json:"-" bson:"sum"`
-	Score          float64 `json:"score" bson:"score"`
-	Ratings        int64   `json:"ratings" bson:"ratings"`
-	LastUpdate     int64   `json:"-" bson:"last_update"`
-	LastDayScore   float64 `json:"-" bson:"last_day_score"`
-	LastDayRatings int64   `json:"-" bson:"last_day_rating"`
+	Resource      `json:"resource" bson:"resource"`
+	SumOfScore    float64           `json:"-" bson:"sum"`
+	Score         float64           `json:"score" bson:"score"`
+	Ratings       int64             `json:"ratings" bson:"ratings"`
+	LastUpdate    int64             `json:"-" bson:"last_update"`
+	Weighted      WeightedRatings   `json:"-" bson:"weighted"`
+	History       []WeightedRatings `json:"-" bson:"history,omitempty"`
+	SmoothedScore float64           `json:"smoothed_score" bson:"-"`
+	Confidence    float64           `json:"confidence" bson:"-"`
 }
 
+// POIRatingsMetric are ratings of resources of a POI. `PlaceType` is the one of the POI,
+// which decides priors of ratings.
 type POIRatingsMetric struct {
 	Resources  []POIResourceRating `json:"resources" bson:"resources,omitempty"`
 	LastUpdate int64               `json:"last_update" bson:"last_update"`
+	PlaceType  string              `json:"-" bson:"-"`
 }
@@ -0,0 +1,15 @@
+package schema
+
+const (
+	PopulationCollection = "population"
+)
+
+// Population is the number of people of an area. Areas are keyed by country, state and
+// county as the ones of `CDSData` and `Boundary`. Empty state and county mean the whole
+// country or state.
+type Population struct {
+	Country string  `json:"country" bson:"country"`
+	State   string  `json:"state" bson:"state"`
+	County  string  `json:"county" bson:"county"`
+	Count   float64 `json:"count" bson:"count"`
+}
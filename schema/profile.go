@@ -23,13 +23,26 @@ var (
 	}
 )
 
+// BehaviorWeights is structure for customized weights of official behaviors
+type BehaviorWeights map[string]float64
+
+// DefaultBehaviorWeights returns weights of official behaviors in `DefaultBehaviorWeightMatrix`
+func DefaultBehaviorWeights() BehaviorWeights {
+	weights := BehaviorWeights{}
+	for id, w := range DefaultBehaviorWeightMatrix {
+		weights[string(id)] = w.Weight
+	}
+	return weights
+}
+
 // ScoreCoefficient is structure for all customized weights for calculating personal score
 type ScoreCoefficient struct {
-	Symptoms       float64        `json:"symptoms" bson:"symptoms"`
-	Behaviors      float64        `json:"behaviors" bson:"behaviors"`
-	Confirms       float64        `json:"confirms" bson:"confirms"`
-	UpdatedAt      time.Time      `json:"-" bson:"updated_at"`
-	SymptomWeights SymptomWeights `json:"symptom_weights" bson:"symptom_weights"`
+	Symptoms        float64         `json:"symptoms" bson:"symptoms"`
+	Behaviors       float64         `json:"behaviors" bson:"behaviors"`
+	Confirms        float64         `json:"confirms" bson:"confirms"`
+	UpdatedAt       time.Time       `json:"-" bson:"updated_at"`
+	SymptomWeights  SymptomWeights  `json:"symptom_weights" bson:"symptom_weights"`
+	BehaviorWeights BehaviorWeights `json:"behavior_weights" bson:"behavior_weights"`
 }
 
 type NudgeType string
@@ -52,10 +65,13 @@ type Profile struct {
 	IndividualMetric    IndividualMetric  `bson:"individual_metric"`
 	Metric              Metric            `bson:"metric"`
 	ScoreCoefficient    *ScoreCoefficient `bson:"score_coefficient"`
+	FormulaVersion      string            `bson:"formula_version"`
 	LastNudge           NudgeTime         `bson:"last_nudge,omitempty"`
 	PointsOfInterest    []ProfilePOI      `bson:"points_of_interest,omitempty"`
 	CustomizedBehaviors []Behavior        `bson:"customized_behavior"`
 	CustomizedSymptoms  []Symptom         `bson:"customized_symptom"`
+
+	LocationHistoryEnabled bool `bson:"location_history_enabled"`
 }
 
 // GeoJSON - mongo location format
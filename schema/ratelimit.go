@@ -0,0 +1,14 @@
+package schema
+
+import "time"
+
+const RateLimitCollection = "rateLimit"
+
+// RateLimitBucket is the state of a token bucket shared by server instances
+type RateLimitBucket struct {
+	Key       string    `bson:"_id"`
+	Tokens    float64   `bson:"tokens"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	Version   int64     `bson:"version"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
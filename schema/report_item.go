@@ -16,3 +16,11 @@ type Bucket struct {
 	Name  string `bson:"name"`
 	Value int    `bson:"value"`
 }
+
+// ReportRecord is a symptom or behavior report reduced to the reported IDs, which scores
+// of an area are calculated from
+type ReportRecord struct {
+	ProfileID string   `bson:"profile_id"`
+	IDs       []string `bson:"ids"`
+	Timestamp int64    `bson:"ts"`
+}
@@ -17,4 +17,6 @@ type ScoreRecord struct {
 	Score       float64         `bson:"score"`
 	UpdateTimes float64         `bson:"update_times"`
 	Date        string          `bson:"date"`
+	// version of the formula which calculated the score
+	FormulaVersion string `bson:"formula_version"`
 }
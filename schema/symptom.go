@@ -2,6 +2,8 @@ package schema
 
 import (
 	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ReportType string
@@ -178,15 +180,44 @@ var GeneralSymptoms = []Symptom{
 
 // SymptomReportData the struct to store symptom data and score
 type SymptomReportData struct {
-	ProfileID     string    `json:"profile_id" bson:"profile_id"`
-	AccountNumber string    `json:"account_number" bson:"account_number"`
-	Symptoms      []Symptom `json:"symptoms" bson:"symptoms"`
-	Location      GeoJSON   `json:"location" bson:"location"`
-	Timestamp     int64     `json:"ts" bson:"ts"`
+	ID             primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	ProfileID      string             `json:"profile_id" bson:"profile_id"`
+	AccountNumber  string             `json:"account_number" bson:"account_number"`
+	Symptoms       []Symptom          `json:"symptoms" bson:"symptoms"`
+	Location       GeoJSON            `json:"location" bson:"location"`
+	Timestamp      int64              `json:"ts" bson:"ts"`
+	IdempotencyKey string             `json:"-" bson:"idempotency_key,omitempty"`
 }
 
 type SymptomDistribution map[string]int
 
+const (
+	SpikeMethodPoisson = "poisson"
+	SpikeMethodEWMA    = "ewma"
+)
+
+// SpikeConfig configures how symptom spikes of an area are detected. Counts of today are
+// compared with the baseline of the last `BaselineDays` days, either by a Poisson test
+// (negative binomial if the baseline is over-dispersed) or by EWMA control limits.
+// A symptom spikes if it is reported by at least `MinCount` people and its strength, in
+// standard deviations above the baseline, is at least `Threshold`. `Lambda` is the
+// smoothing factor of EWMA.
+type SpikeConfig struct {
+	Method       string  `mapstructure:"method"`
+	BaselineDays int     `mapstructure:"baseline_days"`
+	MinCount     int     `mapstructure:"min_count"`
+	Threshold    float64 `mapstructure:"threshold"`
+	Lambda       float64 `mapstructure:"lambda"`
+}
+
+// SymptomSpike is a symptom reported significantly more than usual in an area
+type SymptomSpike struct {
+	ID       string  `json:"id" bson:"id"`
+	Count    int     `json:"count" bson:"count"`
+	Baseline float64 `json:"baseline" bson:"baseline"`
+	Strength float64 `json:"strength" bson:"strength"`
+}
+
 func (s *SymptomReportData) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		Symptoms  []Symptom `json:"symptoms"`
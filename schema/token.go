@@ -0,0 +1,23 @@
+package schema
+
+import "time"
+
+const TokenCollection = "token"
+
+type TokenType string
+
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
+
+// Token records a JWT issued by the API server. It is keyed by the `jti` claim
+// so that a token could be revoked before it expires.
+type Token struct {
+	ID            string    `bson:"_id"`
+	AccountNumber string    `bson:"account_number"`
+	Type          TokenType `bson:"type"`
+	Revoked       bool      `bson:"revoked"`
+	IssuedAt      time.Time `bson:"issued_at"`
+	ExpiresAt     time.Time `bson:"expires_at"`
+}
@@ -1,49 +1,43 @@
 package score
 
 import (
+	"time"
+
 	"github.com/bitmark-inc/autonomy-api/schema"
 )
 
-// CalculateIndividualAutonomyScore calculates autonomy score for individual
+// CalculateIndividualAutonomyScore calculates autonomy score for individual by the formula
+// which calculated the metric of the neighborhood
 func CalculateIndividualAutonomyScore(individualMetric schema.IndividualMetric, neighborMetric schema.Metric) (float64, float64) {
-	scoreToday := neighborMetric.Score*0.2 + individualMetric.Score*0.8
-	scoreYesterday := neighborMetric.ScoreYesterday*0.2 + individualMetric.ScoreYesterday*0.8
+	formula := LookupFormula(neighborMetric.FormulaVersion)
+	scoreToday := formula.IndividualScore(neighborMetric.Score, individualMetric.Score)
+	scoreYesterday := formula.IndividualScore(neighborMetric.ScoreYesterday, individualMetric.ScoreYesterday)
 
 	return scoreToday, ChangeRate(float64(scoreToday), float64(scoreYesterday))
 }
 
-func CalculatePOIAutonomyScore(resources []schema.POIResourceRating, neighbor schema.Metric) (float64, float64, float64) {
-	sumOfScoreToday := float64(0)
-	sumOfScoreYesterday := float64(0)
-	sumOfRatingsToday := float64(0)
-	sumOfRatingsYesterday := float64(0)
-	scoreToday := float64(0)
-	scoreYesterday := float64(0)
+// ResourceAutonomyScore calculates the score of resources of a POI at a time and a day ago,
+// from Bayesian averages of their weighted ratings. Ratings of 1 to 5 are scaled to scores
+// of 20 to 100.
+func ResourceAutonomyScore(placeType string, resources []schema.POIResourceRating, at time.Time) (float64, float64) {
+	scoreToday, _, _ := resourceRatingsAt(placeType, resources, at)
+	scoreYesterday, _, _ := resourceRatingsAt(placeType, resources, at.Add(-ComparisonPeriod))
+
+	return (scoreToday / 5) * 100, (scoreYesterday / 5) * 100
+}
 
+// CalculatePOIAutonomyScore calculates autonomy score for a POI of a place type by the
+// formula which calculated the metric of the neighborhood
+func CalculatePOIAutonomyScore(placeType string, resources []schema.POIResourceRating, neighbor schema.Metric) (float64, float64, float64) {
 	if len(resources) == 0 {
 		return neighbor.Score, neighbor.ScoreYesterday, ChangeRate(neighbor.Score, neighbor.ScoreYesterday)
 	}
 
-	for _, r := range resources {
-		if r.Score > 0 { // score = 0 means not rated
-			sumOfScoreToday = sumOfScoreToday + r.Score*float64(r.Ratings)
-			sumOfRatingsToday = sumOfRatingsToday + float64(r.Ratings)
-		}
-		if r.LastDayScore != 0 {
-			sumOfScoreYesterday = sumOfScoreYesterday + r.LastDayScore*float64(r.LastDayRatings)
-			sumOfRatingsYesterday = sumOfRatingsYesterday + float64(r.LastDayRatings)
-		}
-	}
-
-	if sumOfRatingsToday != 0 {
-		scoreToday = ((sumOfScoreToday / sumOfRatingsToday) / 5) * 100
-	}
-	if sumOfRatingsYesterday != 0 {
-		scoreYesterday = ((sumOfScoreYesterday / sumOfRatingsYesterday) / 5) * 100
-	}
+	scoreToday, scoreYesterday := ResourceAutonomyScore(placeType, resources, time.Now())
 
-	poiScoreToday := 0.2*neighbor.Score + 0.8*scoreToday
-	poiScoreYesterday := 0.2*neighbor.ScoreYesterday + 0.8*scoreYesterday
+	formula := LookupFormula(neighbor.FormulaVersion)
+	poiScoreToday := formula.POIScore(neighbor.Score, scoreToday)
+	poiScoreYesterday := formula.POIScore(neighbor.ScoreYesterday, scoreYesterday)
 
 	return poiScoreToday, poiScoreYesterday, ChangeRate(poiScoreToday, poiScoreYesterday)
 }
@@ -2,7 +2,7 @@ package score
 
 import (
 	"testing"
-	// "time"
+	"time"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
 	"github.com/stretchr/testify/assert"
@@ -43,64 +43,92 @@ func TestCalculateIndividualAutonomyScoreDecrease(t *testing.T) {
 }
 
 func TestCalculatePOIAutonomyScore(t *testing.T) {
-	// neighbor := schema.Metric{
-	// 	Score:          50,
-	// 	ScoreYesterday: 80,
-	// }
-
-	// resources := []schema.POIResourceRating{
-	// 	{
-	// 		Resource:       schema.Resource{ID: "resource_1"},
-	// 		SumOfScore:     45,
-	// 		Score:          4.5,
-	// 		Ratings:        10,
-	// 		LastDayRatings: 8,
-	// 		LastDayScore:   4.625,
-	// 		LastUpdate:     time.Now().Unix(),
-	// 	},
-	// 	{
-	// 		Resource:       schema.Resource{ID: "resource_2"},
-	// 		SumOfScore:     30,
-	// 		Score:          3.75,
-	// 		Ratings:        8,
-	// 		LastDayRatings: 6,
-	// 		LastDayScore:   3.5,
-	// 		LastUpdate:     time.Now().Unix(),
-	// 	},
-	// 	{
-	// 		Resource:       schema.Resource{ID: "resource_3"},
-	// 		SumOfScore:     0,
-	// 		Score:          0,
-	// 		Ratings:        1,
-	// 		LastDayRatings: 0,
-	// 		LastDayScore:   0,
-	// 		LastUpdate:     time.Now().Unix(),
-	// 	},
-	// 	{
-	// 		Resource:       schema.Resource{ID: "resource_4"},
-	// 		SumOfScore:     0,
-	// 		Score:          0,
-	// 		Ratings:        0,
-	// 		LastDayRatings: 0,
-	// 		LastDayScore:   0,
-	// 		LastUpdate:     time.Now().Unix(),
-	// 	},
-	// }
-
-	// FIXME:
-	// autonomy_test.go:91:
-	//  Error Trace:    autonomy_test.go:91
-	// 	Error:          Not equal:
-	// 					expected: 73.15789473684211
-	// 					actual  : 76.66666666666667
-	// 	Test:           TestCalculatePOIAutonomyScore
-	// autonomy_test.go:92:
-	// 	Error Trace:    autonomy_test.go:92
-	// 	Error:          Not equal:
-	// 					expected: -11.092836257309942
-	// 					actual  : -6.828703703703705
-	// 	Test:           TestCalculatePOIAutonomyScore
-	// score, _, delta := CalculatePOIAutonomyScore(resources, neighbor)
-	// assert.Equal(t, 73.15789473684211, score)
-	// assert.Equal(t, -11.092836257309942, delta)
+	neighbor := schema.Metric{
+		Score:          50,
+		ScoreYesterday: 80,
+	}
+
+	now := time.Now()
+	dayAgo := now.Add(-ComparisonPeriod).Unix()
+	resources := []schema.POIResourceRating{
+		{
+			Resource:   schema.Resource{ID: "resource_1"},
+			SumOfScore: 45,
+			Score:      4.5,
+			Ratings:    10,
+			LastUpdate: now.Unix(),
+			Weighted:   schema.WeightedRatings{Sum: 45, Count: 10, At: now.Unix()},
+			History:    []schema.WeightedRatings{{Sum: 37, Count: 8, At: dayAgo}},
+		},
+		{
+			Resource:   schema.Resource{ID: "resource_2"},
+			SumOfScore: 30,
+			Score:      3.75,
+			Ratings:    8,
+			LastUpdate: now.Unix(),
+			Weighted:   schema.WeightedRatings{Sum: 30, Count: 8, At: now.Unix()},
+			History:    []schema.WeightedRatings{{Sum: 21, Count: 6, At: dayAgo}},
+		},
+		{
+			// a rating of 0 means not rated
+			Resource:   schema.Resource{ID: "resource_3"},
+			Ratings:    1,
+			LastUpdate: now.Unix(),
+		},
+		{
+			Resource: schema.Resource{ID: "resource_4"},
+		},
+	}
+
+	// ratings are averaged with 5 ratings of 3 of the default prior:
+	//   today:     (10 * (15 + 45) / 15 + 8 * (15 + 30) / 13) / 18 = 3.7607 => 75.2137
+	//   yesterday: (8 * (15 + 37) / 13 + 6 * (15 + 21) / 11) / 14 = 3.6883 => 73.7662
+	resourceScore, resourceScoreYesterday := ResourceAutonomyScore("", resources, now)
+	assert.InDelta(t, 75.213675, resourceScore, 1e-4)
+	assert.InDelta(t, 73.766234, resourceScoreYesterday, 1e-4)
+
+	// 0.2 * neighbor + 0.8 * resources
+	score, scoreYesterday, delta := CalculatePOIAutonomyScore("", resources, neighbor)
+	assert.InDelta(t, 70.170940, score, 1e-4)
+	assert.InDelta(t, 75.012987, scoreYesterday, 1e-4)
+	assert.InDelta(t, -6.454945, delta, 1e-4)
+}
+
+func TestCalculatePOIAutonomyScoreWithoutResources(t *testing.T) {
+	neighbor := schema.Metric{
+		Score:          50,
+		ScoreYesterday: 80,
+	}
+
+	score, scoreYesterday, delta := CalculatePOIAutonomyScore("", nil, neighbor)
+	assert.Equal(t, 50.0, score)
+	assert.Equal(t, 80.0, scoreYesterday)
+	assert.Equal(t, -37.5, delta)
+}
+
+func TestCalculatePOIAutonomyScoreByNumberOfRatings(t *testing.T) {
+	neighbor := schema.Metric{Score: 50, ScoreYesterday: 50}
+	now := time.Now().Unix()
+
+	single := []schema.POIResourceRating{
+		{
+			Resource: schema.Resource{ID: "resource_1"},
+			Score:    5,
+			Ratings:  1,
+			Weighted: schema.WeightedRatings{Sum: 5, Count: 1, At: now},
+		},
+	}
+	popular := []schema.POIResourceRating{
+		{
+			Resource: schema.Resource{ID: "resource_1"},
+			Score:    4.8,
+			Ratings:  200,
+			Weighted: schema.WeightedRatings{Sum: 960, Count: 200, At: now},
+		},
+	}
+
+	// a single rating of 5 does not outrank 200 ratings averaging 4.8
+	singleScore, _, _ := CalculatePOIAutonomyScore("", single, neighbor)
+	popularScore, _, _ := CalculatePOIAutonomyScore("", popular, neighbor)
+	assert.True(t, singleScore < popularScore)
 }
@@ -6,53 +6,57 @@ import (
 	"github.com/bitmark-inc/autonomy-api/schema"
 )
 
-func UpdateBehaviorMetrics(metric *schema.Metric) {
-	todayTotal := 0
-	yesterdayTotal := 0
+// UpdateBehaviorMetrics calculates the behavior score by weights of official behaviors.
+// Behaviors which are not in the weights are non-official ones and weighted as 1.
+// Default weights are used if weights are not given. Reports are weighted by their ages,
+// and the score of yesterday and the delta compare it with the one of a day ago.
+func UpdateBehaviorMetrics(metric *schema.Metric, weights schema.BehaviorWeights) {
+	if len(weights) == 0 {
+		weights = schema.DefaultBehaviorWeights()
+	}
 
-	officialWeightedSum := float64(0)
-	nonOfficialWeightedSum := float64(0)
-	for behaviorID, cnt := range metric.Details.Behaviors.TodayDistribution {
-		w, ok := schema.DefaultBehaviorWeightMatrix[schema.GoodBehaviorType(behaviorID)]
-		if ok {
-			officialWeightedSum += w.Weight * float64(cnt)
-		} else {
-			nonOfficialWeightedSum += float64(cnt)
-		}
+	behaviors := metric.Details.Behaviors
 
-		todayTotal += cnt
-	}
+	score, total := behaviorScore(weights, behaviors.Distribution, behaviors.ReportTimes)
+	scoreYesterday, totalYesterday := behaviorScore(weights, behaviors.DistributionYesterday, behaviors.ReportTimesYesterday)
 
-	maxWeightedSum := float64(metric.Details.Behaviors.ReportTimes)*schema.TotalOfficialBehaviorWeight + nonOfficialWeightedSum
-	// cap weighted sum of non-official behaviors
-	nonOfficialWeightedSum = math.Min(nonOfficialWeightedSum, maxWeightedSum/2)
-	weightedSum := officialWeightedSum + nonOfficialWeightedSum
-	if maxWeightedSum > 0 {
-		metric.Details.Behaviors.Score = 100 * weightedSum / maxWeightedSum
+	metric.Details.Behaviors.Score = score
+	metric.Details.Behaviors.ScoreYesterday = scoreYesterday
+
+	metric.BehaviorCount = total
+	metric.BehaviorDelta = ChangeRate(total, totalYesterday)
+}
+
+// behaviorScore returns the score of a weighted distribution of behaviors in the weighted
+// number of reports, which is 0 if there is no report, and the weighted count of behaviors
+func behaviorScore(weights schema.BehaviorWeights, distribution map[string]float64, reportTimes float64) (float64, float64) {
+	totalOfficialWeight := float64(0)
+	for _, w := range weights {
+		totalOfficialWeight += w
 	}
 
-	officialWeightedSumYesterday := float64(0)
-	nonOfficialWeightedSumYesterday := float64(0)
-	for behaviorID, cnt := range metric.Details.Behaviors.YesterdayDistribution {
-		w, ok := schema.DefaultBehaviorWeightMatrix[schema.GoodBehaviorType(behaviorID)]
+	total := float64(0)
+	officialWeightedSum := float64(0)
+	nonOfficialWeightedSum := float64(0)
+	for behaviorID, cnt := range distribution {
+		w, ok := weights[behaviorID]
 		if ok {
-			officialWeightedSumYesterday += w.Weight * float64(cnt)
+			officialWeightedSum += w * cnt
 		} else {
-			nonOfficialWeightedSumYesterday += float64(cnt)
+			nonOfficialWeightedSum += cnt
 		}
 
-		yesterdayTotal += cnt
+		total += cnt
 	}
 
-	maxWeightedSumYesterday := float64(metric.Details.Behaviors.ReportTimesYesterday)*schema.TotalOfficialBehaviorWeight + nonOfficialWeightedSumYesterday
-	// cap weighted sum of non-official behaviors
-	nonOfficialWeightedSumYesterday = math.Min(nonOfficialWeightedSumYesterday, maxWeightedSumYesterday/2)
-	weightedSumYesterday := officialWeightedSumYesterday + nonOfficialWeightedSumYesterday
-
-	if maxWeightedSumYesterday > 0 {
-		metric.Details.Behaviors.ScoreYesterday = 100 * weightedSumYesterday / maxWeightedSumYesterday
+	maxWeightedSum := reportTimes*totalOfficialWeight + nonOfficialWeightedSum
+	if maxWeightedSum <= 0 {
+		return 0, total
 	}
 
-	metric.BehaviorCount = float64(todayTotal)
-	metric.BehaviorDelta = ChangeRate(float64(todayTotal), float64(yesterdayTotal))
+	// cap weighted sum of non-official behaviors
+	nonOfficialWeightedSum = math.Min(nonOfficialWeightedSum, maxWeightedSum/2)
+	weightedSum := officialWeightedSum + nonOfficialWeightedSum
+
+	return 100 * weightedSum / maxWeightedSum, total
 }
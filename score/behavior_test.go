@@ -15,7 +15,7 @@ func TestUpdateBehaviorMetrics(t *testing.T) {
 			Behaviors: schema.BehaviorDetail{
 				ReportTimes:          50,
 				ReportTimesYesterday: 25,
-				TodayDistribution: map[string]int{
+				Distribution: map[string]float64{
 					"clean_hand":        20,
 					"social_distancing": 10,
 					"touch_face":        10,
@@ -25,13 +25,13 @@ func TestUpdateBehaviorMetrics(t *testing.T) {
 					"new_behavior_1":    5,
 					"new_behavior_2":    5,
 				},
-				YesterdayDistribution: map[string]int{
+				DistributionYesterday: map[string]float64{
 					"clean_hand": 20,
 				},
 			},
 		},
 	}
-	UpdateBehaviorMetrics(metric)
+	UpdateBehaviorMetrics(metric, nil)
 	assert.Equal(t, "25.81", fmt.Sprintf("%.2f", metric.Details.Behaviors.Score))
 	assert.Equal(t, 13.333333333333334, metric.Details.Behaviors.ScoreYesterday)
 	assert.Equal(t, 80.0, metric.BehaviorCount)
@@ -44,14 +44,14 @@ func TestCalculateBehaviorScoreNoReportToday(t *testing.T) {
 			Behaviors: schema.BehaviorDetail{
 				ReportTimes:          0,
 				ReportTimesYesterday: 5,
-				TodayDistribution:    map[string]int{},
-				YesterdayDistribution: map[string]int{
+				Distribution:         map[string]float64{},
+				DistributionYesterday: map[string]float64{
 					"clean_hand": 20,
 				},
 			},
 		},
 	}
-	UpdateBehaviorMetrics(metric)
+	UpdateBehaviorMetrics(metric, nil)
 	assert.Equal(t, 0.0, metric.Details.Behaviors.Score)
 	assert.Equal(t, 0.0, metric.BehaviorCount)
 	assert.Equal(t, -100.0, metric.BehaviorDelta)
@@ -61,14 +61,14 @@ func TestCalculateBehaviorScoreNoReportToday(t *testing.T) {
 			Behaviors: schema.BehaviorDetail{
 				ReportTimes:          100,
 				ReportTimesYesterday: 50,
-				TodayDistribution:    nil,
-				YesterdayDistribution: map[string]int{
+				Distribution:         nil,
+				DistributionYesterday: map[string]float64{
 					"clean_hand": 20,
 				},
 			},
 		},
 	}
-	UpdateBehaviorMetrics(metric)
+	UpdateBehaviorMetrics(metric, nil)
 	assert.Equal(t, 0.0, metric.Details.Behaviors.Score)
 	assert.Equal(t, 6.666666666666667, metric.Details.Behaviors.ScoreYesterday)
 	assert.Equal(t, 0.0, metric.BehaviorCount)
@@ -81,21 +81,59 @@ func TestCalculateBehaviorScoreSignificantNonOfficialBehaviors(t *testing.T) {
 			Behaviors: schema.BehaviorDetail{
 				ReportTimes:          10,
 				ReportTimesYesterday: 5,
-				TodayDistribution: map[string]int{
+				Distribution: map[string]float64{
 					"clean_hand":     5,
 					"new_behavior_1": 30,
 					"new_behavior_2": 20,
 					"new_behavior_3": 20,
 				},
-				YesterdayDistribution: map[string]int{
+				DistributionYesterday: map[string]float64{
 					"clean_hand": 20,
 				},
 			},
 		},
 	}
-	UpdateBehaviorMetrics(metric)
+	UpdateBehaviorMetrics(metric, nil)
 	assert.Equal(t, "53.85", fmt.Sprintf("%.2f", metric.Details.Behaviors.Score))
 	assert.Equal(t, 66.66666666666667, metric.Details.Behaviors.ScoreYesterday)
 	assert.Equal(t, 75.0, metric.BehaviorCount)
 	assert.Equal(t, 275.0, metric.BehaviorDelta)
 }
+
+func TestUpdateBehaviorMetricsWithWeights(t *testing.T) {
+	newMetric := func() *schema.Metric {
+		return &schema.Metric{
+			Details: schema.Details{
+				Behaviors: schema.BehaviorDetail{
+					ReportTimes: 10,
+					Distribution: map[string]float64{
+						"clean_hand": 10,
+						"wear_mask":  5,
+					},
+				},
+			},
+		}
+	}
+
+	metric := newMetric()
+	UpdateBehaviorMetrics(metric, nil)
+	assert.Equal(t, 25.0, metric.Details.Behaviors.Score) // 15 / (10*6)
+
+	// only washing hands counts
+	weights := schema.BehaviorWeights{
+		"clean_hand":        1,
+		"social_distancing": 0,
+		"touch_face":        0,
+		"wear_mask":         0,
+		"covering_coughs":   0,
+		"clean_surface":     0,
+	}
+	metric = newMetric()
+	UpdateBehaviorMetrics(metric, weights)
+	assert.Equal(t, 100.0, metric.Details.Behaviors.Score)
+
+	weights["wear_mask"] = 3
+	metric = newMetric()
+	UpdateBehaviorMetrics(metric, weights)
+	assert.Equal(t, 62.5, metric.Details.Behaviors.Score) // (10 + 3*5) / (10*4)
+}
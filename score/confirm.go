@@ -5,6 +5,7 @@ import (
 
 	"github.com/bitmark-inc/autonomy-api/consts"
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score/outbreak"
 )
 
 // datasetPrependZero prepend zero data for data set that does not have enough window size
@@ -44,10 +45,35 @@ func exponentialWeightAverage(dataset []schema.CDSScoreDataSet) float64 {
 	return score
 }
 
+// IncidencePopulation is the number of people which incidence of confirmed cases is based on
+const IncidencePopulation = 100000
+
+// datasetIncidence converts daily cases into daily cases per 100k people. Cases are kept
+// as they are if the population is unknown, which is the same as a population of 100k.
+func datasetIncidence(dataset []schema.CDSScoreDataSet, population float64) []schema.CDSScoreDataSet {
+	if population <= 0 {
+		return dataset
+	}
+
+	incidence := make([]schema.CDSScoreDataSet, len(dataset))
+	for i, d := range dataset {
+		incidence[i] = schema.CDSScoreDataSet{Name: d.Name, Cases: d.Cases / population * IncidencePopulation}
+	}
+	return incidence
+}
+
+// CalculateConfirmScore calculates the confirm score by the incidence of confirmed cases
+// in the last 14 days, so areas of different populations could be compared. The growth
+// of cases is estimated before days without data are filled with zero.
 func CalculateConfirmScore(metric *schema.Metric) {
 	details := &metric.Details.Confirm
 	dataset := details.ContinuousData
 
+	// the growth is nil if there are not enough cases to estimate it
+	growth, _ := outbreak.Estimate(dataset)
+	details.Growth = growth
+	metric.ConfirmedGrowth = growth
+
 	sizeOfConfirmData := len(dataset)
 	if 0 == sizeOfConfirmData {
 		metric.Details.Confirm.Score = 0
@@ -63,8 +89,8 @@ func CalculateConfirmScore(metric *schema.Metric) {
 		datasetYesterday = datasetPrependZero(datasetYesterday)
 	}
 
-	score := exponentialWeightAverage(dataset)
-	scoreYesterday := exponentialWeightAverage(datasetYesterday)
+	score := exponentialWeightAverage(datasetIncidence(dataset, details.Population))
+	scoreYesterday := exponentialWeightAverage(datasetIncidence(datasetYesterday, details.Population))
 
 	metric.Details.Confirm.Score = score * 100
 	metric.Details.Confirm.ScoreYesterday = scoreYesterday * 100
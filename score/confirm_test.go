@@ -70,3 +70,65 @@ func TestCalculateConfirmScoreWithTwoHighScoreAtBeginning(t *testing.T) {
 	assert.Equal(t, float64(82.22540024420839), testMetric.Details.Confirm.ScoreYesterday)
 	assert.Equal(t, float64(88.40847697894272), testMetric.Details.Confirm.Score)
 }
+
+func TestCalculateConfirmScoreByIncidence(t *testing.T) {
+	dataset := func() []schema.CDSScoreDataSet {
+		return []schema.CDSScoreDataSet{
+			{Name: "Taiwan", Cases: 3}, {Name: "Taiwan", Cases: 2}, {Name: "Taiwan", Cases: 2}, {Name: "Taiwan", Cases: 1},
+			{Name: "Taiwan", Cases: 0}, {Name: "Taiwan", Cases: 1}, {Name: "Taiwan", Cases: 30}, {Name: "Taiwan", Cases: 4},
+			{Name: "Taiwan", Cases: 0}, {Name: "Taiwan", Cases: 0}, {Name: "Taiwan", Cases: 3}, {Name: "Taiwan", Cases: 3},
+			{Name: "Taiwan", Cases: 4}, {Name: "Taiwan", Cases: 3},
+		}
+	}
+
+	unknown := &schema.Metric{Details: schema.Details{Confirm: schema.ConfirmDetail{ContinuousData: dataset()}}}
+	CalculateConfirmScore(unknown)
+
+	// a population of 100k is the same as an unknown population
+	sameAsUnknown := &schema.Metric{Details: schema.Details{Confirm: schema.ConfirmDetail{ContinuousData: dataset(), Population: IncidencePopulation}}}
+	CalculateConfirmScore(sameAsUnknown)
+	assert.Equal(t, unknown.Details.Confirm.Score, sameAsUnknown.Details.Confirm.Score)
+	assert.Equal(t, unknown.Details.Confirm.ScoreYesterday, sameAsUnknown.Details.Confirm.ScoreYesterday)
+
+	large := &schema.Metric{Details: schema.Details{Confirm: schema.ConfirmDetail{ContinuousData: dataset(), Population: 10000000}}}
+	CalculateConfirmScore(large)
+	small := &schema.Metric{Details: schema.Details{Confirm: schema.ConfirmDetail{ContinuousData: dataset(), Population: 10000}}}
+	CalculateConfirmScore(small)
+
+	assert.True(t, large.Details.Confirm.Score > unknown.Details.Confirm.Score)
+	assert.True(t, small.Details.Confirm.Score < unknown.Details.Confirm.Score)
+
+	// raw cases are kept
+	assert.Equal(t, 3.0, large.Details.Confirm.ContinuousData[0].Cases)
+}
+
+func TestCalculateConfirmScoreEstimatesGrowth(t *testing.T) {
+	testMetric := &schema.Metric{
+		Details: schema.Details{
+			Confirm: schema.ConfirmDetail{
+				ContinuousData: []schema.CDSScoreDataSet{
+					{Name: "Taiwan", Cases: 10}, {Name: "Taiwan", Cases: 11}, {Name: "Taiwan", Cases: 12}, {Name: "Taiwan", Cases: 13},
+					{Name: "Taiwan", Cases: 15}, {Name: "Taiwan", Cases: 16}, {Name: "Taiwan", Cases: 18}, {Name: "Taiwan", Cases: 20},
+					{Name: "Taiwan", Cases: 22}, {Name: "Taiwan", Cases: 24}, {Name: "Taiwan", Cases: 27}, {Name: "Taiwan", Cases: 30},
+					{Name: "Taiwan", Cases: 33}, {Name: "Taiwan", Cases: 36},
+				},
+			},
+		},
+	}
+	CalculateConfirmScore(testMetric)
+	assert.NotNil(t, testMetric.Details.Confirm.Growth)
+	assert.Equal(t, schema.GrowthTrendGrowing, testMetric.Details.Confirm.Growth.Trend)
+	assert.Equal(t, testMetric.Details.Confirm.Growth, testMetric.ConfirmedGrowth)
+
+	// no growth is estimated for too few days
+	testMetric = &schema.Metric{
+		Details: schema.Details{
+			Confirm: schema.ConfirmDetail{
+				ContinuousData: []schema.CDSScoreDataSet{{Name: "Taiwan", Cases: 78}, {Name: "Taiwan", Cases: 87}},
+			},
+		},
+	}
+	CalculateConfirmScore(testMetric)
+	assert.Nil(t, testMetric.Details.Confirm.Growth)
+	assert.Nil(t, testMetric.ConfirmedGrowth)
+}
@@ -0,0 +1,162 @@
+package score
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// ComparisonPeriod is how long ago the previous rolling window is evaluated, so that deltas
+// and scores of yesterday compare reports weighted at now with the ones weighted a day ago
+const ComparisonPeriod = 24 * time.Hour
+
+// DecayConfig decides how reports are weighted by their ages. The weight of a report halves
+// every half-life and reaches zero at the end of the window, so reports neither jump in nor
+// out of scores at a boundary of days.
+type DecayConfig struct {
+	HalfLife time.Duration
+	Window   time.Duration
+}
+
+// DefaultDecayConfig halves weights of reports every 12 hours and drops reports of 3 days ago
+var DefaultDecayConfig = DecayConfig{
+	HalfLife: 12 * time.Hour,
+	Window:   72 * time.Hour,
+}
+
+var (
+	decayLock   sync.RWMutex
+	decayConfig = DefaultDecayConfig
+)
+
+// SetDecayConfig sets the config of report weighting. Durations which are not configured
+// fall back to the default config.
+func SetDecayConfig(c DecayConfig) error {
+	if c.HalfLife == 0 {
+		c.HalfLife = DefaultDecayConfig.HalfLife
+	}
+	if c.Window == 0 {
+		c.Window = DefaultDecayConfig.Window
+	}
+
+	if c.HalfLife < 0 {
+		return fmt.Errorf("invalid decay half-life: %s", c.HalfLife)
+	}
+	if c.Window < c.HalfLife {
+		return fmt.Errorf("decay window %s is shorter than the half-life %s", c.Window, c.HalfLife)
+	}
+
+	decayLock.Lock()
+	defer decayLock.Unlock()
+	decayConfig = c
+	return nil
+}
+
+// CurrentDecayConfig returns the config of report weighting
+func CurrentDecayConfig() DecayConfig {
+	decayLock.RLock()
+	defer decayLock.RUnlock()
+	return decayConfig
+}
+
+// ReportsStartAt returns the time since when reports are needed to weight reports at `now`
+// and at the previous rolling window
+func ReportsStartAt(now time.Time) time.Time {
+	return now.Add(-CurrentDecayConfig().Window - ComparisonPeriod)
+}
+
+// DecayWeight returns the weight of a report of the age. Reports from the future are not
+// counted yet.
+func DecayWeight(c DecayConfig, age time.Duration) float64 {
+	if age < 0 || age >= c.Window {
+		return 0
+	}
+
+	// shift and scale the exponential decay to be 1 at age 0 and 0 at the end of the window
+	tail := math.Exp2(-float64(c.Window) / float64(c.HalfLife))
+	return (math.Exp2(-float64(age)/float64(c.HalfLife)) - tail) / (1 - tail)
+}
+
+// DecaySymptomReports weights symptom reports at a time. A person counts by the latest
+// report, and a symptom of a person counts by the latest report of the symptom. It returns
+// the weighted number of people and the weighted distribution of symptoms.
+func DecaySymptomReports(reports []schema.ReportRecord, at time.Time) (float64, map[string]float64) {
+	c := CurrentDecayConfig()
+
+	latest := map[string]int64{}
+	latestSymptoms := map[string]map[string]int64{}
+	for _, r := range reports {
+		if DecayWeight(c, at.Sub(time.Unix(r.Timestamp, 0))) == 0 {
+			continue
+		}
+
+		if ts, ok := latest[r.ProfileID]; !ok || r.Timestamp > ts {
+			latest[r.ProfileID] = r.Timestamp
+		}
+
+		symptoms, ok := latestSymptoms[r.ProfileID]
+		if !ok {
+			symptoms = map[string]int64{}
+			latestSymptoms[r.ProfileID] = symptoms
+		}
+		for _, id := range r.IDs {
+			if ts, ok := symptoms[id]; !ok || r.Timestamp > ts {
+				symptoms[id] = r.Timestamp
+			}
+		}
+	}
+
+	people := float64(0)
+	for _, ts := range latest {
+		people += DecayWeight(c, at.Sub(time.Unix(ts, 0)))
+	}
+
+	distribution := map[string]float64{}
+	for _, symptoms := range latestSymptoms {
+		for id, ts := range symptoms {
+			distribution[id] += DecayWeight(c, at.Sub(time.Unix(ts, 0)))
+		}
+	}
+
+	return people, distribution
+}
+
+// DecayBehaviorReports weights behavior reports at a time. Every report counts. It returns
+// the weighted number of reports and the weighted distribution of behaviors.
+func DecayBehaviorReports(reports []schema.ReportRecord, at time.Time) (float64, map[string]float64) {
+	c := CurrentDecayConfig()
+
+	times := float64(0)
+	distribution := map[string]float64{}
+	for _, r := range reports {
+		w := DecayWeight(c, at.Sub(time.Unix(r.Timestamp, 0)))
+		if w == 0 {
+			continue
+		}
+
+		times += w
+		for _, id := range r.IDs {
+			distribution[id] += w
+		}
+	}
+
+	return times, distribution
+}
+
+// CountBehaviorReports counts behaviors of reports in a period without weights. It returns
+// the number of reports of each behavior.
+func CountBehaviorReports(reports []schema.ReportRecord, start, end time.Time) map[string]int {
+	counts := map[string]int{}
+	for _, r := range reports {
+		if r.Timestamp < start.Unix() || r.Timestamp >= end.Unix() {
+			continue
+		}
+		for _, id := range r.IDs {
+			counts[id]++
+		}
+	}
+	return counts
+}
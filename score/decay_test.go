@@ -0,0 +1,182 @@
+package score
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+var decayTestMidnight = time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)
+
+// decayTestSymptomReports are reports of the evening before and the morning after midnight
+var decayTestSymptomReports = []schema.ReportRecord{
+	{ProfileID: "a", IDs: []string{"fever", "cough"}, Timestamp: decayTestMidnight.Add(-30 * time.Hour).Unix()},
+	{ProfileID: "a", IDs: []string{"cough"}, Timestamp: decayTestMidnight.Add(-3 * time.Hour).Unix()},
+	{ProfileID: "b", IDs: []string{"fever"}, Timestamp: decayTestMidnight.Add(-2 * time.Hour).Unix()},
+	{ProfileID: "c", IDs: []string{"chills", "new-symptom"}, Timestamp: decayTestMidnight.Add(-1 * time.Hour).Unix()},
+	{ProfileID: "d", IDs: []string{}, Timestamp: decayTestMidnight.Add(-80 * time.Minute).Unix()},
+	{ProfileID: "e", IDs: []string{"fever", "cough"}, Timestamp: decayTestMidnight.Add(2 * time.Hour).Unix()},
+}
+
+var decayTestBehaviorReports = []schema.ReportRecord{
+	{ProfileID: "a", IDs: []string{"clean_hand", "wear_mask"}, Timestamp: decayTestMidnight.Add(-26 * time.Hour).Unix()},
+	{ProfileID: "a", IDs: []string{"clean_hand"}, Timestamp: decayTestMidnight.Add(-4 * time.Hour).Unix()},
+	{ProfileID: "b", IDs: []string{"social_distancing", "new-behavior"}, Timestamp: decayTestMidnight.Add(-90 * time.Minute).Unix()},
+	{ProfileID: "c", IDs: []string{"touch_face"}, Timestamp: decayTestMidnight.Add(-70 * time.Minute).Unix()},
+	{ProfileID: "d", IDs: []string{"wear_mask"}, Timestamp: decayTestMidnight.Add(3 * time.Hour).Unix()},
+}
+
+// decayTestMetric calculates symptom and behavior metrics of the fixtures at a time, as
+// `CollectRawMetrics` and `CalculateMetric` do
+func decayTestMetric(at time.Time) schema.Metric {
+	people, distribution := DecaySymptomReports(decayTestSymptomReports, at)
+	peopleYesterday, distributionYesterday := DecaySymptomReports(decayTestSymptomReports, at.Add(-ComparisonPeriod))
+	reportTimes, behaviors := DecayBehaviorReports(decayTestBehaviorReports, at)
+	reportTimesYesterday, behaviorsYesterday := DecayBehaviorReports(decayTestBehaviorReports, at.Add(-ComparisonPeriod))
+
+	metric := schema.Metric{
+		Details: schema.Details{
+			Symptoms: schema.SymptomDetail{
+				TotalPeople:           people,
+				TotalPeopleYesterday:  peopleYesterday,
+				Distribution:          distribution,
+				DistributionYesterday: distributionYesterday,
+			},
+			Behaviors: schema.BehaviorDetail{
+				ReportTimes:           reportTimes,
+				ReportTimesYesterday:  reportTimesYesterday,
+				Distribution:          behaviors,
+				DistributionYesterday: behaviorsYesterday,
+			},
+		},
+	}
+	UpdateSymptomMetrics(&metric)
+	UpdateBehaviorMetrics(&metric, nil)
+	return metric
+}
+
+func TestDecayWeight(t *testing.T) {
+	c := DecayConfig{HalfLife: 12 * time.Hour, Window: 72 * time.Hour}
+	tail := 1.0 / 64
+
+	assert.Equal(t, 1.0, DecayWeight(c, 0))
+	assert.InDelta(t, (0.5-tail)/(1-tail), DecayWeight(c, 12*time.Hour), 1e-12)
+	assert.InDelta(t, (0.25-tail)/(1-tail), DecayWeight(c, 24*time.Hour), 1e-12)
+	assert.InDelta(t, 0, DecayWeight(c, 72*time.Hour-time.Second), 1e-5)
+	assert.Equal(t, 0.0, DecayWeight(c, 72*time.Hour))
+	assert.Equal(t, 0.0, DecayWeight(c, -time.Second))
+}
+
+func TestDecaySymptomReports(t *testing.T) {
+	c := CurrentDecayConfig()
+	w := func(d time.Duration) float64 { return DecayWeight(c, d) }
+
+	people, distribution := DecaySymptomReports(decayTestSymptomReports, decayTestMidnight)
+
+	// the report of `e` is not counted yet, and `a` counts by the latest report
+	assert.InDelta(t, w(3*time.Hour)+w(2*time.Hour)+w(time.Hour)+w(80*time.Minute), people, 1e-12)
+	assert.InDelta(t, w(30*time.Hour)+w(2*time.Hour), distribution["fever"], 1e-12)
+	assert.InDelta(t, w(3*time.Hour), distribution["cough"], 1e-12)
+	assert.InDelta(t, w(time.Hour), distribution["chills"], 1e-12)
+	assert.InDelta(t, w(time.Hour), distribution["new-symptom"], 1e-12)
+	assert.Len(t, distribution, 4)
+
+	people, distribution = DecaySymptomReports(decayTestSymptomReports, decayTestMidnight.Add(-100*24*time.Hour))
+	assert.Equal(t, 0.0, people)
+	assert.Empty(t, distribution)
+}
+
+func TestDecayBehaviorReports(t *testing.T) {
+	c := CurrentDecayConfig()
+	w := func(d time.Duration) float64 { return DecayWeight(c, d) }
+
+	times, distribution := DecayBehaviorReports(decayTestBehaviorReports, decayTestMidnight)
+
+	// every report counts
+	assert.InDelta(t, w(26*time.Hour)+w(4*time.Hour)+w(90*time.Minute)+w(70*time.Minute), times, 1e-12)
+	assert.InDelta(t, w(26*time.Hour)+w(4*time.Hour), distribution["clean_hand"], 1e-12)
+	assert.InDelta(t, w(26*time.Hour), distribution["wear_mask"], 1e-12)
+	assert.InDelta(t, w(90*time.Minute), distribution["new-behavior"], 1e-12)
+	assert.Len(t, distribution, 5)
+}
+
+func TestCountBehaviorReports(t *testing.T) {
+	// reports of the last 24 hours and the 24 hours before
+	assert.Equal(t, map[string]int{
+		"clean_hand":        1,
+		"social_distancing": 1,
+		"new-behavior":      1,
+		"touch_face":        1,
+	}, CountBehaviorReports(decayTestBehaviorReports, decayTestMidnight.Add(-ComparisonPeriod), decayTestMidnight))
+	assert.Equal(t, map[string]int{
+		"clean_hand": 1,
+		"wear_mask":  1,
+	}, CountBehaviorReports(decayTestBehaviorReports, decayTestMidnight.Add(-2*ComparisonPeriod), decayTestMidnight.Add(-ComparisonPeriod)))
+}
+
+// TestMetricsContinuousAtMidnight checks scores and deltas change smoothly around the
+// midnight of UTC, when reports of today used to become the ones of yesterday
+func TestMetricsContinuousAtMidnight(t *testing.T) {
+	before := decayTestMetric(decayTestMidnight.Add(-time.Second))
+	after := decayTestMetric(decayTestMidnight.Add(time.Second))
+
+	assert.InDelta(t, before.Details.Symptoms.Score, after.Details.Symptoms.Score, 0.01)
+	assert.InDelta(t, before.Details.Symptoms.ScoreYesterday, after.Details.Symptoms.ScoreYesterday, 0.01)
+	assert.InDelta(t, before.Details.Behaviors.Score, after.Details.Behaviors.Score, 0.01)
+	assert.InDelta(t, before.Details.Behaviors.ScoreYesterday, after.Details.Behaviors.ScoreYesterday, 0.01)
+	assert.InDelta(t, before.SymptomCount, after.SymptomCount, 0.01)
+	assert.InDelta(t, before.SymptomDelta, after.SymptomDelta, 0.1)
+	assert.InDelta(t, before.BehaviorCount, after.BehaviorCount, 0.01)
+	assert.InDelta(t, before.BehaviorDelta, after.BehaviorDelta, 0.1)
+
+	// the reports of the evening still count after midnight
+	assert.True(t, after.SymptomCount > 3)
+	assert.True(t, after.Details.Symptoms.Score < 100)
+	assert.True(t, after.BehaviorCount > 3)
+	assert.True(t, after.Details.Behaviors.Score > 0)
+
+	// no step between minutes from 23:00 to 01:00 when no report arrives
+	prev := decayTestMetric(decayTestMidnight.Add(-time.Hour))
+	for m := -59; m <= 60; m++ {
+		metric := decayTestMetric(decayTestMidnight.Add(time.Duration(m) * time.Minute))
+		assert.True(t, math.Abs(metric.Details.Symptoms.Score-prev.Details.Symptoms.Score) < 0.1, "symptom score step at minute %d", m)
+		assert.True(t, math.Abs(metric.Details.Behaviors.Score-prev.Details.Behaviors.Score) < 0.1, "behavior score step at minute %d", m)
+		assert.True(t, math.Abs(metric.SymptomCount-prev.SymptomCount) < 0.01, "symptom count step at minute %d", m)
+		assert.True(t, math.Abs(metric.BehaviorCount-prev.BehaviorCount) < 0.01, "behavior count step at minute %d", m)
+		prev = metric
+	}
+}
+
+func TestMetricsForgetOldReports(t *testing.T) {
+	// reports are forgotten gradually until the window passes the latest one
+	metric := decayTestMetric(decayTestMidnight.Add(48 * time.Hour))
+	assert.True(t, metric.Details.Symptoms.Score < 100)
+	assert.True(t, metric.SymptomCount > 0)
+	assert.True(t, metric.SymptomDelta < 0)
+	assert.True(t, metric.BehaviorDelta < 0)
+
+	metric = decayTestMetric(decayTestMidnight.Add(75 * time.Hour))
+	assert.Equal(t, 100.0, metric.Details.Symptoms.Score)
+	assert.Equal(t, 0.0, metric.Details.Behaviors.Score)
+	assert.Equal(t, 0.0, metric.SymptomCount)
+	assert.Equal(t, 0.0, metric.BehaviorCount)
+	assert.Equal(t, -100.0, metric.SymptomDelta)
+	assert.Equal(t, -100.0, metric.BehaviorDelta)
+}
+
+func TestSetDecayConfig(t *testing.T) {
+	defer SetDecayConfig(DefaultDecayConfig)
+
+	assert.NoError(t, SetDecayConfig(DecayConfig{HalfLife: 6 * time.Hour}))
+	assert.Equal(t, DecayConfig{HalfLife: 6 * time.Hour, Window: 72 * time.Hour}, CurrentDecayConfig())
+	assert.Equal(t, decayTestMidnight.Add(-96*time.Hour), ReportsStartAt(decayTestMidnight))
+
+	assert.EqualError(t, SetDecayConfig(DecayConfig{HalfLife: -time.Hour}), "invalid decay half-life: -1h0m0s")
+	assert.EqualError(t, SetDecayConfig(DecayConfig{HalfLife: 24 * time.Hour, Window: 12 * time.Hour}),
+		"decay window 12h0m0s is shorter than the half-life 24h0m0s")
+	assert.Equal(t, 6*time.Hour, CurrentDecayConfig().HalfLife)
+}
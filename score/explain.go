@@ -0,0 +1,231 @@
+package score
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// components of scores
+const (
+	ComponentIndividual   = "individual"
+	ComponentNeighborhood = "neighborhood"
+	ComponentResources    = "resources"
+	ComponentSymptoms     = "symptoms"
+	ComponentBehaviors    = "behaviors"
+	ComponentConfirm      = "confirm"
+)
+
+// number of symptoms and behaviors listed as drivers of a change
+const explanationDriverCount = 3
+
+// Component is a part of an explained score. The contribution is the part of the score
+// which comes from the component, and the impact is the change of the explained score
+// caused by the change of the component since yesterday.
+type Component struct {
+	ID                    string  `json:"id"`
+	Name                  string  `json:"name"`
+	Value                 float64 `json:"value"`
+	ValueYesterday        float64 `json:"value_yesterday"`
+	Weight                float64 `json:"weight"`
+	Contribution          float64 `json:"contribution"`
+	ContributionYesterday float64 `json:"contribution_yesterday"`
+	Impact                float64 `json:"impact"`
+}
+
+// Driver is a symptom or a behavior whose reports in the neighborhood changed since yesterday
+type Driver struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Today     int    `json:"today"`
+	Yesterday int    `json:"yesterday"`
+	Delta     int    `json:"delta"`
+}
+
+// Explanation tells how a score is composed and why it changed since yesterday. The
+// neighborhood score is broken down further into symptoms, behaviors and confirmed cases.
+type Explanation struct {
+	FormulaVersion string                  `json:"formula_version"`
+	Coefficient    schema.ScoreCoefficient `json:"coefficient"`
+	Score          float64                 `json:"score"`
+	ScoreYesterday float64                 `json:"score_yesterday"`
+	ScoreDelta     float64                 `json:"score_delta"`
+	Summary        string                  `json:"summary"`
+	Components     []Component             `json:"components"`
+	Neighborhood   []Component             `json:"neighborhood"`
+	Symptoms       []Driver                `json:"symptoms"`
+	Behaviors      []Driver                `json:"behaviors"`
+}
+
+// MainComponent returns the component which changes the score the most. Parts of the
+// neighborhood are taken instead of the whole neighborhood. It returns nil if nothing changed.
+func (e Explanation) MainComponent() *Component {
+	candidates := make([]Component, 0, len(e.Components)+len(e.Neighborhood))
+	for _, c := range e.Components {
+		if c.ID != ComponentNeighborhood {
+			candidates = append(candidates, c)
+		}
+	}
+	candidates = append(candidates, e.Neighborhood...)
+
+	var main *Component
+	for i, c := range candidates {
+		if c.Impact == 0 {
+			continue
+		}
+		if main == nil || math.Abs(c.Impact) > math.Abs(main.Impact) {
+			main = &candidates[i]
+		}
+	}
+	return main
+}
+
+// ExplainMetric explains the score of a neighborhood calculated by `CalculateMetric`
+func ExplainMetric(formula ScoreFormula, metric schema.Metric, coefficient *schema.ScoreCoefficient) Explanation {
+	c := formula.DefaultCoefficient()
+	if coefficient != nil {
+		c = *coefficient
+	}
+	if len(c.BehaviorWeights) == 0 {
+		c.BehaviorWeights = schema.DefaultBehaviorWeights()
+	}
+
+	details := metric.Details
+	parts := []struct {
+		id                    string
+		value, valueYesterday float64
+		total                 func(float64) float64
+	}{
+		{ComponentSymptoms, details.Symptoms.Score, details.Symptoms.ScoreYesterday, func(v float64) float64 { return formula.TotalScore(c, v, 0, 0) }},
+		{ComponentBehaviors, details.Behaviors.Score, details.Behaviors.ScoreYesterday, func(v float64) float64 { return formula.TotalScore(c, 0, v, 0) }},
+		{ComponentConfirm, details.Confirm.Score, details.Confirm.ScoreYesterday, func(v float64) float64 { return formula.TotalScore(c, 0, 0, v) }},
+	}
+
+	neighborhood := make([]Component, 0, len(parts))
+	for _, p := range parts {
+		neighborhood = append(neighborhood, newComponent(p.id, p.value, p.valueYesterday, p.total))
+	}
+
+	return Explanation{
+		FormulaVersion: formula.Version(),
+		Coefficient:    c,
+		Score:          metric.Score,
+		ScoreYesterday: metric.ScoreYesterday,
+		ScoreDelta:     ChangeRate(metric.Score, metric.ScoreYesterday),
+		Components:     []Component{},
+		Neighborhood:   neighborhood,
+		Symptoms:       topDrivers(details.Symptoms.TodayData.WeightDistribution, details.Symptoms.YesterdayData.WeightDistribution),
+		Behaviors:      topDrivers(details.Behaviors.TodayDistribution, details.Behaviors.YesterdayDistribution),
+	}
+}
+
+// ExplainIndividualScore explains the autonomy score of an individual
+// calculated by `CalculateIndividualAutonomyScore`
+func ExplainIndividualScore(formula ScoreFormula, individual schema.IndividualMetric, metric schema.Metric, coefficient *schema.ScoreCoefficient) Explanation {
+	e := ExplainMetric(formula, metric, coefficient)
+
+	e.Score = formula.IndividualScore(metric.Score, individual.Score)
+	e.ScoreYesterday = formula.IndividualScore(metric.ScoreYesterday, individual.ScoreYesterday)
+	e.ScoreDelta = ChangeRate(e.Score, e.ScoreYesterday)
+
+	neighbor := func(v float64) float64 { return formula.IndividualScore(v, 0) }
+	e.Components = []Component{
+		newComponent(ComponentIndividual, individual.Score, individual.ScoreYesterday, func(v float64) float64 { return formula.IndividualScore(0, v) }),
+		newComponent(ComponentNeighborhood, metric.Score, metric.ScoreYesterday, neighbor),
+	}
+	e.Neighborhood = scaleImpacts(e.Neighborhood, neighbor(1))
+
+	return e
+}
+
+// ExplainPOIScore explains the autonomy score of a POI calculated by `CalculatePOIAutonomyScore`
+func ExplainPOIScore(formula ScoreFormula, placeType string, resources []schema.POIResourceRating, metric schema.Metric) Explanation {
+	e := ExplainMetric(formula, metric, nil)
+
+	// the score of a POI without any resource is the one of its neighborhood
+	if len(resources) == 0 {
+		e.Components = []Component{
+			newComponent(ComponentNeighborhood, metric.Score, metric.ScoreYesterday, func(v float64) float64 { return v }),
+		}
+		return e
+	}
+
+	resourceScore, resourceScoreYesterday := ResourceAutonomyScore(placeType, resources, time.Now())
+	e.Score = formula.POIScore(metric.Score, resourceScore)
+	e.ScoreYesterday = formula.POIScore(metric.ScoreYesterday, resourceScoreYesterday)
+	e.ScoreDelta = ChangeRate(e.Score, e.ScoreYesterday)
+
+	neighbor := func(v float64) float64 { return formula.POIScore(v, 0) }
+	e.Components = []Component{
+		newComponent(ComponentResources, resourceScore, resourceScoreYesterday, func(v float64) float64 { return formula.POIScore(0, v) }),
+		newComponent(ComponentNeighborhood, metric.Score, metric.ScoreYesterday, neighbor),
+	}
+	e.Neighborhood = scaleImpacts(e.Neighborhood, neighbor(1))
+
+	return e
+}
+
+// newComponent explains a component by the part of a formula which only takes the component.
+// The weight is the contribution of a unit value, which is exact for linear formulas.
+func newComponent(id string, value, valueYesterday float64, part func(float64) float64) Component {
+	contribution := part(value)
+	contributionYesterday := part(valueYesterday)
+	return Component{
+		ID:                    id,
+		Value:                 value,
+		ValueYesterday:        valueYesterday,
+		Weight:                part(1),
+		Contribution:          contribution,
+		ContributionYesterday: contributionYesterday,
+		Impact:                contribution - contributionYesterday,
+	}
+}
+
+// scaleImpacts converts impacts on the neighborhood score into impacts on a score
+// which takes the neighborhood score by the weight
+func scaleImpacts(components []Component, weight float64) []Component {
+	for i := range components {
+		components[i].Impact *= weight
+	}
+	return components
+}
+
+// topDrivers returns items whose counts changed the most since yesterday
+func topDrivers(today, yesterday map[string]int) []Driver {
+	drivers := make([]Driver, 0)
+	for id, cnt := range today {
+		if d := cnt - yesterday[id]; d != 0 {
+			drivers = append(drivers, Driver{ID: id, Today: cnt, Yesterday: yesterday[id], Delta: d})
+		}
+	}
+	for id, cnt := range yesterday {
+		if _, ok := today[id]; !ok && cnt != 0 {
+			drivers = append(drivers, Driver{ID: id, Yesterday: cnt, Delta: -cnt})
+		}
+	}
+
+	sort.Slice(drivers, func(i, j int) bool {
+		di, dj := abs(drivers[i].Delta), abs(drivers[j].Delta)
+		if di != dj {
+			return di > dj
+		}
+		if drivers[i].Today != drivers[j].Today {
+			return drivers[i].Today > drivers[j].Today
+		}
+		return drivers[i].ID < drivers[j].ID
+	})
+
+	if len(drivers) > explanationDriverCount {
+		drivers = drivers[:explanationDriverCount]
+	}
+	return drivers
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
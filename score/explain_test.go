@@ -0,0 +1,136 @@
+package score
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+func testExplainedMetric() schema.Metric {
+	return schema.Metric{
+		Score:          55,
+		ScoreYesterday: 60,
+		FormulaVersion: FormulaVersionV1,
+		Details: schema.Details{
+			Symptoms: schema.SymptomDetail{
+				Score:          80,
+				ScoreYesterday: 60,
+				TodayData: schema.NearestSymptomData{
+					WeightDistribution: map[string]int{"fever": 3, "cough": 1, "fatigue": 2, "loss_taste_smell": 2},
+				},
+				YesterdayData: schema.NearestSymptomData{
+					WeightDistribution: map[string]int{"cough": 2, "headache": 1, "loss_taste_smell": 2},
+				},
+			},
+			Behaviors: schema.BehaviorDetail{
+				Score:                 40,
+				ScoreYesterday:        40,
+				TodayDistribution:     map[string]int{"clean_hand": 2},
+				YesterdayDistribution: map[string]int{"clean_hand": 2},
+			},
+			Confirm: schema.ConfirmDetail{
+				Score:          50,
+				ScoreYesterday: 70,
+			},
+		},
+	}
+}
+
+func TestExplainMetric(t *testing.T) {
+	e := ExplainMetric(FormulaV1{}, testExplainedMetric(), nil)
+
+	assert.Equal(t, FormulaVersionV1, e.FormulaVersion)
+	assert.Equal(t, DefaultScoreV1SymptomCoefficient, e.Coefficient.Symptoms)
+	assert.Equal(t, schema.DefaultBehaviorWeights(), e.Coefficient.BehaviorWeights)
+	assert.Equal(t, 55.0, e.Score)
+	assert.Equal(t, 60.0, e.ScoreYesterday)
+
+	assert.Len(t, e.Neighborhood, 3)
+	var total, totalYesterday float64
+	for _, c := range e.Neighborhood {
+		total += c.Contribution
+		totalYesterday += c.ContributionYesterday
+	}
+	assert.InDelta(t, e.Score, total, 1e-9)
+	assert.InDelta(t, e.ScoreYesterday, totalYesterday, 1e-9)
+
+	assert.Equal(t, ComponentConfirm, e.Neighborhood[2].ID)
+	assert.Equal(t, DefaultScoreV1ConfirmCoefficient, e.Neighborhood[2].Weight)
+	assert.InDelta(t, -10, e.Neighborhood[2].Impact, 1e-9)
+
+	assert.Equal(t, []Driver{
+		{ID: "fever", Today: 3, Delta: 3},
+		{ID: "fatigue", Today: 2, Delta: 2},
+		{ID: "cough", Today: 1, Yesterday: 2, Delta: -1},
+	}, e.Symptoms)
+	assert.Empty(t, e.Behaviors)
+
+	main := e.MainComponent()
+	assert.NotNil(t, main)
+	assert.Equal(t, ComponentConfirm, main.ID)
+}
+
+func TestExplainIndividualScore(t *testing.T) {
+	metric := testExplainedMetric()
+	individual := schema.IndividualMetric{Score: 90, ScoreYesterday: 80}
+
+	e := ExplainIndividualScore(FormulaV1{}, individual, metric, nil)
+
+	score, delta := CalculateIndividualAutonomyScore(individual, metric)
+	assert.InDelta(t, score, e.Score, 1e-9)
+	assert.InDelta(t, delta, e.ScoreDelta, 1e-9)
+
+	assert.Equal(t, ComponentIndividual, e.Components[0].ID)
+	assert.InDelta(t, 0.8, e.Components[0].Weight, 1e-9)
+	assert.InDelta(t, 8, e.Components[0].Impact, 1e-9)
+	assert.Equal(t, ComponentNeighborhood, e.Components[1].ID)
+	assert.InDelta(t, -1, e.Components[1].Impact, 1e-9)
+	assert.InDelta(t, e.Score, e.Components[0].Contribution+e.Components[1].Contribution, 1e-9)
+
+	// impacts of parts of the neighborhood are scaled by its weight
+	assert.InDelta(t, 1, e.Neighborhood[0].Impact, 1e-9)
+	assert.InDelta(t, -2, e.Neighborhood[2].Impact, 1e-9)
+
+	assert.Equal(t, ComponentIndividual, e.MainComponent().ID)
+}
+
+func TestExplainPOIScore(t *testing.T) {
+	metric := testExplainedMetric()
+
+	e := ExplainPOIScore(FormulaV1{}, "", nil, metric)
+	assert.Equal(t, metric.Score, e.Score)
+	assert.Len(t, e.Components, 1)
+	assert.Equal(t, ComponentNeighborhood, e.Components[0].ID)
+	assert.Equal(t, 1.0, e.Components[0].Weight)
+
+	now := time.Now()
+	resources := []schema.POIResourceRating{
+		{
+			Resource: schema.Resource{ID: "resource_1"},
+			Score:    4.5,
+			Ratings:  6,
+			Weighted: schema.WeightedRatings{Sum: 27, Count: 6, At: now.Unix()},
+			History:  []schema.WeightedRatings{{Sum: 5, Count: 1, At: now.Add(-ComparisonPeriod).Unix()}},
+		},
+	}
+	e = ExplainPOIScore(FormulaV1{}, "", resources, metric)
+
+	score, scoreYesterday, delta := CalculatePOIAutonomyScore("", resources, metric)
+	assert.InDelta(t, score, e.Score, 1e-9)
+	assert.InDelta(t, scoreYesterday, e.ScoreYesterday, 1e-9)
+	assert.InDelta(t, delta, e.ScoreDelta, 1e-9)
+
+	assert.Equal(t, ComponentResources, e.Components[0].ID)
+	// (5 * 3 + 27) / 11 and (5 * 3 + 5) / 6 scaled to 100
+	assert.InDelta(t, 76.363636, e.Components[0].Value, 1e-4)
+	assert.InDelta(t, 66.666667, e.Components[0].ValueYesterday, 1e-4)
+	assert.InDelta(t, e.Score, e.Components[0].Contribution+e.Components[1].Contribution, 1e-9)
+}
+
+func TestMainComponentWithoutChanges(t *testing.T) {
+	e := ExplainMetric(FormulaV1{}, schema.Metric{}, nil)
+	assert.Nil(t, e.MainComponent())
+}
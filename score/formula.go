@@ -0,0 +1,180 @@
+package score
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const (
+	FormulaVersionV1 = "v1"
+)
+
+var ErrUnknownFormula = errors.New("unknown score formula")
+
+// ScoreFormula combines scores of components into autonomy scores. Every score calculated
+// by a formula is saved along with its version, so scores of different versions could be
+// told apart and interpreted by the same formula later.
+type ScoreFormula interface {
+	Version() string
+
+	// DefaultCoefficient returns the coefficient of an area if it is not customized
+	DefaultCoefficient() schema.ScoreCoefficient
+
+	// TotalScore combines scores of symptoms, behaviors and confirmed cases of an area
+	TotalScore(c schema.ScoreCoefficient, symptomScore, behaviorScore, confirmedScore float64) float64
+
+	// IndividualScore combines the score of the neighborhood and the one of an individual
+	IndividualScore(neighborScore, individualScore float64) float64
+
+	// POIScore combines the score of the neighborhood and the one of resources of a POI
+	POIScore(neighborScore, resourceScore float64) float64
+}
+
+// FormulaV1 is a linear mix of component scores
+type FormulaV1 struct{}
+
+func (FormulaV1) Version() string {
+	return FormulaVersionV1
+}
+
+func (FormulaV1) DefaultCoefficient() schema.ScoreCoefficient {
+	return schema.ScoreCoefficient{
+		Symptoms:  DefaultScoreV1SymptomCoefficient,
+		Behaviors: DefaultScoreV1BehaviorCoefficient,
+		Confirms:  DefaultScoreV1ConfirmCoefficient,
+	}
+}
+
+func (FormulaV1) TotalScore(c schema.ScoreCoefficient, symptomScore, behaviorScore, confirmedScore float64) float64 {
+	return TotalScoreV1(c, symptomScore, behaviorScore, confirmedScore)
+}
+
+func (FormulaV1) IndividualScore(neighborScore, individualScore float64) float64 {
+	return neighborScore*0.2 + individualScore*0.8
+}
+
+func (FormulaV1) POIScore(neighborScore, resourceScore float64) float64 {
+	return 0.2*neighborScore + 0.8*resourceScore
+}
+
+// FormulaConfig decides the formula to calculate new scores. A formula is rolled out to a
+// percentage of accounts before it becomes the default one.
+type FormulaConfig struct {
+	Version        string
+	RolloutVersion string
+	RolloutPercent int
+}
+
+var (
+	formulaLock   sync.RWMutex
+	formulas      = map[string]ScoreFormula{}
+	formulaConfig = FormulaConfig{Version: FormulaVersionV1}
+)
+
+func init() {
+	RegisterFormula(FormulaV1{})
+}
+
+// RegisterFormula adds a formula into the registry. It panics if the version is registered.
+func RegisterFormula(f ScoreFormula) {
+	formulaLock.Lock()
+	defer formulaLock.Unlock()
+
+	if _, ok := formulas[f.Version()]; ok {
+		panic(fmt.Sprintf("score formula %s is registered twice", f.Version()))
+	}
+	formulas[f.Version()] = f
+}
+
+// FormulaVersions returns versions of all registered formulas
+func FormulaVersions() []string {
+	formulaLock.RLock()
+	defer formulaLock.RUnlock()
+
+	versions := make([]string, 0, len(formulas))
+	for v := range formulas {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Formula returns the registered formula of a version
+func Formula(version string) (ScoreFormula, error) {
+	formulaLock.RLock()
+	defer formulaLock.RUnlock()
+
+	f, ok := formulas[version]
+	if !ok {
+		return nil, ErrUnknownFormula
+	}
+	return f, nil
+}
+
+// SetFormulaConfig switches formulas for new scores. Versions must be registered.
+func SetFormulaConfig(c FormulaConfig) error {
+	if c.Version == "" {
+		c.Version = FormulaVersionV1
+	}
+
+	if _, err := Formula(c.Version); err != nil {
+		return fmt.Errorf("%s: %s", err, c.Version)
+	}
+
+	if c.RolloutVersion != "" {
+		if _, err := Formula(c.RolloutVersion); err != nil {
+			return fmt.Errorf("%s: %s", err, c.RolloutVersion)
+		}
+		if c.RolloutPercent < 0 || c.RolloutPercent > 100 {
+			return fmt.Errorf("invalid rollout percent: %d", c.RolloutPercent)
+		}
+	}
+
+	formulaLock.Lock()
+	defer formulaLock.Unlock()
+	formulaConfig = c
+	return nil
+}
+
+// DefaultFormula returns the formula for scores which are shared by all accounts, e.g. POIs
+func DefaultFormula() ScoreFormula {
+	formulaLock.RLock()
+	defer formulaLock.RUnlock()
+	return formulas[formulaConfig.Version]
+}
+
+// AccountFormula returns the formula for scores of an account. An account always gets the
+// same formula for the same config, so its scores do not switch between formulas.
+func AccountFormula(accountNumber string) ScoreFormula {
+	formulaLock.RLock()
+	defer formulaLock.RUnlock()
+
+	if c := formulaConfig; c.RolloutVersion != "" && c.RolloutPercent > 0 {
+		h := fnv.New32a()
+		h.Write([]byte(accountNumber))
+		if int(h.Sum32()%100) < c.RolloutPercent {
+			return formulas[c.RolloutVersion]
+		}
+	}
+
+	return formulas[formulaConfig.Version]
+}
+
+// LookupFormula returns the formula which calculated a score of the given version. Scores
+// saved before formulas were versioned are calculated by V1. The default formula is
+// returned for versions which are no longer registered.
+func LookupFormula(version string) ScoreFormula {
+	if version == "" {
+		version = FormulaVersionV1
+	}
+
+	if f, err := Formula(version); err == nil {
+		return f
+	}
+	return DefaultFormula()
+}
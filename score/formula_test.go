@@ -0,0 +1,112 @@
+package score
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// testFormula only counts the individual and resources
+type testFormula struct {
+	FormulaV1
+}
+
+func (testFormula) Version() string {
+	return "test"
+}
+
+func (testFormula) IndividualScore(neighborScore, individualScore float64) float64 {
+	return individualScore
+}
+
+func (testFormula) POIScore(neighborScore, resourceScore float64) float64 {
+	return resourceScore
+}
+
+func init() {
+	RegisterFormula(testFormula{})
+}
+
+func TestFormulaRegistry(t *testing.T) {
+	assert.Equal(t, []string{"test", FormulaVersionV1}, FormulaVersions())
+
+	f, err := Formula("test")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", f.Version())
+
+	_, err = Formula("v0")
+	assert.Equal(t, ErrUnknownFormula, err)
+
+	assert.Panics(t, func() { RegisterFormula(FormulaV1{}) })
+}
+
+func TestSetFormulaConfig(t *testing.T) {
+	defer SetFormulaConfig(FormulaConfig{})
+
+	assert.Error(t, SetFormulaConfig(FormulaConfig{Version: "v0"}))
+	assert.Error(t, SetFormulaConfig(FormulaConfig{RolloutVersion: "v0", RolloutPercent: 10}))
+	assert.Error(t, SetFormulaConfig(FormulaConfig{RolloutVersion: "test", RolloutPercent: 101}))
+
+	assert.NoError(t, SetFormulaConfig(FormulaConfig{}))
+	assert.Equal(t, FormulaVersionV1, DefaultFormula().Version())
+
+	assert.NoError(t, SetFormulaConfig(FormulaConfig{Version: "test"}))
+	assert.Equal(t, "test", DefaultFormula().Version())
+	assert.Equal(t, "test", AccountFormula("account").Version())
+}
+
+func TestAccountFormulaRollout(t *testing.T) {
+	defer SetFormulaConfig(FormulaConfig{})
+	assert.NoError(t, SetFormulaConfig(FormulaConfig{RolloutVersion: "test", RolloutPercent: 20}))
+
+	rolledOut := 0
+	for i := 0; i < 10000; i++ {
+		accountNumber := fmt.Sprintf("account-%d", i)
+		f := AccountFormula(accountNumber)
+		// the formula of an account is stable
+		assert.Equal(t, f, AccountFormula(accountNumber))
+		if f.Version() == "test" {
+			rolledOut++
+		}
+	}
+	assert.InDelta(t, 2000, rolledOut, 200)
+
+	// the default formula is used by scores shared by accounts
+	assert.Equal(t, FormulaVersionV1, DefaultFormula().Version())
+}
+
+func TestLookupFormula(t *testing.T) {
+	assert.Equal(t, FormulaVersionV1, LookupFormula("").Version())
+	assert.Equal(t, "test", LookupFormula("test").Version())
+	assert.Equal(t, FormulaVersionV1, LookupFormula("removed").Version())
+}
+
+func TestCalculateMetricByFormula(t *testing.T) {
+	metric := CalculateMetric(FormulaV1{}, schema.Metric{}, nil)
+	assert.Equal(t, FormulaVersionV1, metric.FormulaVersion)
+
+	metric = CalculateMetric(testFormula{}, schema.Metric{}, nil)
+	assert.Equal(t, "test", metric.FormulaVersion)
+}
+
+func TestAutonomyScoresByMetricFormula(t *testing.T) {
+	individual := schema.IndividualMetric{Score: 50, ScoreYesterday: 40}
+	neighbor := schema.Metric{Score: 100, ScoreYesterday: 100}
+
+	// scores without versions are calculated by V1
+	score, _ := CalculateIndividualAutonomyScore(individual, neighbor)
+	assert.Equal(t, 60.0, score)
+
+	neighbor.FormulaVersion = "test"
+	score, delta := CalculateIndividualAutonomyScore(individual, neighbor)
+	assert.Equal(t, 50.0, score)
+	assert.Equal(t, 25.0, delta)
+
+	// 5 ratings of 5 are averaged with 5 ratings of 3 of the default prior
+	resources := []schema.POIResourceRating{{Score: 5, Ratings: 5}}
+	score, _, _ = CalculatePOIAutonomyScore("", resources, neighbor)
+	assert.InDelta(t, 80.0, score, 1e-9)
+}
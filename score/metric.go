@@ -44,48 +44,27 @@ func CheckScoreColorChange(oldScore, newScore float64) bool {
 	return oldScoreMod != newScoreMod
 }
 
-// CheckSymptomSpike check if there is a spike for symptoms distribution
-// for a given metric data
-func CheckSymptomSpike(yesterdayDistribution, currentDistribution schema.SymptomDistribution) []string {
-	spikeSymptoms := []string{}
-
-	for t, c1 := range currentDistribution {
-		if c0, ok := yesterdayDistribution[t]; ok {
-			// check if current symptoms count has 10% more than yesterday
-			if float64(c1)/float64(c0) > 1.1 {
-				spikeSymptoms = append(spikeSymptoms, t)
-			}
-		} else {
-			spikeSymptoms = append(spikeSymptoms, t)
-		}
+// CalculateMetric will calculate, summarize and return a metric based on collected raw metrics.
+// The default coefficient of the formula is used if the coefficient is not customized.
+func CalculateMetric(formula ScoreFormula, rawMetrics schema.Metric, coefficient *schema.ScoreCoefficient) schema.Metric {
+	metric := rawMetrics
 
+	c := formula.DefaultCoefficient()
+	if coefficient != nil {
+		c = *coefficient
 	}
 
-	return spikeSymptoms
-}
-
-// CalculateMetric will calculate, summarize and return a metric based on collected raw metrics
-func CalculateMetric(rawMetrics schema.Metric, coefficient *schema.ScoreCoefficient) schema.Metric {
-	metric := rawMetrics
-
 	UpdateSymptomMetrics(&metric)
-	UpdateBehaviorMetrics(&metric)
+	UpdateBehaviorMetrics(&metric, c.BehaviorWeights)
 	CalculateConfirmScore(&metric)
 
-	if coefficient != nil {
-		metric.Score = TotalScoreV1(*coefficient, metric.Details.Symptoms.Score, metric.Details.Behaviors.Score, metric.Details.Confirm.Score)
-		metric.ScoreYesterday = TotalScoreV1(*coefficient,
-			metric.Details.Symptoms.ScoreYesterday,
-			metric.Details.Behaviors.ScoreYesterday,
-			metric.Details.Confirm.ScoreYesterday)
-	} else {
-		metric.Score = DefaultTotalScore(metric.Details.Symptoms.Score, metric.Details.Behaviors.Score, metric.Details.Confirm.Score)
-		metric.ScoreYesterday = DefaultTotalScore(
-			metric.Details.Symptoms.ScoreYesterday,
-			metric.Details.Behaviors.ScoreYesterday,
-			metric.Details.Confirm.ScoreYesterday)
-	}
+	metric.Score = formula.TotalScore(c, metric.Details.Symptoms.Score, metric.Details.Behaviors.Score, metric.Details.Confirm.Score)
+	metric.ScoreYesterday = formula.TotalScore(c,
+		metric.Details.Symptoms.ScoreYesterday,
+		metric.Details.Behaviors.ScoreYesterday,
+		metric.Details.Confirm.ScoreYesterday)
 	metric.ScoreDelta = ChangeRate(metric.Score, metric.ScoreYesterday)
+	metric.FormulaVersion = formula.Version()
 
 	return metric
 }
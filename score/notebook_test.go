@@ -0,0 +1,102 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// Tests in this file reproduce the examples of share/jupyter/autonomyFormula.ipynb.
+// Both of them must be updated together when a formula is changed.
+
+const notebookDelta = 1e-9
+
+func notebookDataset(cases ...float64) []schema.CDSScoreDataSet {
+	dataset := make([]schema.CDSScoreDataSet, len(cases))
+	for i, c := range cases {
+		dataset[i] = schema.CDSScoreDataSet{Name: "Taiwan", Cases: c}
+	}
+	return dataset
+}
+
+func notebookSymptomScore() float64 {
+	metric := &schema.Metric{
+		Details: schema.Details{
+			Symptoms: schema.SymptomDetail{
+				TotalPeople: 4,
+				Distribution: map[string]float64{
+					"fever":        2,
+					"cough":        2,
+					"chills":       1,
+					"muscle_pain":  1,
+					"customized01": 1,
+					"customized02": 1,
+				},
+			},
+		},
+	}
+	UpdateSymptomMetrics(metric)
+	return metric.Details.Symptoms.Score
+}
+
+func notebookBehaviorScore() float64 {
+	metric := &schema.Metric{
+		Details: schema.Details{
+			Behaviors: schema.BehaviorDetail{
+				ReportTimes: 4,
+				Distribution: map[string]float64{
+					"clean_hand":        2,
+					"social_distancing": 1,
+					"touch_face":        1,
+					"customized01":      1,
+					"customized02":      1,
+				},
+			},
+		},
+	}
+	UpdateBehaviorMetrics(metric, nil)
+	return metric.Details.Behaviors.Score
+}
+
+func notebookConfirmScore(population float64, cases ...float64) float64 {
+	metric := &schema.Metric{
+		Details: schema.Details{
+			Confirm: schema.ConfirmDetail{
+				ContinuousData: notebookDataset(cases...),
+				Population:     population,
+			},
+		},
+	}
+	CalculateConfirmScore(metric)
+	return metric.Details.Confirm.Score
+}
+
+func TestNotebookSymptomScore(t *testing.T) {
+	assert.InDelta(t, 70.0, notebookSymptomScore(), notebookDelta)
+}
+
+func TestNotebookBehaviorScore(t *testing.T) {
+	assert.InDelta(t, 23.076923076923077, notebookBehaviorScore(), notebookDelta)
+}
+
+func TestNotebookConfirmScoreExamples(t *testing.T) {
+	assert.InDelta(t, 20.210651903685417, notebookConfirmScore(0, 3, 2, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 10), notebookDelta)
+	assert.InDelta(t, 52.87554499517347, notebookConfirmScore(0, 20, 2, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 2, 1), notebookDelta)
+}
+
+func TestNotebookConfirmScore14Days(t *testing.T) {
+	cases := []float64{3, 2, 2, 1, 0, 1, 30, 4, 0, 0, 3, 3, 4, 3}
+	assert.InDelta(t, 23.324957885818954, notebookConfirmScore(0, cases...), notebookDelta)
+	assert.InDelta(t, 98.62441353590815, notebookConfirmScore(23568378, cases...), notebookDelta)
+	assert.InDelta(t, 2.9522446218392173, notebookConfirmScore(10000, cases...), notebookDelta)
+}
+
+func TestNotebookOverallScore(t *testing.T) {
+	cases := []float64{3, 2, 2, 1, 0, 1, 30, 4, 0, 0, 3, 3, 4, 3}
+	assert.InDelta(t, 34.93170971214025,
+		DefaultTotalScore(notebookSymptomScore(), notebookBehaviorScore(), notebookConfirmScore(0, cases...)), notebookDelta)
+	assert.InDelta(t, 72.58143753718484,
+		DefaultTotalScore(notebookSymptomScore(), notebookBehaviorScore(), notebookConfirmScore(23568378, cases...)), notebookDelta)
+}
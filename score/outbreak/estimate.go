@@ -0,0 +1,193 @@
+// Package outbreak estimates how fast confirmed cases of an area grow from the daily new
+// cases of the last days.
+//
+// The growth rate is fitted by a log-linear Poisson regression of daily cases, whose
+// confidence interval is widened by over-dispersion of the data. The effective reproduction
+// number is derived from the growth rate with a gamma distributed serial interval
+// (Wallinga & Lipsitch, 2007).
+package outbreak
+
+import (
+	"errors"
+	"math"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const (
+	// MinDays is the minimal number of days to estimate the growth
+	MinDays = 7
+
+	// MinCases is the minimal number of total cases to estimate the growth
+	MinCases = 10
+
+	// SerialIntervalMean and SerialIntervalSD are the mean and the standard deviation
+	// in days of the serial interval of COVID-19 (Nishiura et al., 2020)
+	SerialIntervalMean = 4.7
+	SerialIntervalSD   = 2.9
+
+	// z-value of the 95% confidence intervals
+	confidenceZ = 1.959963984540054
+
+	maxIterations = 100
+	tolerance     = 1e-10
+)
+
+var (
+	ErrInsufficientData = errors.New("insufficient data to estimate the growth")
+	ErrNotConverged     = errors.New("growth estimation not converged")
+)
+
+// Estimate estimates the growth of daily new cases in the data set, which is ordered from
+// the oldest day to the latest one. Negative cases, i.e. corrections of earlier reports,
+// are counted as zero.
+func Estimate(dataset []schema.CDSScoreDataSet) (*schema.ConfirmGrowth, error) {
+	cases := make([]float64, len(dataset))
+	total := float64(0)
+	daysWithCases := 0
+	for i, d := range dataset {
+		cases[i] = math.Max(d.Cases, 0)
+		total += cases[i]
+		if cases[i] > 0 {
+			daysWithCases++
+		}
+	}
+
+	// the growth is unbounded if there are cases on only one day
+	if len(cases) < MinDays || total < MinCases || daysWithCases < 2 {
+		return nil, ErrInsufficientData
+	}
+
+	rate, se, err := fitGrowthRate(cases)
+	if err != nil {
+		return nil, err
+	}
+
+	growthRate := schema.EstimateInterval{
+		Estimate: rate,
+		Lower:    rate - confidenceZ*se,
+		Upper:    rate + confidenceZ*se,
+	}
+
+	growth := &schema.ConfirmGrowth{
+		Trend:      schema.GrowthTrendStable,
+		GrowthRate: growthRate,
+		ReproductionNumber: schema.EstimateInterval{
+			Estimate: ReproductionNumber(growthRate.Estimate),
+			Lower:    ReproductionNumber(growthRate.Lower),
+			Upper:    ReproductionNumber(growthRate.Upper),
+		},
+	}
+
+	if growthRate.Lower > 0 {
+		growth.Trend = schema.GrowthTrendGrowing
+		growth.DoublingTime = &schema.EstimateInterval{
+			Estimate: math.Ln2 / growthRate.Estimate,
+			Lower:    math.Ln2 / growthRate.Upper,
+			Upper:    math.Ln2 / growthRate.Lower,
+		}
+	} else if growthRate.Upper < 0 {
+		growth.Trend = schema.GrowthTrendDeclining
+		growth.HalvingTime = &schema.EstimateInterval{
+			Estimate: math.Ln2 / -growthRate.Estimate,
+			Lower:    math.Ln2 / -growthRate.Lower,
+			Upper:    math.Ln2 / -growthRate.Upper,
+		}
+	}
+
+	return growth, nil
+}
+
+// ReproductionNumber converts a daily growth rate into the effective reproduction number
+// by the moment generating function of a gamma distributed serial interval.
+func ReproductionNumber(rate float64) float64 {
+	shape := math.Pow(SerialIntervalMean/SerialIntervalSD, 2)
+	scale := SerialIntervalSD * SerialIntervalSD / SerialIntervalMean
+
+	base := 1 + rate*scale
+	if base <= 0 {
+		return 0
+	}
+	return math.Pow(base, shape)
+}
+
+// fitGrowthRate fits log(E[cases]) = a + r*t by Newton's method and returns r and its
+// standard error. Days are centered so that a and r are nearly uncorrelated.
+func fitGrowthRate(cases []float64) (float64, float64, error) {
+	n := float64(len(cases))
+	mean := float64(0)
+	for _, c := range cases {
+		mean += c
+	}
+	mean /= n
+
+	t := make([]float64, len(cases))
+	for i := range cases {
+		t[i] = float64(i) - (n-1)/2
+	}
+
+	logLikelihood := func(a, r float64) float64 {
+		l := float64(0)
+		for i, c := range cases {
+			eta := a + r*t[i]
+			l += c*eta - math.Exp(eta)
+		}
+		return l
+	}
+
+	a, r := math.Log(mean), float64(0)
+	var iaa, iar, irr float64
+	converged := false
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		var ua, ur float64
+		iaa, iar, irr = 0, 0, 0
+		for i, c := range cases {
+			mu := math.Exp(a + r*t[i])
+			ua += c - mu
+			ur += (c - mu) * t[i]
+			iaa += mu
+			iar += mu * t[i]
+			irr += mu * t[i] * t[i]
+		}
+
+		det := iaa*irr - iar*iar
+		if det <= 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+			return 0, 0, ErrNotConverged
+		}
+		da := (irr*ua - iar*ur) / det
+		dr := (iaa*ur - iar*ua) / det
+
+		// halve the step until the likelihood is not decreased
+		current := logLikelihood(a, r)
+		step := 1.0
+		for ; step > 1e-6; step /= 2 {
+			if logLikelihood(a+step*da, r+step*dr) >= current {
+				break
+			}
+		}
+		a += step * da
+		r += step * dr
+
+		if math.Abs(step*da) < tolerance && math.Abs(step*dr) < tolerance {
+			converged = true
+			break
+		}
+	}
+
+	if !converged || math.IsNaN(r) || math.IsInf(r, 0) {
+		return 0, 0, ErrNotConverged
+	}
+
+	// scale the variance by the over-dispersion of the Pearson residuals
+	dispersion := float64(0)
+	for i, c := range cases {
+		mu := math.Exp(a + r*t[i])
+		dispersion += (c - mu) * (c - mu) / mu
+	}
+	dispersion = math.Max(dispersion/(n-2), 1)
+
+	det := iaa*irr - iar*iar
+	se := math.Sqrt(dispersion * iaa / det)
+
+	return r, se, nil
+}
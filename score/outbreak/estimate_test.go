@@ -0,0 +1,107 @@
+package outbreak
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+func dataset(cases ...float64) []schema.CDSScoreDataSet {
+	d := make([]schema.CDSScoreDataSet, len(cases))
+	for i, c := range cases {
+		d[i] = schema.CDSScoreDataSet{Name: "Taiwan", Cases: c}
+	}
+	return d
+}
+
+func exponentialDataset(start, rate float64, days int) []schema.CDSScoreDataSet {
+	cases := make([]float64, days)
+	for i := range cases {
+		cases[i] = math.Round(start * math.Exp(rate*float64(i)))
+	}
+	return dataset(cases...)
+}
+
+func TestReproductionNumber(t *testing.T) {
+	assert.Equal(t, 1.0, ReproductionNumber(0))
+	assert.True(t, ReproductionNumber(0.1) > 1)
+	assert.True(t, ReproductionNumber(-0.1) < 1)
+	assert.Equal(t, 0.0, ReproductionNumber(-1))
+}
+
+func TestEstimateGrowing(t *testing.T) {
+	growth, err := Estimate(exponentialDataset(10, 0.1, 14))
+	assert.NoError(t, err)
+	assert.Equal(t, schema.GrowthTrendGrowing, growth.Trend)
+	assert.InDelta(t, 0.1, growth.GrowthRate.Estimate, 0.005)
+	assert.True(t, growth.GrowthRate.Lower < growth.GrowthRate.Estimate)
+	assert.True(t, growth.GrowthRate.Upper > growth.GrowthRate.Estimate)
+
+	assert.True(t, growth.ReproductionNumber.Lower > 1)
+	assert.True(t, growth.ReproductionNumber.Lower < growth.ReproductionNumber.Estimate)
+	assert.True(t, growth.ReproductionNumber.Upper > growth.ReproductionNumber.Estimate)
+
+	assert.NotNil(t, growth.DoublingTime)
+	assert.InDelta(t, math.Ln2/0.1, growth.DoublingTime.Estimate, 0.5)
+	assert.True(t, growth.DoublingTime.Lower < growth.DoublingTime.Estimate)
+	assert.True(t, growth.DoublingTime.Upper > growth.DoublingTime.Estimate)
+	assert.Nil(t, growth.HalvingTime)
+}
+
+func TestEstimateDeclining(t *testing.T) {
+	growth, err := Estimate(exponentialDataset(200, -0.15, 14))
+	assert.NoError(t, err)
+	assert.Equal(t, schema.GrowthTrendDeclining, growth.Trend)
+	assert.InDelta(t, -0.15, growth.GrowthRate.Estimate, 0.01)
+	assert.True(t, growth.ReproductionNumber.Upper < 1)
+
+	assert.Nil(t, growth.DoublingTime)
+	assert.NotNil(t, growth.HalvingTime)
+	assert.InDelta(t, math.Ln2/0.15, growth.HalvingTime.Estimate, 0.5)
+	assert.True(t, growth.HalvingTime.Lower < growth.HalvingTime.Estimate)
+	assert.True(t, growth.HalvingTime.Upper > growth.HalvingTime.Estimate)
+}
+
+func TestEstimateStable(t *testing.T) {
+	growth, err := Estimate(dataset(5, 8, 3, 6, 4, 7, 5, 6, 3, 8, 5, 4, 6, 5))
+	assert.NoError(t, err)
+	assert.Equal(t, schema.GrowthTrendStable, growth.Trend)
+	assert.True(t, growth.GrowthRate.Lower < 0)
+	assert.True(t, growth.GrowthRate.Upper > 0)
+	assert.True(t, growth.ReproductionNumber.Lower < 1)
+	assert.True(t, growth.ReproductionNumber.Upper > 1)
+	assert.Nil(t, growth.DoublingTime)
+	assert.Nil(t, growth.HalvingTime)
+}
+
+func TestEstimateOverDispersedData(t *testing.T) {
+	smooth, err := Estimate(dataset(10, 11, 12, 13, 15, 16, 18, 20, 22, 24, 27, 30, 33, 36))
+	assert.NoError(t, err)
+
+	// the same trend with more noise has a wider interval
+	noisy, err := Estimate(dataset(2, 20, 5, 22, 8, 25, 10, 30, 12, 35, 15, 40, 20, 45))
+	assert.NoError(t, err)
+
+	assert.True(t, noisy.GrowthRate.Upper-noisy.GrowthRate.Lower > smooth.GrowthRate.Upper-smooth.GrowthRate.Lower)
+}
+
+func TestEstimateNegativeCases(t *testing.T) {
+	growth, err := Estimate(dataset(10, 11, 12, -5, 15, 16, 18, 20, 22, 24, 27, 30, 33, 36))
+	assert.NoError(t, err)
+	assert.Equal(t, schema.GrowthTrendGrowing, growth.Trend)
+}
+
+func TestEstimateInsufficientData(t *testing.T) {
+	for _, d := range [][]schema.CDSScoreDataSet{
+		nil,
+		dataset(10, 20, 30, 40, 50, 60),       // too few days
+		dataset(0, 1, 0, 2, 1, 0, 1, 2, 0, 1), // too few cases
+		dataset(0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 100), // cases on only one day
+	} {
+		_, err := Estimate(d)
+		assert.Equal(t, ErrInsufficientData, err)
+	}
+}
@@ -0,0 +1,293 @@
+package score
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// RatingPrior is the prior belief of ratings of a resource, which works as `Strength`
+// ratings of `Mean` given to every resource before any account rates it
+type RatingPrior struct {
+	Mean     float64 `mapstructure:"mean"`
+	Strength float64 `mapstructure:"strength"`
+}
+
+// PlaceTypeRatingPrior is the prior of resources of a place type, with priors of specific
+// resources of the place type
+type PlaceTypeRatingPrior struct {
+	RatingPrior `mapstructure:",squash"`
+	Resources   map[string]RatingPrior `mapstructure:"resources"`
+}
+
+// RatingConfig decides how ratings of POI resources are averaged. The weight of a rating
+// halves every half-life, and the weighted ratings are averaged with the prior of the most
+// specific one of: the resource of the place type, the resource, the place type, and the
+// default prior.
+type RatingConfig struct {
+	HalfLife   time.Duration                   `mapstructure:"half_life"`
+	Prior      RatingPrior                     `mapstructure:"prior"`
+	Resources  map[string]RatingPrior          `mapstructure:"resources"`
+	PlaceTypes map[string]PlaceTypeRatingPrior `mapstructure:"place_types"`
+}
+
+// DefaultRatingConfig halves weights of ratings every 90 days, and adds 5 ratings of 3 to
+// every resource, so that a few ratings can not outrank lots of consistent ones
+var DefaultRatingConfig = RatingConfig{
+	HalfLife: 90 * 24 * time.Hour,
+	Prior: RatingPrior{
+		Mean:     3,
+		Strength: 5,
+	},
+}
+
+var (
+	ratingLock   sync.RWMutex
+	ratingConfig = DefaultRatingConfig
+)
+
+// completeRatingPrior fills fields which are not configured by the ones of `base`
+func completeRatingPrior(p, base RatingPrior) RatingPrior {
+	if p.Mean == 0 {
+		p.Mean = base.Mean
+	}
+	if p.Strength == 0 {
+		p.Strength = base.Strength
+	}
+	return p
+}
+
+func validateRatingPrior(p RatingPrior) error {
+	switch {
+	case p.Mean < 1 || p.Mean > 5:
+		return fmt.Errorf("invalid rating prior mean: %f", p.Mean)
+	case p.Strength <= 0:
+		return fmt.Errorf("invalid rating prior strength: %f", p.Strength)
+	}
+	return nil
+}
+
+// SetRatingConfig sets the config of rating averaging. Keys of resources and place types
+// are case-insensitive, and fields which are not configured fall back to the default config.
+func SetRatingConfig(c RatingConfig) error {
+	if c.HalfLife == 0 {
+		c.HalfLife = DefaultRatingConfig.HalfLife
+	}
+	if c.HalfLife < 0 {
+		return fmt.Errorf("invalid rating half-life: %s", c.HalfLife)
+	}
+
+	c.Prior = completeRatingPrior(c.Prior, DefaultRatingConfig.Prior)
+	if err := validateRatingPrior(c.Prior); err != nil {
+		return err
+	}
+
+	resources := map[string]RatingPrior{}
+	for id, p := range c.Resources {
+		if err := validateRatingPrior(completeRatingPrior(p, c.Prior)); err != nil {
+			return fmt.Errorf("%s: %s", id, err)
+		}
+		resources[strings.ToLower(id)] = p
+	}
+
+	placeTypes := map[string]PlaceTypeRatingPrior{}
+	for placeType, pp := range c.PlaceTypes {
+		if err := validateRatingPrior(completeRatingPrior(pp.RatingPrior, c.Prior)); err != nil {
+			return fmt.Errorf("%s: %s", placeType, err)
+		}
+		placeResources := map[string]RatingPrior{}
+		for id, p := range pp.Resources {
+			if err := validateRatingPrior(completeRatingPrior(p, c.Prior)); err != nil {
+				return fmt.Errorf("%s/%s: %s", placeType, id, err)
+			}
+			placeResources[strings.ToLower(id)] = p
+		}
+		pp.Resources = placeResources
+		placeTypes[strings.ToLower(placeType)] = pp
+	}
+	c.Resources = resources
+	c.PlaceTypes = placeTypes
+
+	ratingLock.Lock()
+	defer ratingLock.Unlock()
+	ratingConfig = c
+	return nil
+}
+
+// CurrentRatingConfig returns the config of rating averaging
+func CurrentRatingConfig() RatingConfig {
+	ratingLock.RLock()
+	defer ratingLock.RUnlock()
+	return ratingConfig
+}
+
+// RatingPriorOf returns the prior of a resource of a place type
+func RatingPriorOf(placeType, resourceID string) RatingPrior {
+	c := CurrentRatingConfig()
+	placeType = strings.ToLower(placeType)
+	resourceID = strings.ToLower(resourceID)
+
+	prior := c.Prior
+	pp, ok := c.PlaceTypes[placeType]
+	if ok {
+		prior = completeRatingPrior(pp.RatingPrior, prior)
+	}
+	if p, ok := c.Resources[resourceID]; ok {
+		prior = completeRatingPrior(p, prior)
+	}
+	if p, ok := pp.Resources[resourceID]; ok {
+		prior = completeRatingPrior(p, prior)
+	}
+	return prior
+}
+
+// WeightedRatingsOf returns the weighted ratings of a resource. Ratings aggregated before
+// they were weighted count as they were all given at the last update.
+func WeightedRatingsOf(r schema.POIResourceRating) schema.WeightedRatings {
+	if r.Weighted.At == 0 && r.Ratings > 0 {
+		return schema.WeightedRatings{
+			Sum:   r.Score * float64(r.Ratings),
+			Count: float64(r.Ratings),
+			At:    r.LastUpdate,
+		}
+	}
+	return r.Weighted
+}
+
+// DecayRatings weights ratings at a later time. Ratings can not be weighted at a time
+// before they were weighted, and ratings of an unknown time are not decayed.
+func DecayRatings(w schema.WeightedRatings, at time.Time) schema.WeightedRatings {
+	age := at.Sub(time.Unix(w.At, 0))
+	if age <= 0 || w.At == 0 || w.Count == 0 {
+		return w
+	}
+
+	decay := math.Exp2(-float64(age) / float64(CurrentRatingConfig().HalfLife))
+	return schema.WeightedRatings{
+		Sum:   w.Sum * decay,
+		Count: w.Count * decay,
+		At:    at.Unix(),
+	}
+}
+
+// beginRatingUpdate weights ratings of a resource at the time of an update. The ratings
+// before the update are kept in the history unless they are updated at the same time, and
+// the history drops the ones no longer needed to tell ratings known a day before the update.
+func beginRatingUpdate(r schema.POIResourceRating, at time.Time) schema.POIResourceRating {
+	w := WeightedRatingsOf(r)
+	history := append([]schema.WeightedRatings{}, r.History...)
+	if (w.At != 0 || w.Count > 0) && w.At < at.Unix() {
+		history = append(history, w)
+	}
+
+	// keep the latest ratings until a day ago and the ones after them
+	since := 0
+	for i, h := range history {
+		if !time.Unix(h.At, 0).After(at.Add(-ComparisonPeriod)) {
+			since = i
+		}
+	}
+	r.History = history[since:]
+
+	r.Weighted = DecayRatings(w, at)
+	r.Weighted.At = at.Unix()
+	return r
+}
+
+// AddRating adds a rating to the weighted ratings of a resource at a time
+func AddRating(r schema.POIResourceRating, score float64, at time.Time) schema.POIResourceRating {
+	r = beginRatingUpdate(r, at)
+	r.Weighted.Sum += score
+	r.Weighted.Count++
+	return r
+}
+
+// WithdrawRating withdraws a rating, which was given at `ratedAt`, from the weighted ratings
+// of a resource at a time
+func WithdrawRating(r schema.POIResourceRating, score float64, ratedAt int64, at time.Time) schema.POIResourceRating {
+	r = beginRatingUpdate(r, at)
+
+	weight := float64(1)
+	if age := at.Sub(time.Unix(ratedAt, 0)); age > 0 {
+		weight = math.Exp2(-float64(age) / float64(CurrentRatingConfig().HalfLife))
+	}
+
+	r.Weighted.Sum = math.Max(r.Weighted.Sum-weight*score, 0)
+	r.Weighted.Count = math.Max(r.Weighted.Count-weight, 0)
+	if r.Weighted.Count == 0 || r.Weighted.Sum == 0 {
+		r.Weighted.Sum, r.Weighted.Count = 0, 0
+	}
+	return r
+}
+
+// ratingsAt returns weighted ratings of a resource known at a time
+func ratingsAt(r schema.POIResourceRating, at time.Time) schema.WeightedRatings {
+	w := WeightedRatingsOf(r)
+	if !time.Unix(w.At, 0).After(at) {
+		return DecayRatings(w, at)
+	}
+
+	for i := len(r.History) - 1; i >= 0; i-- {
+		if h := r.History[i]; !time.Unix(h.At, 0).After(at) {
+			return DecayRatings(h, at)
+		}
+	}
+	return schema.WeightedRatings{}
+}
+
+// smoothRatings averages weighted ratings with a prior. The confidence is the share of the
+// ratings in the average, which is 0 without any rating and approaches 1 as ratings grow.
+func smoothRatings(w schema.WeightedRatings, prior RatingPrior) (float64, float64) {
+	if w.Count <= 0 || w.Sum <= 0 {
+		return prior.Mean, 0
+	}
+	return (prior.Strength*prior.Mean + w.Sum) / (prior.Strength + w.Count), w.Count / (prior.Strength + w.Count)
+}
+
+// SmoothRating returns the Bayesian average of ratings of a resource of a place type at a
+// time, and the confidence of the average
+func SmoothRating(placeType string, r schema.POIResourceRating, at time.Time) (float64, float64) {
+	return smoothRatings(ratingsAt(r, at), RatingPriorOf(placeType, r.Resource.ID))
+}
+
+// SmoothResourceRatings fills smoothed scores and confidences of resources of a place type
+func SmoothResourceRatings(placeType string, resources []schema.POIResourceRating, at time.Time) {
+	for i, r := range resources {
+		resources[i].SmoothedScore, resources[i].Confidence = SmoothRating(placeType, r, at)
+	}
+}
+
+// resourceRatingsAt averages smoothed ratings of rated resources weighted by their weighted
+// numbers of ratings. It returns the average, the confidence and the weighted number of
+// ratings.
+func resourceRatingsAt(placeType string, resources []schema.POIResourceRating, at time.Time) (float64, float64, float64) {
+	sumOfScore := float64(0)
+	sumOfConfidence := float64(0)
+	count := float64(0)
+	for _, r := range resources {
+		w := ratingsAt(r, at)
+		if w.Count <= 0 || w.Sum <= 0 { // not rated
+			continue
+		}
+
+		score, confidence := smoothRatings(w, RatingPriorOf(placeType, r.Resource.ID))
+		sumOfScore += w.Count * score
+		sumOfConfidence += w.Count * confidence
+		count += w.Count
+	}
+
+	if count == 0 {
+		return 0, 0, 0
+	}
+	return sumOfScore / count, sumOfConfidence / count, count
+}
+
+// ResourceConfidence returns the confidence of the resource score of a place type at a time
+func ResourceConfidence(placeType string, resources []schema.POIResourceRating, at time.Time) float64 {
+	_, confidence, _ := resourceRatingsAt(placeType, resources, at)
+	return confidence
+}
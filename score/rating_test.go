@@ -0,0 +1,167 @@
+package score
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+var ratingTestNow = time.Date(2020, 6, 2, 12, 0, 0, 0, time.UTC)
+
+func TestSmoothRating(t *testing.T) {
+	single := schema.POIResourceRating{
+		Resource: schema.Resource{ID: "resource_1"},
+		Weighted: schema.WeightedRatings{Sum: 5, Count: 1, At: ratingTestNow.Unix()},
+	}
+	popular := schema.POIResourceRating{
+		Resource: schema.Resource{ID: "resource_1"},
+		Weighted: schema.WeightedRatings{Sum: 960, Count: 200, At: ratingTestNow.Unix()},
+	}
+
+	// (5 * 3 + 5) / 6 and (5 * 3 + 960) / 205
+	score, confidence := SmoothRating("", single, ratingTestNow)
+	assert.InDelta(t, 3.333333, score, 1e-6)
+	assert.InDelta(t, 1.0/6, confidence, 1e-9)
+
+	score, confidence = SmoothRating("", popular, ratingTestNow)
+	assert.InDelta(t, 4.756098, score, 1e-6)
+	assert.InDelta(t, 200.0/205, confidence, 1e-9)
+
+	// an unrated resource is the prior without any confidence
+	score, confidence = SmoothRating("", schema.POIResourceRating{}, ratingTestNow)
+	assert.Equal(t, 3.0, score)
+	assert.Equal(t, 0.0, confidence)
+}
+
+func TestSmoothRatingDecay(t *testing.T) {
+	r := schema.POIResourceRating{
+		Resource: schema.Resource{ID: "resource_1"},
+		Weighted: schema.WeightedRatings{Sum: 50, Count: 10, At: ratingTestNow.Unix()},
+	}
+
+	// ratings of 5 weigh half after the half-life, and the average moves to the prior
+	score, confidence := SmoothRating("", r, ratingTestNow.Add(DefaultRatingConfig.HalfLife))
+	assert.InDelta(t, 4.0, score, 1e-9)
+	assert.InDelta(t, 0.5, confidence, 1e-9)
+}
+
+func TestWeightedRatingsOfLegacyRatings(t *testing.T) {
+	r := schema.POIResourceRating{
+		SumOfScore: 9,
+		Score:      4.5,
+		Ratings:    2,
+		LastUpdate: ratingTestNow.Unix(),
+	}
+	assert.Equal(t, schema.WeightedRatings{Sum: 9, Count: 2, At: ratingTestNow.Unix()}, WeightedRatingsOf(r))
+
+	// ratings of an unknown time are not decayed
+	r.LastUpdate = 0
+	assert.Equal(t, schema.WeightedRatings{Sum: 9, Count: 2}, DecayRatings(WeightedRatingsOf(r), ratingTestNow))
+}
+
+func TestAddAndWithdrawRating(t *testing.T) {
+	r := schema.POIResourceRating{Resource: schema.Resource{ID: "resource_1"}}
+
+	r = AddRating(r, 5, ratingTestNow)
+	r = AddRating(r, 3, ratingTestNow)
+	assert.Equal(t, schema.WeightedRatings{Sum: 8, Count: 2, At: ratingTestNow.Unix()}, r.Weighted)
+	assert.Empty(t, r.History)
+
+	// the ratings a half-life ago weigh half, and the ones before the update are kept
+	later := ratingTestNow.Add(DefaultRatingConfig.HalfLife)
+	r = AddRating(r, 4, later)
+	assert.InDelta(t, 8, r.Weighted.Sum, 1e-9)
+	assert.InDelta(t, 2, r.Weighted.Count, 1e-9)
+	assert.Equal(t, later.Unix(), r.Weighted.At)
+	assert.Equal(t, []schema.WeightedRatings{{Sum: 8, Count: 2, At: ratingTestNow.Unix()}}, r.History)
+
+	// a rating of 5 given a half-life ago is withdrawn by its weight then
+	r = WithdrawRating(r, 5, ratingTestNow.Unix(), later)
+	assert.InDelta(t, 5.5, r.Weighted.Sum, 1e-9)
+	assert.InDelta(t, 1.5, r.Weighted.Count, 1e-9)
+
+	r = WithdrawRating(r, 4, later.Unix(), later)
+	r = WithdrawRating(r, 3, ratingTestNow.Unix(), later)
+	assert.Equal(t, schema.WeightedRatings{At: later.Unix()}, r.Weighted)
+}
+
+func TestResourceScoreYesterday(t *testing.T) {
+	r := schema.POIResourceRating{Resource: schema.Resource{ID: "resource_1"}}
+	r = AddRating(r, 5, ratingTestNow.Add(-30*time.Hour))
+	r = AddRating(r, 1, ratingTestNow.Add(-time.Hour))
+	r = AddRating(r, 1, ratingTestNow)
+
+	w := func(age time.Duration) float64 {
+		return math.Exp2(-float64(age) / float64(DefaultRatingConfig.HalfLife))
+	}
+
+	// ratings of the last day are not known a day ago
+	today, yesterday := ResourceAutonomyScore("", []schema.POIResourceRating{r}, ratingTestNow)
+	assert.InDelta(t, 20*(15+5*w(30*time.Hour)+w(time.Hour)+1)/(5+w(30*time.Hour)+w(time.Hour)+1), today, 1e-9)
+	assert.InDelta(t, 20*(15+5*w(6*time.Hour))/(5+w(6*time.Hour)), yesterday, 1e-9)
+}
+
+func TestResourceScoreYesterdayAfterUpdatesOfLastDay(t *testing.T) {
+	r := schema.POIResourceRating{Resource: schema.Resource{ID: "resource_1"}}
+	r = AddRating(r, 3, ratingTestNow.Add(-50*time.Hour))
+	r = AddRating(r, 5, ratingTestNow.Add(-30*time.Hour))
+	r = AddRating(r, 1, ratingTestNow.Add(-20*time.Hour))
+	r = AddRating(r, 1, ratingTestNow.Add(-time.Hour))
+
+	w := func(age time.Duration) float64 {
+		return math.Exp2(-float64(age) / float64(DefaultRatingConfig.HalfLife))
+	}
+
+	// ratings known a day ago are the ones of the update 30 hours ago
+	_, yesterday := ResourceAutonomyScore("", []schema.POIResourceRating{r}, ratingTestNow)
+	assert.InDelta(t, 20*(15+3*w(26*time.Hour)+5*w(6*time.Hour))/(5+w(26*time.Hour)+w(6*time.Hour)), yesterday, 1e-9)
+
+	// the ratings before the update 30 hours ago are dropped since they are no longer needed
+	assert.Len(t, r.History, 2)
+	assert.Equal(t, ratingTestNow.Add(-30*time.Hour).Unix(), r.History[0].At)
+}
+
+func TestRatingPriorOf(t *testing.T) {
+	defer SetRatingConfig(DefaultRatingConfig)
+
+	err := SetRatingConfig(RatingConfig{
+		Prior: RatingPrior{Mean: 3.5},
+		Resources: map[string]RatingPrior{
+			"Resource_1": {Mean: 4},
+		},
+		PlaceTypes: map[string]PlaceTypeRatingPrior{
+			"hospital": {
+				RatingPrior: RatingPrior{Strength: 10},
+				Resources: map[string]RatingPrior{
+					"resource_1": {Mean: 4.5},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, RatingPrior{Mean: 3.5, Strength: 5}, RatingPriorOf("restaurant", "resource_2"))
+	assert.Equal(t, RatingPrior{Mean: 4, Strength: 5}, RatingPriorOf("restaurant", "resource_1"))
+	assert.Equal(t, RatingPrior{Mean: 3.5, Strength: 10}, RatingPriorOf("Hospital", "resource_2"))
+	assert.Equal(t, RatingPrior{Mean: 4.5, Strength: 10}, RatingPriorOf("hospital", "resource_1"))
+	assert.Equal(t, DefaultRatingConfig.HalfLife, CurrentRatingConfig().HalfLife)
+}
+
+func TestSetRatingConfigInvalid(t *testing.T) {
+	defer SetRatingConfig(DefaultRatingConfig)
+
+	assert.EqualError(t, SetRatingConfig(RatingConfig{HalfLife: -time.Hour}), "invalid rating half-life: -1h0m0s")
+	assert.EqualError(t, SetRatingConfig(RatingConfig{Prior: RatingPrior{Mean: 6}}), "invalid rating prior mean: 6.000000")
+	assert.EqualError(t, SetRatingConfig(RatingConfig{
+		PlaceTypes: map[string]PlaceTypeRatingPrior{
+			"hospital": {Resources: map[string]RatingPrior{"resource_1": {Strength: -1}}},
+		},
+	}), "hospital/resource_1: invalid rating prior strength: -1.000000")
+
+	// the previous config is kept
+	assert.Equal(t, DefaultRatingConfig.Prior, RatingPriorOf("hospital", "resource_1"))
+}
@@ -16,3 +16,16 @@ func ResourceScore(poiRating schema.POIResourceRating, newRating schema.RatingRe
 	average := sum / float64(count)
 	return count, sum, average
 }
+
+// RemoveResourceScore withdraws a rating from a POI resource. It returns the
+// count, sum and average of the remaining ratings.
+func RemoveResourceScore(poiRating schema.POIResourceRating, oldRating schema.RatingResource) (int64, float64, float64) {
+	count := poiRating.Ratings - 1
+	if count <= 0 {
+		return 0, 0, 0
+	}
+
+	sum := poiRating.SumOfScore - oldRating.Score
+	average := sum / float64(count)
+	return count, sum, average
+}
@@ -10,12 +10,10 @@ import (
 
 func TestResourceScoreFirstRating(t *testing.T) {
 	poiResourceARating := schema.POIResourceRating{
-		Resource:       schema.Resource{ID: "resource_2"},
-		SumOfScore:     30,
-		Score:          3.75,
-		Ratings:        8,
-		LastDayRatings: 6,
-		LastDayScore:   3.5,
+		Resource:   schema.Resource{ID: "resource_2"},
+		SumOfScore: 30,
+		Score:      3.75,
+		Ratings:    8,
 	}
 	userResourceARating := schema.RatingResource{
 		Resource: schema.Resource{ID: "resource_2"},
@@ -30,12 +28,10 @@ func TestResourceScoreFirstRating(t *testing.T) {
 
 func TestResourceScoreUpdateRating(t *testing.T) {
 	poiResourceARating := schema.POIResourceRating{
-		Resource:       schema.Resource{ID: "resource_2"},
-		SumOfScore:     30,
-		Score:          3.75,
-		Ratings:        8,
-		LastDayRatings: 6,
-		LastDayScore:   3.5,
+		Resource:   schema.Resource{ID: "resource_2"},
+		SumOfScore: 30,
+		Score:      3.75,
+		Ratings:    8,
 	}
 
 	userResourceARating := schema.RatingResource{
@@ -53,3 +49,41 @@ func TestResourceScoreUpdateRating(t *testing.T) {
 	assert.Equal(t, sum, float64(31))
 	assert.Equal(t, average, float64(31)/float64(8))
 }
+
+func TestRemoveResourceScore(t *testing.T) {
+	poiResourceARating := schema.POIResourceRating{
+		Resource:   schema.Resource{ID: "resource_2"},
+		SumOfScore: 30,
+		Score:      3.75,
+		Ratings:    8,
+	}
+
+	userResourceARating := schema.RatingResource{
+		Resource: schema.Resource{ID: "resource_2"},
+		Score:    2,
+	}
+
+	count, sum, average := RemoveResourceScore(poiResourceARating, userResourceARating)
+	assert.Equal(t, int64(7), count)
+	assert.Equal(t, float64(28), sum)
+	assert.Equal(t, float64(4), average)
+}
+
+func TestRemoveResourceScoreLastRating(t *testing.T) {
+	poiResourceARating := schema.POIResourceRating{
+		Resource:   schema.Resource{ID: "resource_2"},
+		SumOfScore: 4,
+		Score:      4,
+		Ratings:    1,
+	}
+
+	userResourceARating := schema.RatingResource{
+		Resource: schema.Resource{ID: "resource_2"},
+		Score:    4,
+	}
+
+	count, sum, average := RemoveResourceScore(poiResourceARating, userResourceARating)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, float64(0), sum)
+	assert.Equal(t, float64(0), average)
+}
@@ -0,0 +1,268 @@
+package score
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// maxSpikeStrength caps strengths of counts which are impossible under the baseline
+const maxSpikeStrength = 10
+
+// DefaultSpikeConfig flags a symptom reported by at least 3 people today if it is 3 standard
+// deviations above the Poisson baseline of the last 7 days, i.e. p < 0.0014.
+var DefaultSpikeConfig = schema.SpikeConfig{
+	Method:       schema.SpikeMethodPoisson,
+	BaselineDays: 7,
+	MinCount:     3,
+	Threshold:    3,
+	Lambda:       0.3,
+}
+
+var (
+	spikeLock    sync.RWMutex
+	spikeConfig  = DefaultSpikeConfig
+	spikeConfigs = map[string]schema.SpikeConfig{}
+)
+
+// spikeArea is the key of an area in spike configs, e.g. `United States/New York/Kings County`
+func spikeArea(parts ...string) string {
+	return strings.ToLower(strings.Join(parts, "/"))
+}
+
+// completeSpikeConfig fills fields which are not configured by the ones of `base`
+func completeSpikeConfig(c, base schema.SpikeConfig) schema.SpikeConfig {
+	if c.Method == "" {
+		c.Method = base.Method
+	}
+	if c.BaselineDays == 0 {
+		c.BaselineDays = base.BaselineDays
+	}
+	if c.MinCount == 0 {
+		c.MinCount = base.MinCount
+	}
+	if c.Threshold == 0 {
+		c.Threshold = base.Threshold
+	}
+	if c.Lambda == 0 {
+		c.Lambda = base.Lambda
+	}
+	return c
+}
+
+func validateSpikeConfig(c schema.SpikeConfig) error {
+	switch {
+	case c.Method != schema.SpikeMethodPoisson && c.Method != schema.SpikeMethodEWMA:
+		return fmt.Errorf("unknown spike method: %s", c.Method)
+	case c.BaselineDays < 1:
+		return fmt.Errorf("invalid spike baseline days: %d", c.BaselineDays)
+	case c.MinCount < 1:
+		return fmt.Errorf("invalid spike min count: %d", c.MinCount)
+	case c.Threshold <= 0:
+		return fmt.Errorf("invalid spike threshold: %f", c.Threshold)
+	case c.Lambda <= 0 || c.Lambda > 1:
+		return fmt.Errorf("invalid spike lambda: %f", c.Lambda)
+	}
+	return nil
+}
+
+// SetSpikeConfig sets the default config of spike detection and the ones of areas, which
+// are keyed by `country`, `country/state` or `country/state/county`. Fields which are not
+// configured fall back to the default config.
+func SetSpikeConfig(c schema.SpikeConfig, areas map[string]schema.SpikeConfig) error {
+	c = completeSpikeConfig(c, DefaultSpikeConfig)
+	if err := validateSpikeConfig(c); err != nil {
+		return err
+	}
+
+	configs := map[string]schema.SpikeConfig{}
+	for area, ac := range areas {
+		ac = completeSpikeConfig(ac, c)
+		if err := validateSpikeConfig(ac); err != nil {
+			return fmt.Errorf("%s: %s", area, err)
+		}
+		configs[strings.ToLower(area)] = ac
+	}
+
+	spikeLock.Lock()
+	defer spikeLock.Unlock()
+	spikeConfig = c
+	spikeConfigs = configs
+	return nil
+}
+
+// SpikeConfigOf returns the config of the most specific area of an address
+func SpikeConfigOf(address schema.AddressComponent) schema.SpikeConfig {
+	spikeLock.RLock()
+	defer spikeLock.RUnlock()
+
+	for _, area := range []string{
+		spikeArea(address.Country, address.State, address.County),
+		spikeArea(address.Country, address.State),
+		spikeArea(address.Country),
+	} {
+		if c, ok := spikeConfigs[area]; ok {
+			return c
+		}
+	}
+	return spikeConfig
+}
+
+// DetectSymptomSpikes compares counts of symptoms today with their baseline, which are
+// daily distributions of the days before today. Spikes are sorted from the strongest one.
+func DetectSymptomSpikes(baseline []schema.SymptomDistribution, today schema.SymptomDistribution, config schema.SpikeConfig) []schema.SymptomSpike {
+	config = completeSpikeConfig(config, DefaultSpikeConfig)
+
+	spikes := []schema.SymptomSpike{}
+	if len(baseline) == 0 {
+		return spikes
+	}
+
+	for id, count := range today {
+		if count < config.MinCount {
+			continue
+		}
+
+		counts := make([]float64, len(baseline))
+		for i, d := range baseline {
+			counts[i] = float64(d[id])
+		}
+
+		var expected, strength float64
+		if config.Method == schema.SpikeMethodEWMA {
+			expected, strength = ewmaStrength(counts, float64(count), config.Lambda)
+		} else {
+			expected, strength = countTestStrength(counts, count)
+		}
+
+		if strength >= config.Threshold {
+			spikes = append(spikes, schema.SymptomSpike{
+				ID:       id,
+				Count:    count,
+				Baseline: expected,
+				Strength: strength,
+			})
+		}
+	}
+
+	sort.Slice(spikes, func(i, j int) bool {
+		if spikes[i].Strength != spikes[j].Strength {
+			return spikes[i].Strength > spikes[j].Strength
+		}
+		return spikes[i].ID < spikes[j].ID
+	})
+
+	return spikes
+}
+
+// SpikeStrength returns the strength of the strongest spike, or 0 if there is no spike
+func SpikeStrength(spikes []schema.SymptomSpike) float64 {
+	strength := float64(0)
+	for _, s := range spikes {
+		strength = math.Max(strength, s.Strength)
+	}
+	return strength
+}
+
+// baselineMoments returns the mean and the variance of daily counts. The mean is at least
+// one report in the whole baseline, so that a symptom new to an area is not infinitely
+// significant.
+func baselineMoments(counts []float64) (float64, float64) {
+	n := float64(len(counts))
+	mean := float64(0)
+	for _, c := range counts {
+		mean += c
+	}
+	mean /= n
+
+	variance := float64(0)
+	if n > 1 {
+		for _, c := range counts {
+			variance += (c - mean) * (c - mean)
+		}
+		variance /= n - 1
+	}
+
+	return math.Max(mean, 1/n), variance
+}
+
+// countTestStrength tests the count of today against a Poisson distribution of the baseline
+// mean, or a negative binomial one if the baseline varies more than Poisson. The p-value is
+// converted to standard deviations of a normal distribution.
+func countTestStrength(counts []float64, count int) (float64, float64) {
+	mean, variance := baselineMoments(counts)
+
+	var logPMF func(k int) float64
+	if variance > mean {
+		r := mean * mean / (variance - mean)
+		p := r / (r + mean)
+		lgr, _ := math.Lgamma(r)
+		logPMF = func(k int) float64 {
+			lgkr, _ := math.Lgamma(float64(k) + r)
+			lgk, _ := math.Lgamma(float64(k) + 1)
+			return lgkr - lgr - lgk + r*math.Log(p) + float64(k)*math.Log(1-p)
+		}
+	} else {
+		logPMF = func(k int) float64 {
+			lgk, _ := math.Lgamma(float64(k) + 1)
+			return float64(k)*math.Log(mean) - mean - lgk
+		}
+	}
+
+	return mean, pValueStrength(upperTail(count, mean, logPMF))
+}
+
+// upperTail returns P(X >= k) of a discrete distribution of the mean
+func upperTail(k int, mean float64, logPMF func(int) float64) float64 {
+	if float64(k) <= mean {
+		cdf := float64(0)
+		for i := 0; i < k; i++ {
+			cdf += math.Exp(logPMF(i))
+		}
+		return math.Max(1-cdf, 0)
+	}
+
+	// terms are decreasing above the mean of both distributions
+	tail := float64(0)
+	for i := k; ; i++ {
+		term := math.Exp(logPMF(i))
+		tail += term
+		if term <= tail*1e-12 || i-k > 10000 {
+			break
+		}
+	}
+	return tail
+}
+
+// pValueStrength converts a one-sided p-value to the z-score of a standard normal distribution
+func pValueStrength(p float64) float64 {
+	if p >= 0.5 {
+		return 0
+	}
+	if p <= 0 {
+		return maxSpikeStrength
+	}
+	return math.Min(math.Sqrt2*math.Erfcinv(2*p), maxSpikeStrength)
+}
+
+// ewmaStrength smooths daily counts, from the baseline to today, by an exponentially
+// weighted moving average and returns how far the average of today is above the baseline
+// mean in standard deviations of the average.
+func ewmaStrength(counts []float64, count float64, lambda float64) (float64, float64) {
+	mean, variance := baselineMoments(counts)
+	// counts vary at least as Poisson ones
+	sd := math.Sqrt(math.Max(variance, mean))
+
+	ewma := mean
+	for _, c := range counts {
+		ewma = lambda*c + (1-lambda)*ewma
+	}
+	ewma = lambda*count + (1-lambda)*ewma
+
+	strength := (ewma - mean) / (sd * math.Sqrt(lambda/(2-lambda)))
+	return mean, math.Max(math.Min(strength, maxSpikeStrength), 0)
+}
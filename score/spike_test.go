@@ -0,0 +1,121 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+func flatBaseline(days int, d schema.SymptomDistribution) []schema.SymptomDistribution {
+	baseline := make([]schema.SymptomDistribution, days)
+	for i := range baseline {
+		baseline[i] = d
+	}
+	return baseline
+}
+
+func TestDetectSymptomSpikesMinCount(t *testing.T) {
+	// doubling from 1 to 2 reports is not a spike of a small area
+	baseline := flatBaseline(7, schema.SymptomDistribution{"cough": 1})
+	spikes := DetectSymptomSpikes(baseline, schema.SymptomDistribution{"cough": 2}, DefaultSpikeConfig)
+	assert.Empty(t, spikes)
+
+	// a new symptom reported by one person is not a spike either
+	spikes = DetectSymptomSpikes(baseline, schema.SymptomDistribution{"fever": 1}, DefaultSpikeConfig)
+	assert.Empty(t, spikes)
+}
+
+func TestDetectSymptomSpikesPoisson(t *testing.T) {
+	baseline := flatBaseline(7, schema.SymptomDistribution{"cough": 2, "fever": 10})
+	today := schema.SymptomDistribution{"cough": 12, "fever": 11, "chills": 5}
+
+	spikes := DetectSymptomSpikes(baseline, today, DefaultSpikeConfig)
+	assert.Len(t, spikes, 2)
+
+	// a new symptom of 5 reports is more surprising than 12 reports of 2 per day
+	assert.Equal(t, "chills", spikes[0].ID)
+	assert.Equal(t, 5, spikes[0].Count)
+	assert.InDelta(t, 1.0/7, spikes[0].Baseline, 1e-9)
+	assert.Equal(t, "cough", spikes[1].ID)
+	assert.Equal(t, 12, spikes[1].Count)
+	assert.InDelta(t, 2.0, spikes[1].Baseline, 1e-9)
+	assert.True(t, spikes[0].Strength > spikes[1].Strength)
+	assert.True(t, spikes[1].Strength >= DefaultSpikeConfig.Threshold)
+	assert.Equal(t, spikes[0].Strength, SpikeStrength(spikes))
+
+	// a normal fluctuation is not a spike
+	assert.Empty(t, DetectSymptomSpikes(baseline, schema.SymptomDistribution{"fever": 13}, DefaultSpikeConfig))
+}
+
+func TestDetectSymptomSpikesOverDispersed(t *testing.T) {
+	today := schema.SymptomDistribution{"cough": 12}
+
+	steady := flatBaseline(6, schema.SymptomDistribution{"cough": 4})
+	assert.Len(t, DetectSymptomSpikes(steady, today, DefaultSpikeConfig), 1)
+
+	// the same mean which varies a lot is tested by a negative binomial distribution
+	varying := []schema.SymptomDistribution{
+		{"cough": 0}, {"cough": 10}, {"cough": 1}, {"cough": 8}, {"cough": 0}, {"cough": 5},
+	}
+	assert.Empty(t, DetectSymptomSpikes(varying, today, DefaultSpikeConfig))
+}
+
+func TestDetectSymptomSpikesEWMA(t *testing.T) {
+	config := schema.SpikeConfig{Method: schema.SpikeMethodEWMA}
+	baseline := flatBaseline(7, schema.SymptomDistribution{"cough": 4})
+
+	spikes := DetectSymptomSpikes(baseline, schema.SymptomDistribution{"cough": 16}, config)
+	assert.Len(t, spikes, 1)
+	assert.InDelta(t, 4.0, spikes[0].Baseline, 1e-9)
+	// (0.3 * 16 + 0.7 * 4 - 4) / (2 * sqrt(0.3 / 1.7))
+	assert.InDelta(t, 4.284857, spikes[0].Strength, 1e-6)
+
+	assert.Empty(t, DetectSymptomSpikes(baseline, schema.SymptomDistribution{"cough": 8}, config))
+}
+
+func TestDetectSymptomSpikesNoBaseline(t *testing.T) {
+	assert.Empty(t, DetectSymptomSpikes(nil, schema.SymptomDistribution{"cough": 100}, DefaultSpikeConfig))
+}
+
+func TestSpikeConfigOf(t *testing.T) {
+	defer SetSpikeConfig(DefaultSpikeConfig, nil)
+
+	err := SetSpikeConfig(schema.SpikeConfig{Threshold: 2.5}, map[string]schema.SpikeConfig{
+		"Taiwan":                              {MinCount: 10},
+		"United States/New York":              {Method: schema.SpikeMethodEWMA},
+		"United States/New York/Kings County": {BaselineDays: 14},
+	})
+	assert.NoError(t, err)
+
+	c := SpikeConfigOf(schema.AddressComponent{Country: "Taiwan", State: "Taipei City"})
+	assert.Equal(t, 10, c.MinCount)
+	assert.Equal(t, 2.5, c.Threshold)
+	assert.Equal(t, schema.SpikeMethodPoisson, c.Method)
+
+	c = SpikeConfigOf(schema.AddressComponent{Country: "United States", State: "New York", County: "Kings County"})
+	assert.Equal(t, 14, c.BaselineDays)
+	assert.Equal(t, schema.SpikeMethodPoisson, c.Method)
+
+	c = SpikeConfigOf(schema.AddressComponent{Country: "United States", State: "New York", County: "Queens County"})
+	assert.Equal(t, schema.SpikeMethodEWMA, c.Method)
+	assert.Equal(t, DefaultSpikeConfig.BaselineDays, c.BaselineDays)
+
+	c = SpikeConfigOf(schema.AddressComponent{Country: "Japan"})
+	assert.Equal(t, 2.5, c.Threshold)
+	assert.Equal(t, DefaultSpikeConfig.MinCount, c.MinCount)
+}
+
+func TestSetSpikeConfigInvalid(t *testing.T) {
+	defer SetSpikeConfig(DefaultSpikeConfig, nil)
+
+	assert.EqualError(t, SetSpikeConfig(schema.SpikeConfig{Method: "zscore"}, nil), "unknown spike method: zscore")
+	assert.EqualError(t, SetSpikeConfig(schema.SpikeConfig{Lambda: 1.5}, nil), "invalid spike lambda: 1.500000")
+	assert.EqualError(t, SetSpikeConfig(schema.SpikeConfig{}, map[string]schema.SpikeConfig{
+		"Taiwan": {MinCount: -1},
+	}), "Taiwan: invalid spike min count: -1")
+
+	// the previous config is kept
+	assert.Equal(t, DefaultSpikeConfig, SpikeConfigOf(schema.AddressComponent{Country: "Taiwan"}))
+}
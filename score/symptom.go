@@ -6,70 +6,76 @@ import (
 	"github.com/bitmark-inc/autonomy-api/schema"
 )
 
+// UpdateSymptomMetrics calculates the symptom score by the distribution of symptoms weighted
+// by ages of reports. The score of yesterday and the delta compare it with the one of a day ago.
 func UpdateSymptomMetrics(metric *schema.Metric) {
 	rawData := metric.Details.Symptoms
 
-	totalWeight := float64(0)
-	for _, w := range schema.DefaultSymptomWeights {
-		totalWeight += w
+	score, totalCount := symptomScore(rawData.Distribution, rawData.TotalPeople)
+	scoreYesterday, totalCountYesterday := symptomScore(rawData.DistributionYesterday, rawData.TotalPeopleYesterday)
+
+	// yesterday is the baseline if days before it are not collected
+	baseline := make([]schema.SymptomDistribution, 0, len(rawData.BaselineData))
+	for _, d := range rawData.BaselineData {
+		baseline = append(baseline, d.WeightDistribution)
+	}
+	if len(baseline) == 0 && rawData.YesterdayData.WeightDistribution != nil {
+		baseline = append(baseline, rawData.YesterdayData.WeightDistribution)
 	}
 
-	weightedSum := float64(0)
-	officialCountToday := 0
-	nonOfficialCountToday := 0
-	for symptomID, cnt := range rawData.TodayData.WeightDistribution {
-		weight, ok := schema.DefaultSymptomWeights[symptomID]
-		if ok {
-			officialCountToday += cnt
-		} else {
-			weight = 1
-			nonOfficialCountToday += cnt
-		}
+	spikes := DetectSymptomSpikes(baseline, rawData.TodayData.WeightDistribution, rawData.SpikeConfig)
+	spikeList := make([]string, 0, len(spikes))
+	for _, s := range spikes {
+		spikeList = append(spikeList, s.ID)
+	}
 
-		weightedSum += float64(cnt) * weight
+	metric.SymptomCount = totalCount
+	metric.SymptomDelta = ChangeRate(totalCount, totalCountYesterday)
+	metric.Details.Symptoms = schema.SymptomDetail{
+		Score:                 score,
+		ScoreYesterday:        scoreYesterday,
+		TotalPeople:           rawData.TotalPeople,
+		TotalPeopleYesterday:  rawData.TotalPeopleYesterday,
+		Distribution:          rawData.Distribution,
+		DistributionYesterday: rawData.DistributionYesterday,
+		TodayData:             rawData.TodayData,
+		YesterdayData:         rawData.YesterdayData,
+		BaselineData:          rawData.BaselineData,
+		SpikeConfig:           rawData.SpikeConfig,
+		LastSpikeList:         spikeList,
+		LastSpikes:            spikes,
+		LastSpikeUpdate:       time.Now().UTC(),
 	}
-	totalCountToday := officialCountToday + nonOfficialCountToday
+}
 
-	maxWeightedSumToday := float64(rawData.TotalPeople)*totalWeight + float64(nonOfficialCountToday)
-	score := 100.0
-	if maxWeightedSumToday > 0 {
-		score = 100 * (1 - weightedSum/maxWeightedSumToday)
+// symptomScore returns the score of a weighted distribution of symptoms reported by the
+// weighted number of people, and the weighted count of symptoms
+func symptomScore(distribution map[string]float64, people float64) (float64, float64) {
+	totalWeight := float64(0)
+	for _, w := range schema.DefaultSymptomWeights {
+		totalWeight += w
 	}
 
-	weightedSumYesterday := float64(0)
-	officialCountYesterday := 0
-	nonOfficialCountYesterday := 0
-	for symptomID, cnt := range rawData.YesterdayData.WeightDistribution {
+	weightedSum := float64(0)
+	officialCount := float64(0)
+	nonOfficialCount := float64(0)
+	for symptomID, cnt := range distribution {
 		weight, ok := schema.DefaultSymptomWeights[symptomID]
 		if ok {
-			officialCountYesterday += cnt
+			officialCount += cnt
 		} else {
 			weight = 1
-			nonOfficialCountYesterday += cnt
+			nonOfficialCount += cnt
 		}
 
-		weightedSumYesterday += float64(cnt) * weight
+		weightedSum += cnt * weight
 	}
-	totalCountYesterday := officialCountYesterday + nonOfficialCountYesterday
 
-	maxWeightedSumYesterday := float64(rawData.TotalPeopleYesterday)*totalWeight + float64(nonOfficialCountYesterday)
-	scoreYesterday := 100.0
-	if maxWeightedSumYesterday > 0 {
-		scoreYesterday = 100 * (1 - weightedSumYesterday/maxWeightedSumYesterday)
+	maxWeightedSum := people*totalWeight + nonOfficialCount
+	score := 100.0
+	if maxWeightedSum > 0 {
+		score = 100 * (1 - weightedSum/maxWeightedSum)
 	}
 
-	spikeList := CheckSymptomSpike(rawData.YesterdayData.WeightDistribution, rawData.TodayData.WeightDistribution)
-
-	metric.SymptomCount = float64(totalCountToday)
-	metric.SymptomDelta = ChangeRate(float64(totalCountToday), float64(totalCountYesterday))
-	metric.Details.Symptoms = schema.SymptomDetail{
-		Score:                score,
-		ScoreYesterday:       scoreYesterday,
-		TotalPeople:          float64(rawData.TotalPeople),
-		TotalPeopleYesterday: float64(rawData.TotalPeopleYesterday),
-		TodayData:            metric.Details.Symptoms.TodayData,
-		YesterdayData:        metric.Details.Symptoms.YesterdayData,
-		LastSpikeList:        spikeList,
-		LastSpikeUpdate:      time.Now().UTC(),
-	}
+	return score, officialCount + nonOfficialCount
 }
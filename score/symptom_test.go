@@ -15,18 +15,16 @@ func TestUpdateSymptomMetrics(t *testing.T) {
 			Symptoms: schema.SymptomDetail{
 				TotalPeople:          10,
 				TotalPeopleYesterday: 5,
-				TodayData: schema.NearestSymptomData{
-					WeightDistribution: map[string]int{
-						"cough":       3, // weight 2
-						"fever":       7, // weight 3
-						"new-symptom": 1, // weight 1
-					}},
-				YesterdayData: schema.NearestSymptomData{
-					WeightDistribution: map[string]int{
-						"cough":       1,
-						"fever":       1,
-						"new-symptom": 2,
-					}},
+				Distribution: map[string]float64{
+					"cough":       3, // weight 2
+					"fever":       7, // weight 3
+					"new-symptom": 1, // weight 1
+				},
+				DistributionYesterday: map[string]float64{
+					"cough":       1,
+					"fever":       1,
+					"new-symptom": 2,
+				},
 			},
 		},
 	}
@@ -54,12 +52,11 @@ func TestUpdateSymptomMetricsNoReportYesterday(t *testing.T) {
 			Symptoms: schema.SymptomDetail{
 				TotalPeople:          5,
 				TotalPeopleYesterday: 5,
-				TodayData: schema.NearestSymptomData{
-					WeightDistribution: schema.SymptomDistribution{
-						"fever":         10, // weight 3
-						"new-symptom-1": 1,  // weight 1
-						"new-symptom-2": 1,  // weight 1
-					}},
+				Distribution: map[string]float64{
+					"fever":         10, // weight 3
+					"new-symptom-1": 1,  // weight 1
+					"new-symptom-2": 1,  // weight 1
+				},
 			},
 		},
 	}
@@ -78,12 +75,11 @@ func TestUpdateSymptomMetricsNoReportToday(t *testing.T) {
 			Symptoms: schema.SymptomDetail{
 				TotalPeople:          5,
 				TotalPeopleYesterday: 5,
-				YesterdayData: schema.NearestSymptomData{
-					WeightDistribution: schema.SymptomDistribution{
-						"nasal":         10, // weight 1
-						"new-symptom-1": 1,  // weight 1
-						"new-symptom-2": 1,  // weight 1
-					}},
+				DistributionYesterday: map[string]float64{
+					"nasal":         10, // weight 1
+					"new-symptom-1": 1,  // weight 1
+					"new-symptom-2": 1,  // weight 1
+				},
 			},
 		},
 	}
@@ -0,0 +1,148 @@
+package catalog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+// Catalog is the messages of a locale bundle. Nested keys of the bundle are joined
+// by dots, e.g. `symptoms.cough.name`, which are the message IDs used by the code.
+type Catalog struct {
+	Locale   string
+	Keys     []string
+	Messages map[string]string
+
+	doc yaml.MapSlice
+}
+
+// Parse parses a locale bundle
+func Parse(locale string, data []byte) (*Catalog, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{
+		Locale:   locale,
+		Keys:     make([]string, 0),
+		Messages: map[string]string{},
+		doc:      doc,
+	}
+
+	if err := c.flatten("", doc); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Load reads the bundle `<locale>.yaml` in a directory
+func Load(dir, locale string) (*Catalog, error) {
+	data, err := ioutil.ReadFile(path.Join(dir, locale+".yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(locale, data)
+}
+
+// LoadAll reads bundles of all locales listed in the locale file of a directory.
+// The catalog of the default locale is the first one.
+func LoadAll(dir string) ([]*Catalog, error) {
+	list, err := utils.ReadLocaleList(path.Join(dir, utils.LocaleFile))
+	if err != nil {
+		return nil, err
+	}
+
+	locales := []string{list.Default}
+	for _, l := range list.Locales {
+		if l.Code != list.Default {
+			locales = append(locales, l.Code)
+		}
+	}
+
+	catalogs := make([]*Catalog, 0, len(locales))
+	for _, locale := range locales {
+		c, err := Load(dir, locale)
+		if err != nil {
+			return nil, err
+		}
+		catalogs = append(catalogs, c)
+	}
+
+	return catalogs, nil
+}
+
+func (c *Catalog) flatten(prefix string, doc yaml.MapSlice) error {
+	for _, item := range doc {
+		key := fmt.Sprint(item.Key)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := item.Value.(type) {
+		case yaml.MapSlice:
+			if err := c.flatten(key, v); err != nil {
+				return err
+			}
+		case string:
+			c.Keys = append(c.Keys, key)
+			c.Messages[key] = v
+		default:
+			return fmt.Errorf("message %s of %s is not a string", key, c.Locale)
+		}
+	}
+
+	return nil
+}
+
+// Set sets the message of a key. A new key is appended to its section.
+func (c *Catalog) Set(key, message string) {
+	if _, ok := c.Messages[key]; !ok {
+		c.Keys = append(c.Keys, key)
+	}
+	c.Messages[key] = message
+	c.doc = setMessage(c.doc, strings.Split(key, "."), message)
+}
+
+func setMessage(doc yaml.MapSlice, path []string, message string) yaml.MapSlice {
+	for i, item := range doc {
+		if fmt.Sprint(item.Key) != path[0] {
+			continue
+		}
+
+		if len(path) == 1 {
+			doc[i].Value = message
+		} else {
+			section, _ := item.Value.(yaml.MapSlice)
+			doc[i].Value = setMessage(section, path[1:], message)
+		}
+		return doc
+	}
+
+	if len(path) == 1 {
+		return append(doc, yaml.MapItem{Key: path[0], Value: message})
+	}
+	return append(doc, yaml.MapItem{Key: path[0], Value: setMessage(nil, path[1:], message)})
+}
+
+// Marshal returns the bundle of the catalog in YAML
+func (c *Catalog) Marshal() ([]byte, error) {
+	return yaml.Marshal(c.doc)
+}
+
+// Save writes the bundle `<locale>.yaml` into a directory
+func (c *Catalog) Save(dir string) error {
+	data, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(dir, c.Locale+".yaml"), data, 0644)
+}
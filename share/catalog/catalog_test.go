@@ -0,0 +1,188 @@
+package catalog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+const testSource = `symptoms:
+  cough:
+    name: Cough
+  fever:
+    name: Fever
+resources:
+  resource_1:
+    name: face masks
+  resource_2:
+    name: gloves
+notification:
+  symptom_spike:
+    heading: Spike in symptoms
+    content: "Symptoms near you: {{.Symptoms}}."
+`
+
+const testTarget = `symptoms:
+  cough:
+    name: 咳嗽
+  headache:
+    name: 頭痛
+resources:
+  resource_1:
+    name: 戴口罩
+  resource_2:
+    name: 戴手套
+notification:
+  symptom_spike:
+    heading: 症狀飆升
+    content: "附近的症狀: {{.Symptom}}"
+`
+
+func mustParse(t *testing.T, locale, data string) *Catalog {
+	c, err := Parse(locale, []byte(data))
+	assert.NoError(t, err)
+	return c
+}
+
+func testUsage() Usage {
+	return Usage{
+		IDs: []string{"notification.%s.heading", "symptoms.%s.name", "resources.%s.name"},
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`^notification\.[^.]+\.heading$`),
+			regexp.MustCompile(`^symptoms\.[^.]+\.name$`),
+			regexp.MustCompile(`^resources\.[^.]+\.name$`),
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	c := mustParse(t, "en", testSource)
+
+	assert.Equal(t, []string{
+		"symptoms.cough.name",
+		"symptoms.fever.name",
+		"resources.resource_1.name",
+		"resources.resource_2.name",
+		"notification.symptom_spike.heading",
+		"notification.symptom_spike.content",
+	}, c.Keys)
+	assert.Equal(t, "Symptoms near you: {{.Symptoms}}.", c.Messages["notification.symptom_spike.content"])
+	assert.Equal(t, []string{"symptoms", "resources", "notification"}, Sections(c))
+
+	_, err := Parse("en", []byte("symptoms:\n  cough:\n    name: [a, b]\n"))
+	assert.Error(t, err)
+}
+
+func TestSet(t *testing.T) {
+	c := mustParse(t, "en", testSource)
+
+	c.Set("symptoms.cough.name", "Dry cough")
+	c.Set("symptoms.headache.name", "Headache")
+	c.Set("conditions.covid_19.name", "COVID-19")
+
+	data, err := c.Marshal()
+	assert.NoError(t, err)
+
+	reloaded := mustParse(t, "en", string(data))
+	assert.Equal(t, c.Messages, reloaded.Messages)
+	// new keys are appended to their sections
+	assert.Equal(t, []string{
+		"symptoms.cough.name",
+		"symptoms.fever.name",
+		"symptoms.headache.name",
+		"resources.resource_1.name",
+		"resources.resource_2.name",
+		"notification.symptom_spike.heading",
+		"notification.symptom_spike.content",
+		"conditions.covid_19.name",
+	}, reloaded.Keys)
+}
+
+func TestCheck(t *testing.T) {
+	source := mustParse(t, "en", testSource)
+	target := mustParse(t, "zh_tw", testTarget)
+
+	required := map[string][]string{
+		"symptoms": {"symptoms.cough.name", "symptoms.fever.name", "symptoms.throat.name"},
+	}
+
+	issues := Check([]*Catalog{source, target}, testUsage(), required, 3)
+	assert.Equal(t, []Issue{
+		{Locale: "en", Key: "symptoms.throat.name", Kind: IssueMissing},
+		{Locale: "en", Key: "notification.symptom_spike.content", Kind: IssueUnused, Detail: "not used by the code"},
+		{Locale: "en", Kind: IssueResourceCount, Detail: "DefaultResourceCount is 3 but 2 resources are translated"},
+		{Locale: "zh_tw", Key: "symptoms.fever.name", Kind: IssueMissing},
+		{Locale: "zh_tw", Key: "notification.symptom_spike.content", Kind: IssuePlaceholder, Detail: "expect [.Symptoms], got [.Symptom]"},
+		{Locale: "zh_tw", Key: "symptoms.headache.name", Kind: IssueUnused, Detail: "not in en"},
+	}, issues)
+}
+
+func TestCheckLiteralMessageIDs(t *testing.T) {
+	source := mustParse(t, "en", testSource)
+
+	usage := testUsage()
+	usage.IDs = append(usage.IDs, "notification.behavior_suggestion.heading")
+
+	issues := Check([]*Catalog{source}, usage, nil, 2)
+	assert.Contains(t, issues, Issue{Locale: "en", Key: "notification.behavior_suggestion.heading", Kind: IssueMissing, Detail: "used by the code"})
+}
+
+func TestPlaceholders(t *testing.T) {
+	assert.Equal(t, []string{}, Placeholders("no placeholder"))
+	assert.Equal(t, []string{".Count", ".Symptoms"}, Placeholders("{{ .Symptoms }} and {{.Count}}"))
+}
+
+func TestExportAndImport(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatXLIFF} {
+		source := mustParse(t, "en", testSource)
+		target := mustParse(t, "zh_tw", testTarget)
+
+		var buf bytes.Buffer
+		assert.NoError(t, Export(&buf, format, source, target))
+		exported := buf.String()
+
+		// translators fill in the missing message
+		exported = strings.Replace(exported, "Fever,", "Fever,發燒", 1)
+		exported = strings.Replace(exported, "<source>Fever</source>\n        <target></target>", "<source>Fever</source>\n        <target>發燒</target>", 1)
+
+		imported := mustParse(t, "zh_tw", "")
+		n, err := Import(strings.NewReader(exported), format, source, imported)
+		assert.NoError(t, err, format)
+		assert.Equal(t, len(source.Keys), n, format)
+		assert.Equal(t, "發燒", imported.Messages["symptoms.fever.name"], format)
+		assert.Equal(t, "附近的症狀: {{.Symptom}}", imported.Messages["notification.symptom_spike.content"], format)
+		assert.Equal(t, source.Keys, imported.Keys, format)
+	}
+}
+
+func TestImportUnknownKeys(t *testing.T) {
+	source := mustParse(t, "en", testSource)
+	target := mustParse(t, "zh_tw", testTarget)
+
+	_, err := Import(strings.NewReader("key,source,target\nsymptoms.unknown.name,,未知\n"), FormatCSV, source, target)
+	assert.EqualError(t, err, "unknown key: symptoms.unknown.name")
+
+	_, err = Import(strings.NewReader("id,text\n"), FormatCSV, source, target)
+	assert.Error(t, err)
+
+	_, err = Import(strings.NewReader(""), "po", source, target)
+	assert.EqualError(t, err, "unknown format: po")
+}
+
+// TestRepositoryCatalogs checks the bundles of this repository
+func TestRepositoryCatalogs(t *testing.T) {
+	catalogs, err := LoadAll("../../i18n")
+	assert.NoError(t, err)
+
+	usage, err := ScanUsage("../..", Sections(catalogs[0]))
+	assert.NoError(t, err)
+	assert.Contains(t, usage.IDs, "symptoms.%s.name")
+	assert.Contains(t, usage.IDs, "notification.behavior_suggestion.heading")
+
+	assert.Empty(t, Check(catalogs, usage, RequiredKeys(), store.DefaultResourceCount))
+}
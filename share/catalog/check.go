@@ -0,0 +1,289 @@
+package catalog
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+// kinds of issues
+const (
+	IssueMissing       = "missing"
+	IssueUnused        = "unused"
+	IssuePlaceholder   = "placeholder"
+	IssueResourceCount = "resource_count"
+)
+
+// Issue is a problem found in a catalog
+type Issue struct {
+	Locale string
+	Key    string
+	Kind   string
+	Detail string
+}
+
+func (i Issue) String() string {
+	if i.Detail == "" {
+		return fmt.Sprintf("%s\t%s\t%s", i.Locale, i.Kind, i.Key)
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s", i.Locale, i.Kind, i.Key, i.Detail)
+}
+
+var (
+	messageIDLiteral = regexp.MustCompile(`^[a-z_]+(\.([a-z0-9_]+|%s|%d))+$`)
+	placeholder      = regexp.MustCompile(`{{\s*([^}]*?)\s*}}`)
+	resourceKey      = regexp.MustCompile(`^resources\.resource_\d+\.name$`)
+)
+
+// Usage is the message IDs looked up by the code. An ID built by `fmt.Sprintf`
+// is a pattern which matches any key of its format.
+type Usage struct {
+	IDs      []string
+	Patterns []*regexp.Regexp
+}
+
+// Uses returns whether a key is looked up by the code
+func (u Usage) Uses(key string) bool {
+	for _, p := range u.Patterns {
+		if p.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanUsage collects message IDs from string literals of Go files under a directory.
+// Only literals under the given sections, e.g. `symptoms`, are taken as message IDs.
+func ScanUsage(root string, sections []string) (Usage, error) {
+	isSection := map[string]bool{}
+	for _, s := range sections {
+		isSection[s] = true
+	}
+
+	found := map[string]bool{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			switch info.Name() {
+			case "vendor", "mocks", ".git":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), p, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil || !messageIDLiteral.MatchString(value) {
+				return true
+			}
+
+			if isSection[strings.SplitN(value, ".", 2)[0]] {
+				found[value] = true
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return Usage{}, err
+	}
+
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	usage := Usage{IDs: ids, Patterns: make([]*regexp.Regexp, 0, len(ids))}
+	for _, id := range ids {
+		pattern := regexp.QuoteMeta(id)
+		pattern = strings.ReplaceAll(pattern, "%s", `[^.]+`)
+		pattern = strings.ReplaceAll(pattern, "%d", `[0-9]+`)
+		usage.Patterns = append(usage.Patterns, regexp.MustCompile("^"+pattern+"$"))
+	}
+
+	return usage, nil
+}
+
+// Sections returns the top level sections of a catalog
+func Sections(c *Catalog) []string {
+	sections := make([]string, 0)
+	seen := map[string]bool{}
+	for _, key := range c.Keys {
+		s := strings.SplitN(key, ".", 2)[0]
+		if !seen[s] {
+			sections = append(sections, s)
+			seen[s] = true
+		}
+	}
+	return sections
+}
+
+// RequiredKeys returns keys of items defined by the code. Each of them must be translated
+// in the default locale. Keys are grouped by their sections which are exhaustive, which
+// means a key of the section is unused if it is not required.
+func RequiredKeys() map[string][]string {
+	symptoms := make([]string, 0)
+	for _, list := range [][]schema.Symptom{schema.COVID19Symptoms, schema.GeneralSymptoms} {
+		for _, s := range list {
+			symptoms = append(symptoms, fmt.Sprintf("symptoms.%s.name", s.ID))
+		}
+	}
+
+	behaviors := make([]string, 0)
+	for _, b := range schema.OfficialBehaviors {
+		behaviors = append(behaviors,
+			fmt.Sprintf("behaviors.%s.name", b.ID),
+			fmt.Sprintf("behaviors.%s.desc", b.ID))
+	}
+
+	resources := make([]string, 0, store.DefaultResourceCount)
+	for i := 1; i <= store.DefaultResourceCount; i++ {
+		resources = append(resources, fmt.Sprintf("resources.resource_%d.name", i))
+	}
+
+	return map[string][]string{
+		"symptoms":  symptoms,
+		"behaviors": behaviors,
+		"resources": resources,
+	}
+}
+
+// Check checks catalogs of all locales against the first one, which is the default locale.
+// A key is missing if it is required or used by the code but not in the default locale, or
+// it is not translated in another locale. A key is unused if it is neither used by the code
+// nor required, or it is not in the default locale. Placeholders of a translation must be
+// the same as the default locale, and resources must match `DefaultResourceCount`.
+func Check(catalogs []*Catalog, usage Usage, required map[string][]string, resourceCount int) []Issue {
+	if len(catalogs) == 0 {
+		return nil
+	}
+
+	issues := make([]Issue, 0)
+	base := catalogs[0]
+
+	isRequired := map[string]bool{}
+	sections := make([]string, 0, len(required))
+	for section, keys := range required {
+		sections = append(sections, section)
+		for _, key := range keys {
+			isRequired[key] = true
+		}
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		for _, key := range required[section] {
+			if _, ok := base.Messages[key]; !ok {
+				issues = append(issues, Issue{Locale: base.Locale, Key: key, Kind: IssueMissing})
+			}
+		}
+	}
+
+	// message IDs without any placeholder must exist
+	for _, id := range usage.IDs {
+		if strings.Contains(id, "%") {
+			continue
+		}
+		if _, ok := base.Messages[id]; !ok {
+			issues = append(issues, Issue{Locale: base.Locale, Key: id, Kind: IssueMissing, Detail: "used by the code"})
+		}
+	}
+
+	for _, key := range base.Keys {
+		section := strings.SplitN(key, ".", 2)[0]
+		if _, exhaustive := required[section]; exhaustive && !isRequired[key] {
+			issues = append(issues, Issue{Locale: base.Locale, Key: key, Kind: IssueUnused, Detail: "not defined by the code"})
+		} else if !usage.Uses(key) {
+			issues = append(issues, Issue{Locale: base.Locale, Key: key, Kind: IssueUnused, Detail: "not used by the code"})
+		}
+	}
+
+	issues = append(issues, checkResourceCount(base, resourceCount)...)
+
+	for _, c := range catalogs[1:] {
+		for _, key := range base.Keys {
+			message, ok := c.Messages[key]
+			if !ok {
+				issues = append(issues, Issue{Locale: c.Locale, Key: key, Kind: IssueMissing})
+				continue
+			}
+
+			expected, actual := Placeholders(base.Messages[key]), Placeholders(message)
+			if strings.Join(expected, " ") != strings.Join(actual, " ") {
+				issues = append(issues, Issue{
+					Locale: c.Locale,
+					Key:    key,
+					Kind:   IssuePlaceholder,
+					Detail: fmt.Sprintf("expect %v, got %v", expected, actual),
+				})
+			}
+		}
+
+		for _, key := range c.Keys {
+			if _, ok := base.Messages[key]; !ok {
+				issues = append(issues, Issue{Locale: c.Locale, Key: key, Kind: IssueUnused, Detail: fmt.Sprintf("not in %s", base.Locale)})
+			}
+		}
+	}
+
+	return issues
+}
+
+// checkResourceCount checks the number of resources against `DefaultResourceCount`.
+// Gaps and extra resources are reported as missing and unused keys.
+func checkResourceCount(c *Catalog, count int) []Issue {
+	n := 0
+	for _, key := range c.Keys {
+		if resourceKey.MatchString(key) {
+			n++
+		}
+	}
+
+	if n != count {
+		return []Issue{{
+			Locale: c.Locale,
+			Kind:   IssueResourceCount,
+			Detail: fmt.Sprintf("DefaultResourceCount is %d but %d resources are translated", count, n),
+		}}
+	}
+
+	return nil
+}
+
+// Placeholders returns sorted template actions of a message, e.g. `.Symptoms` of `{{.Symptoms}}`
+func Placeholders(message string) []string {
+	placeholders := make([]string, 0)
+	for _, m := range placeholder.FindAllStringSubmatch(message, -1) {
+		placeholders = append(placeholders, m[1])
+	}
+	sort.Strings(placeholders)
+	return placeholders
+}
@@ -0,0 +1,179 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// formats to exchange catalogs with translators
+const (
+	FormatCSV   = "csv"
+	FormatXLIFF = "xliff"
+)
+
+var csvHeader = []string{"key", "source", "target"}
+
+// Export writes messages of the source locale with their translations in the target
+// locale. Every key of the source is exported so that translators could fill in the
+// missing ones.
+func Export(w io.Writer, format string, source, target *Catalog) error {
+	switch format {
+	case FormatCSV:
+		return exportCSV(w, source, target)
+	case FormatXLIFF:
+		return exportXLIFF(w, source, target)
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// Import reads translations into the target catalog. Keys which are not in the source
+// are rejected and empty translations are skipped. It returns the number of imported messages.
+func Import(r io.Reader, format string, source, target *Catalog) (int, error) {
+	var translations [][2]string
+	var err error
+
+	switch format {
+	case FormatCSV:
+		translations, err = importCSV(r)
+	case FormatXLIFF:
+		translations, err = importXLIFF(r)
+	default:
+		return 0, fmt.Errorf("unknown format: %s", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range translations {
+		if _, ok := source.Messages[t[0]]; !ok {
+			return 0, fmt.Errorf("unknown key: %s", t[0])
+		}
+	}
+
+	n := 0
+	for _, t := range translations {
+		if strings.TrimSpace(t[1]) == "" {
+			continue
+		}
+		target.Set(t[0], t[1])
+		n++
+	}
+
+	return n, nil
+}
+
+func exportCSV(w io.Writer, source, target *Catalog) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, key := range source.Keys {
+		if err := cw.Write([]string{key, source.Messages[key], target.Messages[key]}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func importCSV(r io.Reader) ([][2]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+		return nil, fmt.Errorf("invalid csv header, expect %s", strings.Join(csvHeader, ","))
+	}
+
+	translations := make([][2]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		translations = append(translations, [2]string{record[0], record[2]})
+	}
+
+	return translations, nil
+}
+
+// xliff is a document of XLIFF 1.2
+type xliff struct {
+	XMLName xml.Name  `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string    `xml:"version,attr"`
+	File    xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string           `xml:"original,attr"`
+	SourceLanguage string           `xml:"source-language,attr"`
+	TargetLanguage string           `xml:"target-language,attr"`
+	Datatype       string           `xml:"datatype,attr"`
+	Units          []xliffTransUnit `xml:"body>trans-unit"`
+}
+
+type xliffTransUnit struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source"`
+	Target string `xml:"target"`
+}
+
+func exportXLIFF(w io.Writer, source, target *Catalog) error {
+	doc := xliff{
+		Version: "1.2",
+		File: xliffFile{
+			Original:       target.Locale + ".yaml",
+			SourceLanguage: languageTag(source.Locale),
+			TargetLanguage: languageTag(target.Locale),
+			Datatype:       "plaintext",
+			Units:          make([]xliffTransUnit, 0, len(source.Keys)),
+		},
+	}
+
+	for _, key := range source.Keys {
+		doc.File.Units = append(doc.File.Units, xliffTransUnit{
+			ID:     key,
+			Source: source.Messages[key],
+			Target: target.Messages[key],
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func importXLIFF(r io.Reader) ([][2]string, error) {
+	var doc xliff
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	translations := make([][2]string, 0, len(doc.File.Units))
+	for _, u := range doc.File.Units {
+		translations = append(translations, [2]string{u.ID, u.Target})
+	}
+
+	return translations, nil
+}
+
+// languageTag returns the language tag of a locale code, e.g. zh-TW for zh_tw
+func languageTag(locale string) string {
+	parts := strings.Split(locale, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i])
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bitmark-inc/autonomy-api/share/catalog"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+const usage = `usage: i18n-catalog <command> [flags]
+
+commands:
+  check   list missing, unused and inconsistent messages of all locales
+  export  export messages of a locale for translators
+  import  import translated messages into a locale
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "check":
+		err = check(os.Args[2:])
+	case "export":
+		err = export(os.Args[2:])
+	case "import":
+		err = importCatalog(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func check(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	dir := fs.String("dir", "./i18n", "directory of locale bundles")
+	src := fs.String("src", ".", "root directory of the source code")
+	fs.Parse(args)
+
+	catalogs, err := catalog.LoadAll(*dir)
+	if err != nil {
+		return err
+	}
+
+	usage, err := catalog.ScanUsage(*src, catalog.Sections(catalogs[0]))
+	if err != nil {
+		return err
+	}
+
+	issues := catalog.Check(catalogs, usage, catalog.RequiredKeys(), store.DefaultResourceCount)
+	for _, i := range issues {
+		fmt.Println(i)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issues found", len(issues))
+	}
+	return nil
+}
+
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := fs.String("dir", "./i18n", "directory of locale bundles")
+	locale := fs.String("locale", "", "locale to translate into, e.g. zh_tw")
+	format := fs.String("format", catalog.FormatCSV, "csv or xliff")
+	output := fs.String("o", "", "output file, default to stdout")
+	fs.Parse(args)
+
+	source, target, err := loadSourceAndTarget(*dir, *locale)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return catalog.Export(w, *format, source, target)
+}
+
+func importCatalog(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dir := fs.String("dir", "./i18n", "directory of locale bundles")
+	locale := fs.String("locale", "", "locale of the translations, e.g. zh_tw")
+	format := fs.String("format", catalog.FormatCSV, "csv or xliff")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("a file of translations is required")
+	}
+
+	source, target, err := loadSourceAndTarget(*dir, *locale)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := catalog.Import(f, *format, source, target)
+	if err != nil {
+		return err
+	}
+
+	if err := target.Save(*dir); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d messages imported into %s\n", n, target.Locale)
+	return nil
+}
+
+// loadSourceAndTarget loads the catalog of the default locale and the one of a locale.
+// The target is empty if its bundle does not exist yet.
+func loadSourceAndTarget(dir, locale string) (*catalog.Catalog, *catalog.Catalog, error) {
+	if locale == "" {
+		return nil, nil, fmt.Errorf("locale is required")
+	}
+
+	catalogs, err := catalog.LoadAll(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range catalogs {
+		if c.Locale == locale {
+			return catalogs[0], c, nil
+		}
+	}
+
+	target, err := catalog.Parse(locale, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return catalogs[0], target, nil
+}
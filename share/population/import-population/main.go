@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/share/population"
+	"github.com/bitmark-inc/autonomy-api/store"
+)
+
+func init() {
+	viper.AutomaticEnv()
+	viper.SetEnvPrefix("autonomy")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: import-population <population.csv>...")
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	opts := options.Client().ApplyURI(viper.GetString("mongo.conn"))
+	client, err := mongo.NewClient(opts)
+	if err != nil {
+		panic(err)
+	}
+	if err := client.Connect(ctx); err != nil {
+		panic(err)
+	}
+
+	mongoStore := store.NewMongoStore(client, viper.GetString("mongo.database"))
+
+	for _, name := range os.Args[1:] {
+		file, err := os.Open(name)
+		if err != nil {
+			panic(err)
+		}
+
+		populations, err := population.ParseCSV(file)
+		file.Close()
+		if err != nil {
+			panic(fmt.Errorf("%s: %s", name, err))
+		}
+
+		n, err := mongoStore.ImportPopulation(populations)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s: %d areas imported\n", name, n)
+	}
+}
@@ -0,0 +1,76 @@
+package population
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// columns of population data-sets
+var columns = []string{"country", "state", "county", "population"}
+
+// ParseCSV reads populations from a CSV file with a header of country, state, county and
+// population. Names of areas must be the same as the ones of confirmed cases and boundaries.
+func ParseCSV(r io.Reader) ([]schema.Population, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %s", err)
+	}
+
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, c := range columns {
+		if _, ok := index[c]; !ok {
+			return nil, fmt.Errorf("missing column: %s", c)
+		}
+	}
+
+	populations := []schema.Population{}
+	seen := map[string]int{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p := schema.Population{
+			Country: strings.TrimSpace(record[index["country"]]),
+			State:   strings.TrimSpace(record[index["state"]]),
+			County:  strings.TrimSpace(record[index["county"]]),
+		}
+		if p.Country == "" {
+			return nil, fmt.Errorf("line %d: empty country", line)
+		}
+		if p.State == "" && p.County != "" {
+			return nil, fmt.Errorf("line %d: county without state", line)
+		}
+
+		count, err := strconv.ParseFloat(strings.ReplaceAll(record[index["population"]], ",", ""), 64)
+		if err != nil || count <= 0 {
+			return nil, fmt.Errorf("line %d: invalid population: %s", line, record[index["population"]])
+		}
+		p.Count = count
+
+		key := p.Country + "|" + p.State + "|" + p.County
+		if l, ok := seen[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate area of line %d", line, l)
+		}
+		seen[key] = line
+
+		populations = append(populations, p)
+	}
+
+	return populations, nil
+}
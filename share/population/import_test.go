@@ -0,0 +1,41 @@
+package population
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+func TestParseCSV(t *testing.T) {
+	populations, err := ParseCSV(strings.NewReader(`Country,State,County,Population
+Taiwan,,,23568378
+United States,New York,Kings County,"2,559,903"
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.Population{
+		{Country: "Taiwan", Count: 23568378},
+		{Country: "United States", State: "New York", County: "Kings County", Count: 2559903},
+	}, populations)
+
+	// columns could be in any order
+	populations, err = ParseCSV(strings.NewReader("population,county,state,country\n364134,,,Iceland\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.Population{{Country: "Iceland", Count: 364134}}, populations)
+}
+
+func TestParseInvalidCSV(t *testing.T) {
+	for data, message := range map[string]string{
+		"country,state,population\n":                                 "missing column: county",
+		"country,state,county,population\n,,,100\n":                  "line 2: empty country",
+		"country,state,county,population\nTaiwan,,Taipei City,100\n": "line 2: county without state",
+		"country,state,county,population\nTaiwan,,,unknown\n":        "line 2: invalid population: unknown",
+		"country,state,county,population\nTaiwan,,,0\n":              "line 2: invalid population: 0",
+		"country,state,county,population\nTaiwan,,,1\nTaiwan,,,2\n":  "line 3: duplicate area of line 2",
+	} {
+		_, err := ParseCSV(strings.NewReader(data))
+		assert.EqualError(t, err, message, data)
+	}
+}
@@ -493,7 +493,8 @@ func (m *mongoDB) UpdateProfileMetric(accountNumber string, metric schema.Metric
 	}
 	update := bson.M{
 		"$set": bson.M{
-			"metric": metric,
+			"metric":          metric,
+			"formula_version": metric.FormulaVersion,
 		},
 	}
 
@@ -7,6 +7,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
 )
 
 func aggStageGeoProximity(maxDistance int, location schema.Location) bson.M {
@@ -47,9 +48,18 @@ func specifyField(fieldName string) string {
 	return fmt.Sprintf("$%s", fieldName)
 }
 
-func getStartTimeOfConsecutiveDays(now time.Time) (yesterdayStartAt time.Time, todayStartAt time.Time, tomorrowStartAt time.Time) {
-	todayStartAt = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-	yesterdayStartAt = todayStartAt.AddDate(0, 0, -1)
-	tomorrowStartAt = todayStartAt.AddDate(0, 0, 1)
+// getRollingWindows returns the start of the 24-hour window before the last one, the start of
+// the last one and the end of the last one, which includes `now`. The windows roll with time,
+// so counts of them do not jump at the midnight of any timezone.
+func getRollingWindows(now time.Time) (previousStartAt time.Time, lastStartAt time.Time, endAt time.Time) {
+	endAt = now.Add(time.Second)
+	lastStartAt = endAt.Add(-score.ComparisonPeriod)
+	previousStartAt = lastStartAt.Add(-score.ComparisonPeriod)
 	return
 }
+
+// aggWindowOfReport returns 0 for reports in the last window since `lastStartAt`, and 1 for
+// reports in the one before
+func aggWindowOfReport(lastStartAt time.Time) bson.M {
+	return bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$ts", lastStartAt.Unix()}}, 0, 1}}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+var (
+	ErrServiceSignatureReplayed = fmt.Errorf("service signature replayed")
+)
+
+// Audit - operations for audit logs
+type Audit interface {
+	AddAuditLog(entry schema.AuditLog) error
+	UseServiceSignature(serviceID, signature string, expiresAt time.Time) error
+}
+
+// AddAuditLog saves an audit log entry
+func (m *mongoDB) AddAuditLog(entry schema.AuditLog) error {
+	c := m.client.Database(m.database).Collection(schema.AuditLogCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err := c.InsertOne(ctx, entry)
+	return err
+}
+
+// UseServiceSignature records a signature of a service request until it expires. It returns
+// `ErrServiceSignatureReplayed` if the signature has been used.
+func (m *mongoDB) UseServiceSignature(serviceID, signature string, expiresAt time.Time) error {
+	c := m.client.Database(m.database).Collection(schema.ServiceSignatureCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err := c.InsertOne(ctx, schema.ServiceSignature{
+		ID:        serviceID + ":" + signature,
+		ExpiresAt: expiresAt,
+	})
+	if we, ok := err.(mongo.WriteException); ok {
+		if 1 == len(we.WriteErrors) && DuplicateKeyCode == we.WriteErrors[0].Code {
+			return ErrServiceSignatureReplayed
+		}
+	}
+	return err
+}
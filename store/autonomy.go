@@ -20,9 +20,12 @@ type AutonomyCore interface {
 	// Help
 	RequestHelp(accountNumber, subject, needs, meetingPlace, contactInfo string) (*schema.HelpRequest, error)
 	GetHelp(helpID string) (*schema.HelpRequest, error)
-	ListHelps(accountNumber string, latitude, longitude float64, count int64) ([]schema.HelpRequest, error)
+	ListHelps(accountNumber string, latitude, longitude float64, after Cursor, count int64) ([]schema.HelpRequest, error)
 	AnswerHelp(accountNumber string, helpID string) (*schema.HelpRequest, error)
 	ExpireHelps() error
+	ExpireHelp(helpID string) error
+	ListAccountHelps(accountNumber string) ([]schema.HelpRequest, error)
+	DeleteAccountHelps(accountNumber string) error
 }
 
 // AutonomyStore is an implementation of AutonomyCore
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last item of a page. Lists are sorted by timestamps and
+// then ids in descending order, so items which share a timestamp are neither skipped nor
+// duplicated across pages. A cursor without the id points before all items at the time.
+type Cursor struct {
+	Time time.Time
+	ID   string
+}
+
+// IsZero returns whether a cursor points to the beginning of a list
+func (c Cursor) IsZero() bool {
+	return c.Time.IsZero() && c.ID == ""
+}
+
+// Encode returns the opaque string of a cursor for clients
+func (c Cursor) Encode() string {
+	var ts int64
+	if !c.Time.IsZero() {
+		ts = c.Time.UnixNano()
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(ts, 10) + ":" + c.ID))
+}
+
+// DecodeCursor parses a cursor returned by `Encode`
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	ts, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || ts < 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	c := Cursor{ID: parts[1]}
+	if ts > 0 {
+		c.Time = time.Unix(0, ts).UTC()
+	}
+
+	return c, nil
+}
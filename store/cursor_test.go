@@ -0,0 +1,47 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorEncoding(t *testing.T) {
+	for _, c := range []Cursor{
+		{},
+		{Time: time.Date(2020, 6, 1, 8, 0, 0, 123, time.UTC)},
+		{Time: time.Date(2020, 6, 1, 8, 0, 0, 0, time.UTC), ID: "5ed4b5d1f0a2c3e4d5b6a7c8"},
+		{ID: "5ed4b5d1f0a2c3e4d5b6a7c8"},
+	} {
+		decoded, err := DecodeCursor(c.Encode())
+		assert.NoError(t, err)
+		assert.Equal(t, c, decoded)
+	}
+
+	assert.True(t, Cursor{}.IsZero())
+	assert.False(t, Cursor{ID: "id"}.IsZero())
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	for _, s := range []string{"not base64!", "MTIzNDU", "YWJjOmlk", "LTE6aWQ"} {
+		_, err := DecodeCursor(s)
+		assert.Equal(t, ErrInvalidCursor, err, s)
+	}
+}
+
+func TestHistoryQuery(t *testing.T) {
+	ts := time.Unix(1591000000, 0)
+
+	q, err := historyQuery("account", Cursor{Time: ts})
+	assert.NoError(t, err)
+	assert.Equal(t, "account", q["account_number"])
+	assert.NotContains(t, q, "$or")
+
+	q, err = historyQuery("account", Cursor{Time: ts, ID: "5ed4b5d1f0a2c3e4d5b6a7c8"})
+	assert.NoError(t, err)
+	assert.Contains(t, q, "$or")
+
+	_, err = historyQuery("account", Cursor{Time: ts, ID: "not-an-object-id"})
+	assert.Equal(t, ErrInvalidCursor, err)
+}
@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const accountDeletionLifetime = 30 * 24 * time.Hour
+
+var (
+	ErrAccountDeletionNotFound   = fmt.Errorf("account deletion not found")
+	ErrAccountDeletionInProgress = fmt.Errorf("account deletion in progress")
+)
+
+// AccountDeletion - operations for removing every record of an account
+type AccountDeletion interface {
+	StartAccountDeletion(accountNumber string) (*schema.AccountDeletion, error)
+	GetAccountDeletion(accountNumber string) (*schema.AccountDeletion, error)
+	GetAccountDeletionByID(id string) (*schema.AccountDeletion, error)
+	CompleteAccountDeletionStep(accountNumber string, step schema.DeletionStep) error
+	FinishAccountDeletion(accountNumber string, deletionErr error) error
+	ClaimStaleAccountDeletion(before time.Time) (*schema.AccountDeletion, error)
+
+	AnonymizeReports(profileID string) error
+	DeleteScoreHistory(accountNumber string) error
+}
+
+// StartAccountDeletion marks an account as deleting. A failed deletion is resumed
+// from its completed steps, otherwise the deletion starts from the first step. Deletions
+// are started by conditional writes, so that only one of concurrent requests starts one and
+// the others get `ErrAccountDeletionInProgress`.
+func (m *mongoDB) StartAccountDeletion(accountNumber string) (*schema.AccountDeletion, error) {
+	c := m.client.Database(m.database).Collection(schema.AccountDeletionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	var resumed schema.AccountDeletion
+	err := c.FindOneAndUpdate(ctx,
+		bson.M{"_id": accountNumber, "status": schema.DeletionFailed},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"status":     schema.DeletionInProgress,
+				"updated_at": now,
+				// deletions failed before they had IDs get one
+				"deletion_id": bson.M{"$ifNull": bson.A{"$deletion_id", uuid.New().String()}},
+			}}},
+			{{Key: "$unset", Value: bson.A{"error", "completed_at", "expires_at"}}},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&resumed)
+	if err == nil {
+		return &resumed, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	deletion := schema.AccountDeletion{
+		ID:               uuid.New().String(),
+		AccountNumber:    accountNumber,
+		PointsOfInterest: []primitive.ObjectID{},
+		Status:           schema.DeletionInProgress,
+		CompletedSteps:   []schema.DeletionStep{},
+		StartedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	profile, err := m.GetProfile(accountNumber)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if profile != nil {
+		deletion.ProfileID = profile.ID
+		for _, p := range profile.PointsOfInterest {
+			deletion.PointsOfInterest = append(deletion.PointsOfInterest, p.ID)
+		}
+	}
+
+	// a deletion in progress is not matched, so the upsert conflicts with its ID
+	if _, err := c.ReplaceOne(ctx,
+		bson.M{"_id": accountNumber, "status": bson.M{"$ne": schema.DeletionInProgress}},
+		deletion,
+		options.Replace().SetUpsert(true),
+	); err != nil {
+		if we, ok := err.(mongo.WriteException); ok {
+			if 1 == len(we.WriteErrors) && DuplicateKeyCode == we.WriteErrors[0].Code {
+				return nil, ErrAccountDeletionInProgress
+			}
+		}
+		return nil, err
+	}
+
+	return &deletion, nil
+}
+
+// GetAccountDeletion returns the deletion progress of an account
+func (m *mongoDB) GetAccountDeletion(accountNumber string) (*schema.AccountDeletion, error) {
+	c := m.client.Database(m.database).Collection(schema.AccountDeletionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var deletion schema.AccountDeletion
+	if err := c.FindOne(ctx, bson.M{"_id": accountNumber}).Decode(&deletion); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAccountDeletionNotFound
+		}
+		return nil, err
+	}
+
+	return &deletion, nil
+}
+
+// GetAccountDeletionByID returns the deletion progress by the ID of the deletion, which
+// is readable after tokens of the account are revoked
+func (m *mongoDB) GetAccountDeletionByID(id string) (*schema.AccountDeletion, error) {
+	c := m.client.Database(m.database).Collection(schema.AccountDeletionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var deletion schema.AccountDeletion
+	if err := c.FindOne(ctx, bson.M{"deletion_id": id}).Decode(&deletion); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAccountDeletionNotFound
+		}
+		return nil, err
+	}
+
+	return &deletion, nil
+}
+
+// CompleteAccountDeletionStep records a finished step of a deletion
+func (m *mongoDB) CompleteAccountDeletionStep(accountNumber string, step schema.DeletionStep) error {
+	c := m.client.Database(m.database).Collection(schema.AccountDeletionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err := c.UpdateOne(ctx, bson.M{"_id": accountNumber}, bson.M{
+		"$addToSet": bson.M{"completed_steps": step},
+		"$set":      bson.M{"updated_at": time.Now().UTC()},
+	})
+	return err
+}
+
+// ClaimStaleAccountDeletion takes over a deletion in progress which has not been updated
+// since a time, e.g. the server was restarted while deleting the account. The claimed
+// deletion is touched so that it is not claimed by another server at the same time.
+func (m *mongoDB) ClaimStaleAccountDeletion(before time.Time) (*schema.AccountDeletion, error) {
+	c := m.client.Database(m.database).Collection(schema.AccountDeletionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var deletion schema.AccountDeletion
+	if err := c.FindOneAndUpdate(ctx,
+		bson.M{
+			"status":     schema.DeletionInProgress,
+			"updated_at": bson.M{"$not": bson.M{"$gte": before}},
+		},
+		bson.M{"$set": bson.M{"updated_at": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&deletion); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAccountDeletionNotFound
+		}
+		return nil, err
+	}
+
+	return &deletion, nil
+}
+
+// FinishAccountDeletion ends a deletion as completed, or as failed if an error is given.
+// The record is kept for a while so that clients are able to query the result.
+func (m *mongoDB) FinishAccountDeletion(accountNumber string, deletionErr error) error {
+	c := m.client.Database(m.database).Collection(schema.AccountDeletionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	fields := bson.M{
+		"status":       schema.DeletionCompleted,
+		"completed_at": now,
+		"expires_at":   now.Add(accountDeletionLifetime),
+	}
+
+	if deletionErr != nil {
+		fields["status"] = schema.DeletionFailed
+		fields["error"] = deletionErr.Error()
+	}
+
+	_, err := c.UpdateOne(ctx, bson.M{"_id": accountNumber}, bson.M{"$set": fields})
+	return err
+}
+
+// AnonymizeReports detaches symptom and behavior reports from a profile. Each report is
+// kept under its own random ID so that the statistics of areas remain unchanged, while
+// reports of the profile could not be linked to each other.
+func (m *mongoDB) AnonymizeReports(profileID string) error {
+	if profileID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*defaultTimeout)
+	defer cancel()
+
+	for _, collection := range []string{schema.SymptomReportCollection, schema.BehaviorReportCollection} {
+		c := m.client.Database(m.database).Collection(collection)
+
+		cursor, err := c.Find(ctx, bson.M{"profile_id": profileID}, options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			log.WithField("prefix", mongoLogPrefix).WithError(err).Errorf("fail to query %s", collection)
+			return err
+		}
+
+		var reports []bson.M
+		if err := cursor.All(ctx, &reports); err != nil {
+			return err
+		}
+
+		if len(reports) == 0 {
+			continue
+		}
+
+		models := make([]mongo.WriteModel, 0, len(reports))
+		for _, r := range reports {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": r["_id"], "profile_id": profileID}).
+				SetUpdate(bson.M{"$set": bson.M{
+					"profile_id":     uuid.New().String(),
+					"account_number": "",
+				}}))
+		}
+
+		result, err := c.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			log.WithField("prefix", mongoLogPrefix).WithError(err).Errorf("fail to anonymize %s", collection)
+			return err
+		}
+
+		log.WithField("prefix", mongoLogPrefix).Infof("anonymize %d records of %s", result.ModifiedCount, collection)
+	}
+
+	return nil
+}
+
+// DeleteScoreHistory removes the individual score history of an account
+func (m *mongoDB) DeleteScoreHistory(accountNumber string) error {
+	c := m.client.Database(m.database).Collection(schema.ScoreHistoryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err := c.DeleteMany(ctx, bson.M{"owner": accountNumber, "type": schema.ScoreRecordTypeIndividual})
+	return err
+}
@@ -0,0 +1,294 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
+)
+
+var (
+	deletionPOIID = primitive.NewObjectID()
+
+	// resource_2 was rated a half-life before the last rating of the profile
+	deletionRatedAt = time.Now().Add(-score.DefaultRatingConfig.HalfLife)
+
+	deletionProfile = schema.Profile{
+		ID:            "deletion-profile-id",
+		AccountNumber: "account-deletion",
+		PointsOfInterest: []schema.ProfilePOI{
+			{
+				ID: deletionPOIID,
+				ResourceRatings: schema.ProfileRatingsMetric{
+					Resources: []schema.RatingResource{
+						{Resource: schema.Resource{ID: "resource_1"}, Score: 5},
+						{Resource: schema.Resource{ID: "resource_2"}, Score: 5, RatedAt: deletionRatedAt.Unix()},
+					},
+					LastUpdate: time.Now().Unix(),
+				},
+			},
+		},
+	}
+
+	deletionPOI = schema.POI{
+		ID: deletionPOIID,
+		ResourceRatings: schema.POIRatingsMetric{
+			Resources: []schema.POIResourceRating{
+				{Resource: schema.Resource{ID: "resource_1"}, SumOfScore: 8, Score: 4, Ratings: 2},
+				{
+					Resource:   schema.Resource{ID: "resource_2"},
+					SumOfScore: 8, Score: 4, Ratings: 2,
+					Weighted: schema.WeightedRatings{Sum: 5.5, Count: 1.5, At: time.Now().Unix()},
+				},
+			},
+		},
+	}
+)
+
+type AccountDeletionTestSuite struct {
+	suite.Suite
+	connURI      string
+	testDBName   string
+	mongoClient  *mongo.Client
+	testDatabase *mongo.Database
+}
+
+func NewAccountDeletionTestSuite(connURI, dbName string) *AccountDeletionTestSuite {
+	return &AccountDeletionTestSuite{
+		connURI:    connURI,
+		testDBName: dbName,
+	}
+}
+
+func (s *AccountDeletionTestSuite) SetupSuite() {
+	if s.connURI == "" || s.testDBName == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	if err = mongoClient.Connect(context.Background()); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+	s.testDatabase = mongoClient.Database(s.testDBName)
+
+	// make sure the test suite is run with a clean environment
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+
+	if err := s.LoadFixtures(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+// LoadFixtures will preload fixtures into test mongodb
+func (s *AccountDeletionTestSuite) LoadFixtures() error {
+	ctx := context.Background()
+
+	if _, err := s.testDatabase.Collection(schema.ProfileCollection).InsertOne(ctx, deletionProfile); err != nil {
+		return err
+	}
+
+	if _, err := s.testDatabase.Collection(schema.POICollection).InsertOne(ctx, deletionPOI); err != nil {
+		return err
+	}
+
+	if _, err := s.testDatabase.Collection(schema.SymptomReportCollection).InsertMany(ctx, []interface{}{
+		schema.SymptomReportData{ProfileID: deletionProfile.ID, AccountNumber: deletionProfile.AccountNumber},
+		schema.SymptomReportData{ProfileID: deletionProfile.ID, AccountNumber: deletionProfile.AccountNumber},
+	}); err != nil {
+		return err
+	}
+
+	if _, err := s.testDatabase.Collection(schema.ScoreHistoryCollection).InsertMany(ctx, []interface{}{
+		schema.ScoreRecord{Owner: deletionProfile.AccountNumber, Type: schema.ScoreRecordTypeIndividual, Date: "2020-07-01"},
+		schema.ScoreRecord{Owner: deletionPOIID.Hex(), Type: schema.ScoreRecordTypePOI, Date: "2020-07-01"},
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CleanMongoDB drop the whole test mongodb
+func (s *AccountDeletionTestSuite) CleanMongoDB() error {
+	return s.testDatabase.Drop(context.Background())
+}
+
+func (s *AccountDeletionTestSuite) TearDownSuite() {
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func (s *AccountDeletionTestSuite) TestDeletionProgress() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	deletion, err := store.StartAccountDeletion(deletionProfile.AccountNumber)
+	s.NoError(err)
+	s.Equal(schema.DeletionInProgress, deletion.Status)
+	s.Equal(deletionProfile.ID, deletion.ProfileID)
+	s.Equal([]primitive.ObjectID{deletionPOIID}, deletion.PointsOfInterest)
+
+	// a deletion in progress is not started again
+	_, err = store.StartAccountDeletion(deletionProfile.AccountNumber)
+	s.Equal(ErrAccountDeletionInProgress, err)
+
+	deletionID := deletion.ID
+	s.NotEmpty(deletionID)
+	deletion, err = store.GetAccountDeletionByID(deletionID)
+	s.NoError(err)
+	s.Equal(deletionProfile.AccountNumber, deletion.AccountNumber)
+
+	s.NoError(store.CompleteAccountDeletionStep(deletionProfile.AccountNumber, schema.DeletionStepWorkflows))
+	s.NoError(store.FinishAccountDeletion(deletionProfile.AccountNumber, fmt.Errorf("failed")))
+
+	deletion, err = store.GetAccountDeletion(deletionProfile.AccountNumber)
+	s.NoError(err)
+	s.Equal(schema.DeletionFailed, deletion.Status)
+	s.Equal("failed", deletion.Error)
+
+	// a failed deletion is resumed with its completed steps
+	deletion, err = store.StartAccountDeletion(deletionProfile.AccountNumber)
+	s.NoError(err)
+	s.Equal(schema.DeletionInProgress, deletion.Status)
+	s.True(deletion.IsStepCompleted(schema.DeletionStepWorkflows))
+	s.False(deletion.IsStepCompleted(schema.DeletionStepAccount))
+	s.Equal(deletionID, deletion.ID)
+
+	s.NoError(store.FinishAccountDeletion(deletionProfile.AccountNumber, nil))
+	deletion, err = store.GetAccountDeletion(deletionProfile.AccountNumber)
+	s.NoError(err)
+	s.Equal(schema.DeletionCompleted, deletion.Status)
+	s.NotNil(deletion.ExpiresAt)
+
+	_, err = store.GetAccountDeletion("account-not-exist")
+	s.Equal(ErrAccountDeletionNotFound, err)
+	_, err = store.GetAccountDeletionByID("deletion-not-exist")
+	s.Equal(ErrAccountDeletionNotFound, err)
+}
+
+func (s *AccountDeletionTestSuite) TestStartAccountDeletionConcurrently() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.StartAccountDeletion("account-concurrent-deletion")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// only one of the requests starts the deletion
+	started := 0
+	for err := range errs {
+		if err == nil {
+			started++
+		} else {
+			s.Equal(ErrAccountDeletionInProgress, err)
+		}
+	}
+	s.Equal(1, started)
+}
+
+func (s *AccountDeletionTestSuite) TestClaimStaleAccountDeletion() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	ctx := context.Background()
+
+	_, err := store.StartAccountDeletion("account-deleting")
+	s.NoError(err)
+
+	_, err = s.testDatabase.Collection(schema.AccountDeletionCollection).InsertOne(ctx, schema.AccountDeletion{
+		AccountNumber: "account-stale-deletion",
+		Status:        schema.DeletionInProgress,
+		StartedAt:     time.Now().Add(-time.Hour),
+		UpdatedAt:     time.Now().Add(-time.Hour),
+	})
+	s.NoError(err)
+
+	before := time.Now().Add(-10 * time.Minute)
+
+	// only the deletion which is not updated recently is claimed
+	claimed, err := store.ClaimStaleAccountDeletion(before)
+	s.NoError(err)
+	s.Equal("account-stale-deletion", claimed.AccountNumber)
+	s.True(claimed.UpdatedAt.After(before))
+
+	// a claimed deletion is not claimed again until it is stale again
+	_, err = store.ClaimStaleAccountDeletion(before)
+	s.Equal(ErrAccountDeletionNotFound, err)
+
+	s.NoError(store.FinishAccountDeletion("account-deleting", nil))
+	s.NoError(store.FinishAccountDeletion("account-stale-deletion", nil))
+}
+
+func (s *AccountDeletionTestSuite) TestRemovePOIRatings() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	affected, err := store.RemovePOIRatings(deletionProfile.AccountNumber)
+	s.NoError(err)
+	s.Equal([]primitive.ObjectID{deletionPOIID}, affected)
+
+	poi, err := store.GetPOI(deletionPOIID)
+	s.NoError(err)
+	s.Equal(int64(1), poi.ResourceRatings.Resources[0].Ratings)
+	s.Equal(float64(3), poi.ResourceRatings.Resources[0].SumOfScore)
+	s.Equal(float64(3), poi.ResourceRatings.Resources[0].Score)
+
+	// the rating is withdrawn by its weight at the time it was rated
+	s.InDelta(3, poi.ResourceRatings.Resources[1].Weighted.Sum, 1e-3)
+	s.InDelta(1, poi.ResourceRatings.Resources[1].Weighted.Count, 1e-3)
+
+	// ratings are withdrawn only once
+	affected, err = store.RemovePOIRatings(deletionProfile.AccountNumber)
+	s.NoError(err)
+	s.Len(affected, 0)
+}
+
+func (s *AccountDeletionTestSuite) TestAnonymizeReportsAndDeleteScoreHistory() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	ctx := context.Background()
+
+	s.NoError(store.AnonymizeReports(deletionProfile.ID))
+
+	count, err := s.testDatabase.Collection(schema.SymptomReportCollection).CountDocuments(ctx, bson.M{"profile_id": deletionProfile.ID})
+	s.NoError(err)
+	s.Equal(int64(0), count)
+
+	// reports are kept with their own random IDs
+	profileIDs, err := s.testDatabase.Collection(schema.SymptomReportCollection).Distinct(ctx, "profile_id", bson.M{})
+	s.NoError(err)
+	s.Len(profileIDs, 2)
+
+	s.NoError(store.DeleteScoreHistory(deletionProfile.AccountNumber))
+
+	count, err = s.testDatabase.Collection(schema.ScoreHistoryCollection).CountDocuments(ctx, bson.M{})
+	s.NoError(err)
+	s.Equal(int64(1), count)
+}
+
+func TestAccountDeletionTestSuite(t *testing.T) {
+	suite.Run(t, NewAccountDeletionTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled", "test-account-deletion"))
+}
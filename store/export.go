@@ -0,0 +1,316 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const accountExportLifetime = 7 * 24 * time.Hour
+
+var (
+	ErrAccountExportNotFound = fmt.Errorf("export not found")
+)
+
+// previous statuses from which an export is allowed to move to a status
+var accountExportTransitions = map[schema.ExportStatus][]schema.ExportStatus{
+	schema.ExportProcessing: {schema.ExportPending},
+	schema.ExportCompleted:  {schema.ExportProcessing},
+	schema.ExportFailed:     {schema.ExportPending, schema.ExportProcessing},
+}
+
+// AccountExport - operations for personal data export
+type AccountExport interface {
+	CreateAccountExport(accountNumber string) (*schema.AccountExport, error)
+	GetLatestAccountExport(accountNumber string) (*schema.AccountExport, error)
+	UpdateAccountExport(id string, status schema.ExportStatus) error
+	CompleteAccountExport(id string, archive []byte) error
+	FailStaleAccountExports(accountNumber string, before time.Time) error
+	OpenAccountExportArchive(id string) (io.ReadCloser, error)
+	ExportAccountDocuments(accountNumber string) (map[string][]bson.M, error)
+	DeleteAccountExports(accountNumber string) error
+	DeleteExpiredAccountExportArchives(now time.Time) (int, error)
+}
+
+// accountExportBucket returns the GridFS bucket of export archives, which are too large
+// to be kept in export documents
+func (m *mongoDB) accountExportBucket() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(
+		m.client.Database(m.database),
+		options.GridFSBucket().SetName(schema.AccountExportArchiveBucket),
+	)
+}
+
+// CreateAccountExport adds a pending export request for an account
+func (m *mongoDB) CreateAccountExport(accountNumber string) (*schema.AccountExport, error) {
+	c := m.client.Database(m.database).Collection(schema.AccountExportCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	export := schema.AccountExport{
+		ID:            uuid.New().String(),
+		AccountNumber: accountNumber,
+		Status:        schema.ExportPending,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(accountExportLifetime),
+	}
+
+	if _, err := c.InsertOne(ctx, export); err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// GetLatestAccountExport returns the most recent export request of an account
+func (m *mongoDB) GetLatestAccountExport(accountNumber string) (*schema.AccountExport, error) {
+	c := m.client.Database(m.database).Collection(schema.AccountExportCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var export schema.AccountExport
+	if err := c.FindOne(ctx,
+		bson.M{"account_number": accountNumber},
+		options.FindOne().SetSort(bson.M{"created_at": -1}),
+	).Decode(&export); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAccountExportNotFound
+		}
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// updateAccountExport moves an export to a status with extra fields. It returns
+// ErrAccountExportNotFound if the export is not in a status which could move to the status.
+func (m *mongoDB) updateAccountExport(id string, status schema.ExportStatus, fields bson.M) error {
+	c := m.client.Database(m.database).Collection(schema.AccountExportCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	fields["status"] = status
+	if status == schema.ExportCompleted || status == schema.ExportFailed {
+		fields["completed_at"] = time.Now().UTC()
+	}
+
+	result, err := c.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": bson.M{"$in": accountExportTransitions[status]},
+	}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrAccountExportNotFound
+	}
+
+	return nil
+}
+
+// UpdateAccountExport updates the status of an export request
+func (m *mongoDB) UpdateAccountExport(id string, status schema.ExportStatus) error {
+	return m.updateAccountExport(id, status, bson.M{})
+}
+
+// CompleteAccountExport saves the archive of an export in GridFS and completes the
+// export. The archive is removed if the export is no longer being processed.
+func (m *mongoDB) CompleteAccountExport(id string, archive []byte) error {
+	bucket, err := m.accountExportBucket()
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.SetWriteDeadline(time.Now().Add(4 * defaultTimeout)); err != nil {
+		return err
+	}
+
+	if err := bucket.UploadFromStreamWithID(id, id, bytes.NewReader(archive),
+		options.GridFSUpload().SetMetadata(bson.M{"expires_at": time.Now().UTC().Add(accountExportLifetime)}),
+	); err != nil {
+		return err
+	}
+
+	if err := m.updateAccountExport(id, schema.ExportCompleted, bson.M{"archive_size": int64(len(archive))}); err != nil {
+		if err := bucket.Delete(id); err != nil {
+			log.WithError(err).WithField("export_id", id).Error("fail to remove export archive")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FailStaleAccountExports marks exports of an account which are still in progress
+// but were requested before a time as failed
+func (m *mongoDB) FailStaleAccountExports(accountNumber string, before time.Time) error {
+	c := m.client.Database(m.database).Collection(schema.AccountExportCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err := c.UpdateMany(ctx, bson.M{
+		"account_number": accountNumber,
+		"status":         bson.M{"$in": accountExportTransitions[schema.ExportFailed]},
+		"created_at":     bson.M{"$lt": before},
+	}, bson.M{"$set": bson.M{
+		"status":       schema.ExportFailed,
+		"completed_at": time.Now().UTC(),
+	}})
+	return err
+}
+
+// OpenAccountExportArchive opens the archive of a completed export
+func (m *mongoDB) OpenAccountExportArchive(id string) (io.ReadCloser, error) {
+	bucket, err := m.accountExportBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(id)
+	if err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return nil, ErrAccountExportNotFound
+		}
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// ExportAccountDocuments collects all mongodb documents which belong to an account.
+// The result is keyed by the name of each dataset.
+func (m *mongoDB) ExportAccountDocuments(accountNumber string) (map[string][]bson.M, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*defaultTimeout)
+	defer cancel()
+
+	db := m.client.Database(m.database)
+
+	var profile schema.Profile
+	if err := db.Collection(schema.ProfileCollection).FindOne(ctx, bson.M{"account_number": accountNumber}).Decode(&profile); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errAccountNotFound
+		}
+		return nil, err
+	}
+
+	poiIDs := make(bson.A, 0, len(profile.PointsOfInterest))
+	for _, p := range profile.PointsOfInterest {
+		poiIDs = append(poiIDs, p.ID)
+	}
+
+	queries := []struct {
+		name       string
+		collection string
+		filter     bson.M
+	}{
+		{"profile", schema.ProfileCollection, bson.M{"account_number": accountNumber}},
+		{"symptom_reports", schema.SymptomReportCollection, bson.M{"profile_id": profile.ID}},
+		{"behavior_reports", schema.BehaviorReportCollection, bson.M{"profile_id": profile.ID}},
+		{"score_history", schema.ScoreHistoryCollection, bson.M{"owner": accountNumber, "type": schema.ScoreRecordTypeIndividual}},
+		{"location_history", schema.LocationHistoryCollection, bson.M{"account_number": accountNumber}},
+		{"points_of_interest", schema.POICollection, bson.M{"_id": bson.M{"$in": poiIDs}}},
+	}
+
+	result := make(map[string][]bson.M, len(queries))
+	for _, q := range queries {
+		cursor, err := db.Collection(q.collection).Find(ctx, q.filter)
+		if err != nil {
+			return nil, err
+		}
+
+		docs := make([]bson.M, 0)
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+
+		if q.name == "points_of_interest" {
+			// aggregated ratings of a POI come from other people
+			for _, d := range docs {
+				delete(d, "resource_ratings")
+			}
+		}
+
+		result[q.name] = docs
+	}
+
+	return result, nil
+}
+
+// deleteAccountExportArchives removes archives matched by a filter of GridFS files
+func (m *mongoDB) deleteAccountExportArchives(filter bson.M) (int, error) {
+	bucket, err := m.accountExportBucket()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	cursor, err := bucket.Find(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var files []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &files); err != nil {
+		return 0, err
+	}
+
+	for _, f := range files {
+		if err := bucket.Delete(f.ID); err != nil && err != gridfs.ErrFileNotFound {
+			return 0, err
+		}
+	}
+
+	return len(files), nil
+}
+
+// DeleteAccountExports removes all exports of an account and their archives
+func (m *mongoDB) DeleteAccountExports(accountNumber string) error {
+	c := m.client.Database(m.database).Collection(schema.AccountExportCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	cursor, err := c.Find(ctx, bson.M{"account_number": accountNumber}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+
+	var exports []schema.AccountExport
+	if err := cursor.All(ctx, &exports); err != nil {
+		return err
+	}
+
+	ids := make(bson.A, 0, len(exports))
+	for _, e := range exports {
+		ids = append(ids, e.ID)
+	}
+
+	if _, err := m.deleteAccountExportArchives(bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return err
+	}
+
+	_, err = c.DeleteMany(ctx, bson.M{"account_number": accountNumber})
+	return err
+}
+
+// DeleteExpiredAccountExportArchives removes archives which expired before a time. Export
+// documents are removed by their TTL index, which does not apply to GridFS files.
+func (m *mongoDB) DeleteExpiredAccountExportArchives(now time.Time) (int, error) {
+	return m.deleteAccountExportArchives(bson.M{"metadata.expires_at": bson.M{"$lt": now}})
+}
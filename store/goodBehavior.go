@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -23,27 +22,33 @@ const (
 	DuplicateKeyCode = 11000
 )
 
+var (
+	ErrBehaviorNotFound = fmt.Errorf("customized behavior not found")
+)
+
 var localizedBehaviors map[string][]schema.Behavior = map[string][]schema.Behavior{}
 
 // GoodBehaviorReport save a GoodBehaviorData into Database
 type GoodBehaviorReport interface {
 	CreateBehavior(behavior schema.Behavior) (string, error)
-	GoodBehaviorSave(data *schema.BehaviorReportData) error
+	GoodBehaviorSave(data *schema.BehaviorReportData, idempotentSince int64) (*schema.BehaviorReportData, error)
+	FindBehaviorReportByIdempotencyKey(profileID, key string, since int64) (*schema.BehaviorReportData, error)
 	FindBehaviorsByIDs(ids []string) ([]schema.Behavior, error)
 	FindBehaviorDistribution(profileID string, loc *schema.Location, dist int, start, end int64) (map[string]int, error)
-	FindNearbyBehaviorReportTimes(dist int, loc schema.Location, start, end int64) (int, error)
 	FindNearbyNonOfficialBehaviors(dist int, loc schema.Location) ([]schema.Behavior, error)
 	ListOfficialBehavior(string) ([]schema.Behavior, error)
 	ListCustomizedBehaviors() ([]schema.Behavior, error)
 	GetBehaviorCount(profileID string, loc *schema.Location, dist int, now time.Time) (int, int, error)
 	GetPersonalBehaviorTimeSeriesData(profileID string, start, end int64, utcOffset string, granularity schema.AggregationTimeGranularity) (map[string][]schema.Bucket, error)
+	UpdateCustomizedBehavior(id, name, desc string) error
+	MergeCustomizedBehavior(fromID, toID string) error
 }
 
 func (m *mongoDB) ListOfficialBehavior(lang string) ([]schema.Behavior, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	lang = strings.ReplaceAll(strings.ToLower(lang), "-", "_")
+	lang = utils.ResolveLanguage(lang, "")
 
 	if behaviors, ok := localizedBehaviors[lang]; ok {
 		return behaviors, nil
@@ -136,23 +141,51 @@ func (m *mongoDB) CreateBehavior(behavior schema.Behavior) (string, error) {
 	return string(behavior.ID), nil
 }
 
-// GoodBehaviorData save a GoodBehaviorData into mongoDB
-func (m *mongoDB) GoodBehaviorSave(data *schema.BehaviorReportData) error {
+// GoodBehaviorData save a GoodBehaviorData into mongoDB. If the idempotency key of the
+// report is used by another report after `idempotentSince`, the report is not saved and
+// the original one is returned.
+func (m *mongoDB) GoodBehaviorSave(data *schema.BehaviorReportData, idempotentSince int64) (*schema.BehaviorReportData, error) {
 	if 0 == len(data.Behaviors) {
 		data.Behaviors = []schema.Behavior{}
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	c := m.client.Database(m.database)
-	_, err := c.Collection(schema.BehaviorReportCollection).InsertOne(ctx, *data)
-	we, hasErr := err.(mongo.WriteException)
-	if hasErr {
-		if 1 == len(we.WriteErrors) && DuplicateKeyCode == we.WriteErrors[0].Code {
-			return nil
+
+	report := *data
+	report.Location = coarsenReportLocation(report.Location)
+
+	replayed, err := insertProfileReport(ctx, c.Collection(schema.BehaviorReportCollection), report,
+		report.ProfileID, report.IdempotencyKey, idempotentSince)
+	if err != nil || !replayed {
+		return nil, err
+	}
+
+	return m.FindBehaviorReportByIdempotencyKey(report.ProfileID, report.IdempotencyKey, idempotentSince)
+}
+
+// FindBehaviorReportByIdempotencyKey returns a report of a profile submitted with the
+// idempotency key after `since`. It returns nil if there is no such report.
+func (m *mongoDB) FindBehaviorReportByIdempotencyKey(profileID, key string, since int64) (*schema.BehaviorReportData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	c := m.client.Database(m.database).Collection(schema.BehaviorReportCollection)
+
+	query := bson.M{
+		"profile_id":      profileID,
+		"idempotency_key": key,
+		"ts":              bson.M{"$gte": since},
+	}
+
+	var report schema.BehaviorReportData
+	if err := c.FindOne(ctx, query).Decode(&report); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
-	return nil
+
+	return &report, nil
 }
 
 func (m *mongoDB) FindBehaviorsByIDs(ids []string) ([]schema.Behavior, error) {
@@ -264,41 +297,6 @@ func (m *mongoDB) FindBehaviorDistribution(profileID string, loc *schema.Locatio
 	return result, nil
 }
 
-// FindNearbyBehaviorReportTimes returns the number of report times in the specified area and within the specified time rage.
-//
-// Take the same case described in the above function FindBehaviorDistribution for example,
-// the result is 5.
-func (m *mongoDB) FindNearbyBehaviorReportTimes(dist int, loc schema.Location, start, end int64) (int, error) {
-	c := m.client.Database(m.database).Collection(schema.BehaviorReportCollection)
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
-	defer cancel()
-
-	pipeline := []bson.M{
-		aggStageGeoProximity(dist, loc),
-		aggStageReportedBetween(start, end),
-		{
-			"$count": "count",
-		},
-	}
-	cursor, err := c.Aggregate(ctx, pipeline)
-	if err != nil {
-		return 0, err
-	}
-
-	if !cursor.Next(ctx) {
-		return 0, nil
-	}
-
-	var result struct {
-		Count int `bson:"count"`
-	}
-	if err := cursor.Decode(&result); err != nil {
-		return 0, err
-	}
-
-	return result.Count, nil
-}
-
 // FindNearbyNonOfficialBehaviors returns non-official behaviors in the specified area.
 func (m *mongoDB) FindNearbyNonOfficialBehaviors(dist int, loc schema.Location) ([]schema.Behavior, error) {
 	distribution, err := m.FindBehaviorDistribution("", &loc, dist, 0, 9223372036854775807)
@@ -334,7 +332,8 @@ func (m *mongoDB) FindNearbyNonOfficialBehaviors(dist int, loc schema.Location)
 	return behaviors, nil
 }
 
-// GetBehaviorCount returns the number of reported behaviors for today and yesterday.
+// GetBehaviorCount returns the number of reported behaviors in the last 24 hours until `now`
+// and the 24 hours before.
 //
 // Either profileID of loc is required.
 // If profileID is provided, returned values are personal metrics.
@@ -360,19 +359,14 @@ func (m *mongoDB) GetBehaviorCount(profileID string, loc *schema.Location, dist
 		return 0, 0, errors.New("either profile ID or location not provided")
 	}
 
-	yesterdayStartAt, todayStartAt, tomorrowStartAt := getStartTimeOfConsecutiveDays(now)
+	previousStartAt, lastStartAt, endAt := getRollingWindows(now)
 
 	pipeline := []bson.M{
 		filter,
-		aggStageReportedBetween(yesterdayStartAt.Unix(), tomorrowStartAt.Unix()),
+		aggStageReportedBetween(previousStartAt.Unix(), endAt.Unix()),
 		{
 			"$project": bson.M{
-				"day": bson.M{
-					"$dateToString": bson.M{
-						"format": "%Y-%m-%d",
-						"date":   bson.M{"$toDate": bson.M{"$multiply": bson.A{"$ts", 1000}}},
-					},
-				},
+				"window": aggWindowOfReport(lastStartAt),
 				"count": bson.M{
 					"$add": bson.A{
 						bson.M{"$size": bson.M{"$ifNull": bson.A{"$official_behaviors", bson.A{}}}},
@@ -384,7 +378,7 @@ func (m *mongoDB) GetBehaviorCount(profileID string, loc *schema.Location, dist
 		},
 		{
 			"$group": bson.M{
-				"_id": "$day",
+				"_id": "$window",
 				"count": bson.M{
 					"$sum": "$count",
 				},
@@ -396,20 +390,18 @@ func (m *mongoDB) GetBehaviorCount(profileID string, loc *schema.Location, dist
 		return 0, 0, err
 	}
 	var aggItem struct {
-		Date  string `bson:"_id"`
-		Count int    `bson:"count"`
+		Window int `bson:"_id"`
+		Count  int `bson:"count"`
 	}
-	result := make(map[string]int)
+	result := make(map[int]int)
 	for cursor.Next(ctx) {
 		if err := cursor.Decode(&aggItem); err != nil {
 			return 0, 0, err
 		}
-		result[aggItem.Date] = aggItem.Count
+		result[aggItem.Window] = aggItem.Count
 	}
 
-	today := todayStartAt.Format("2006-01-02")
-	yesterday := yesterdayStartAt.Format("2006-01-02")
-	return result[today], result[yesterday], nil
+	return result[0], result[1], nil
 }
 
 // GetPersonalBehaviorTimeSeriesData returns the number of reported behaviors
@@ -508,3 +500,93 @@ func (m *mongoDB) GetPersonalBehaviorTimeSeriesData(profileID string, start, end
 	}
 	return results, nil
 }
+
+// embeddedBehaviorFields are the fields of each collection which embed behaviors
+var embeddedBehaviorFields = map[string]string{
+	schema.BehaviorReportCollection: "behaviors",
+	schema.ProfileCollection:        "customized_behavior",
+}
+
+// UpdateCustomizedBehavior edits the name and the description of a customized behavior.
+// Behaviors embedded in existing reports and profiles are updated as well.
+func (m *mongoDB) UpdateCustomizedBehavior(id, name, desc string) error {
+	if 0 == len(name) {
+		return errors.New("empty behavior")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	c := m.client.Database(m.database)
+
+	result, err := c.Collection(schema.BehaviorCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "source": schema.CustomizedBehavior},
+		bson.M{"$set": bson.M{"name": name, "desc": desc}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrBehaviorNotFound
+	}
+
+	for collection, field := range embeddedBehaviorFields {
+		if _, err := c.Collection(collection).UpdateMany(ctx,
+			bson.M{field + "._id": id},
+			bson.M{"$set": bson.M{field + ".$.name": name, field + ".$.desc": desc}},
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MergeCustomizedBehavior merges a customized behavior into another behavior.
+// Reports and profiles of the merged behavior are rewritten to the target one and the
+// merged behavior is removed.
+func (m *mongoDB) MergeCustomizedBehavior(fromID, toID string) error {
+	if fromID == toID {
+		return errors.New("can not merge a behavior into itself")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	c := m.client.Database(m.database)
+
+	var from, to schema.Behavior
+	if err := c.Collection(schema.BehaviorCollection).FindOne(ctx,
+		bson.M{"_id": fromID, "source": schema.CustomizedBehavior}).Decode(&from); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrBehaviorNotFound
+		}
+		return err
+	}
+
+	if err := c.Collection(schema.BehaviorCollection).FindOne(ctx, bson.M{"_id": toID}).Decode(&to); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrBehaviorNotFound
+		}
+		return err
+	}
+
+	for collection, field := range embeddedBehaviorFields {
+		// documents which have both behaviors only need to drop the merged one
+		if _, err := c.Collection(collection).UpdateMany(ctx,
+			bson.M{field + "._id": bson.M{"$all": bson.A{fromID, toID}}},
+			bson.M{"$pull": bson.M{field: bson.M{"_id": fromID}}},
+		); err != nil {
+			return err
+		}
+
+		if _, err := c.Collection(collection).UpdateMany(ctx,
+			bson.M{field + "._id": fromID},
+			bson.M{"$set": bson.M{field + ".$": to}},
+		); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.Collection(schema.BehaviorCollection).DeleteOne(ctx, bson.M{"_id": fromID})
+	return err
+}
@@ -168,37 +168,22 @@ func (s *BehaviorTestSuite) TestFindBehaviorDistribution() {
 	}, distribution)
 }
 
-func (s *BehaviorTestSuite) TestFindNearbyBehaviorReportTimes() {
-	store := NewMongoStore(s.mongoClient, s.testDBName)
-
-	start := time.Date(2020, 5, 26, 0, 0, 0, 0, time.UTC).UTC().Unix()
-	end := time.Date(2020, 5, 26, 24, 0, 0, 0, time.UTC).UTC().Unix()
-	count, err := store.FindNearbyBehaviorReportTimes(
-		s.neighborhoodRadius,
-		schema.Location{
-			Longitude: locationBitmark.Coordinates[0],
-			Latitude:  locationBitmark.Coordinates[1],
-		}, start, end)
-	s.NoError(err)
-	s.Equal(3, count)
-}
-
 func (s *BehaviorTestSuite) TestGetBehaviorCountForIndividual() {
 	store := NewMongoStore(s.mongoClient, s.testDBName)
 
-	now := time.Date(2020, 5, 25, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2020, 5, 25, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err := store.GetBehaviorCount("userA", nil, 0, now)
 	s.NoError(err)
 	s.Equal(2, todayCount)
 	s.Equal(0, yesterdayCount)
 
-	now = time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetBehaviorCount("userA", nil, 0, now)
 	s.NoError(err)
 	s.Equal(4, todayCount)
 	s.Equal(2, yesterdayCount)
 
-	now = time.Date(2020, 5, 27, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 27, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetBehaviorCount("userA", nil, 0, now)
 	s.NoError(err)
 	s.Equal(0, todayCount)
@@ -213,29 +198,51 @@ func (s *BehaviorTestSuite) TestGetBehaviorCountForCommunity() {
 		Latitude:  locationBitmark.Coordinates[1],
 	}
 
-	now := time.Date(2020, 5, 25, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2020, 5, 25, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err := store.GetBehaviorCount("", loc, s.neighborhoodRadius, now)
 	s.NoError(err)
 	s.Equal(2, todayCount)
 	s.Equal(0, yesterdayCount)
 
-	now = time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetBehaviorCount("", loc, s.neighborhoodRadius, now)
 	s.NoError(err)
 	s.Equal(6, todayCount)
 	s.Equal(2, yesterdayCount)
 
-	now = time.Date(2020, 5, 27, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 27, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetBehaviorCount("", loc, s.neighborhoodRadius, now)
 	s.NoError(err)
 	s.Equal(0, todayCount)
 	s.Equal(6, yesterdayCount)
 }
 
+func (s *BehaviorTestSuite) TestGetBehaviorCountAroundMidnight() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	loc := &schema.Location{
+		Longitude: locationBitmark.Coordinates[0],
+		Latitude:  locationBitmark.Coordinates[1],
+	}
+
+	// counts of rolling windows do not jump at midnight
+	beforeMidnight := time.Date(2020, 5, 26, 23, 0, 0, 0, time.UTC)
+	todayCount, yesterdayCount, err := store.GetBehaviorCount("", loc, s.neighborhoodRadius, beforeMidnight)
+	s.NoError(err)
+	s.Equal(6, todayCount)
+	s.Equal(2, yesterdayCount)
+
+	afterMidnight := time.Date(2020, 5, 27, 1, 0, 0, 0, time.UTC)
+	todayCount, yesterdayCount, err = store.GetBehaviorCount("", loc, s.neighborhoodRadius, afterMidnight)
+	s.NoError(err)
+	s.Equal(6, todayCount)
+	s.Equal(2, yesterdayCount)
+}
+
 func (s *BehaviorTestSuite) TestGetNearbyReportingBehaviorsUserCount() {
 	store := NewMongoStore(s.mongoClient, s.testDBName)
 
-	now := time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	count, countYesterday, err := store.GetNearbyReportingUserCount(
 		schema.ReportTypeBehavior,
 		s.neighborhoodRadius,
@@ -248,7 +255,7 @@ func (s *BehaviorTestSuite) TestGetNearbyReportingBehaviorsUserCount() {
 	s.Equal(2, count)
 	s.Equal(1, countYesterday)
 
-	now = time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	count, countYesterday, err = store.GetNearbyReportingUserCount(
 		schema.ReportTypeBehavior,
 		s.neighborhoodRadius,
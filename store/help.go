@@ -3,6 +3,7 @@ package store
 import (
 	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 
 	"github.com/bitmark-inc/autonomy-api/consts"
@@ -35,8 +36,11 @@ func (s *AutonomyStore) RequestHelp(accountNumber, subject, needs, meetingPlace,
 }
 
 // ListHelps first queries accounts within 50KM and returns lists of help
-// requests by those accounts
-func (s *AutonomyStore) ListHelps(accountNumber string, latitude, longitude float64, count int64) ([]schema.HelpRequest, error) {
+// requests by those accounts. Requests are sorted by their states and then the
+// distances of requesters, and `count` of them after the one of the cursor are
+// returned, or all of them if `count` is 0. The cursor is invalid if its request is
+// no longer listed.
+func (s *AutonomyStore) ListHelps(accountNumber string, latitude, longitude float64, after Cursor, count int64) ([]schema.HelpRequest, error) {
 	helps := []schema.HelpRequest{}
 
 	accounts, err := s.mongo.NearestDistance(consts.CORHORT_DISTANCE_RANGE, schema.Location{
@@ -47,16 +51,48 @@ func (s *AutonomyStore) ListHelps(accountNumber string, latitude, longitude floa
 		return nil, err
 	}
 
-	if err := s.ormDB.Raw(
-		`SELECT * FROM help_requests
+	listed := `WITH listed AS (
+		SELECT help_requests.*, account.index AS account_index FROM help_requests
 		JOIN unnest(?::text[]) WITH ORDINALITY account(requester, index) USING (requester)
 		WHERE (requester = ? OR helper = ? OR state = ?) AND created_at > now() - INTERVAL '12 hours'
-		ORDER BY state, account.index;`, // HARDCODED: 12 hours of expiration
+	)` // HARDCODED: 12 hours of expiration
+	args := []interface{}{
 		pq.Array(accounts),
 		accountNumber,
 		accountNumber,
 		schema.HELP_PENDING,
-	).Scan(&helps).Error; err != nil {
+	}
+
+	query := listed + ` SELECT * FROM listed`
+	if after.ID != "" {
+		id, err := uuid.Parse(after.ID)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+
+		var found struct {
+			Count int
+		}
+		if err := s.ormDB.Raw(listed+` SELECT count(*) AS count FROM listed WHERE id = ?;`, append(args, id)...).
+			Scan(&found).Error; err != nil {
+			return nil, err
+		}
+		if found.Count == 0 {
+			return nil, ErrInvalidCursor
+		}
+
+		query += ` WHERE (state, account_index, id) > (SELECT state, account_index, id FROM listed WHERE id = ?)`
+		args = append(args, id)
+	}
+
+	query += ` ORDER BY state, account_index, id`
+	if count > 0 {
+		query += ` LIMIT ?`
+		args = append(args, count)
+	}
+	query += `;`
+
+	if err := s.ormDB.Raw(query, args...).Scan(&helps).Error; err != nil {
 		return nil, err
 	}
 
@@ -115,3 +151,39 @@ func (s *AutonomyStore) ExpireHelps() error {
 		Where("state = ? AND created_at <= now() - interval '12 hours'", schema.HELP_PENDING).
 		Update("state", schema.HELP_EXPIRED).Error
 }
+
+// ExpireHelp expires a pending help request regardless of its age
+func (s *AutonomyStore) ExpireHelp(helpID string) error {
+	result := s.ormDB.Model(schema.HelpRequest{}).
+		Where("id = ? AND state = ?", helpID, schema.HELP_PENDING).
+		Update("state", schema.HELP_EXPIRED)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrRequestNotExist
+	}
+
+	return nil
+}
+
+// ListAccountHelps returns all help requests made or answered by an account
+func (s *AutonomyStore) ListAccountHelps(accountNumber string) ([]schema.HelpRequest, error) {
+	helps := []schema.HelpRequest{}
+	if err := s.ormDB.Where("requester = ? OR helper = ?", accountNumber, accountNumber).
+		Order("created_at").Find(&helps).Error; err != nil {
+		return nil, err
+	}
+	return helps, nil
+}
+
+// DeleteAccountHelps removes help requests made by an account and
+// anonymizes the helper of requests answered by the account
+func (s *AutonomyStore) DeleteAccountHelps(accountNumber string) error {
+	if err := s.ormDB.Delete(schema.HelpRequest{}, "requester = ?", accountNumber).Error; err != nil {
+		return err
+	}
+
+	return s.ormDB.Model(schema.HelpRequest{}).Where("helper = ?", accountNumber).Update("helper", "").Error
+}
@@ -4,17 +4,19 @@ import (
 	"context"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
 )
 
 type History interface {
-	GetReportedSymptoms(accountNumber string, earierThan, limit int64, lang string) ([]*schema.SymptomReportData, error)
-	GetReportedBehaviors(accountNumber string, earierThan, limit int64, lang string) ([]*schema.BehaviorReportData, error)
+	GetReportedSymptoms(accountNumber string, after Cursor, limit int64, lang string) ([]*schema.SymptomReportData, error)
+	GetReportedBehaviors(accountNumber string, after Cursor, limit int64, lang string) ([]*schema.BehaviorReportData, error)
 }
 
-func (m *mongoDB) GetReportedSymptoms(accountNumber string, earierThan, limit int64, lang string) ([]*schema.SymptomReportData, error) {
+// GetReportedSymptoms returns the latest symptom reports of an account after a cursor
+func (m *mongoDB) GetReportedSymptoms(accountNumber string, after Cursor, limit int64, lang string) ([]*schema.SymptomReportData, error) {
 	c := m.client.Database(m.database).Collection(schema.SymptomReportCollection)
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
@@ -29,10 +31,14 @@ func (m *mongoDB) GetReportedSymptoms(accountNumber string, earierThan, limit in
 		mapping[s.ID] = s
 	}
 
-	query, _ := historyQuery(accountNumber, earierThan, limit)
+	query, err := historyQuery(accountNumber, after)
+	if err != nil {
+		return nil, err
+	}
+
 	pipeline := []bson.M{
 		{"$match": query},
-		{"$sort": bson.M{"ts": -1}},
+		{"$sort": historySort},
 		{"$limit": limit},
 		{
 			"$project": bson.M{
@@ -75,7 +81,8 @@ func (m *mongoDB) GetReportedSymptoms(accountNumber string, earierThan, limit in
 	return reports, nil
 }
 
-func (m *mongoDB) GetReportedBehaviors(accountNumber string, earierThan, limit int64, lang string) ([]*schema.BehaviorReportData, error) {
+// GetReportedBehaviors returns the latest behavior reports of an account after a cursor
+func (m *mongoDB) GetReportedBehaviors(accountNumber string, after Cursor, limit int64, lang string) ([]*schema.BehaviorReportData, error) {
 	c := m.client.Database(m.database).Collection(schema.BehaviorReportCollection)
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
@@ -90,8 +97,12 @@ func (m *mongoDB) GetReportedBehaviors(accountNumber string, earierThan, limit i
 		mapping[b.ID] = b
 	}
 
-	query, options := historyQuery(accountNumber, earierThan, limit)
-	cur, err := c.Find(ctx, query, options)
+	query, err := historyQuery(accountNumber, after)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := c.Find(ctx, query, options.Find().SetSort(historySort).SetLimit(limit))
 	if err != nil {
 		return nil, err
 	}
@@ -116,12 +127,28 @@ func (m *mongoDB) GetReportedBehaviors(accountNumber string, earierThan, limit i
 	return reports, nil
 }
 
-func historyQuery(accountNumber string, earierThan, limit int64) (bson.M, *options.FindOptions) {
+// reports are sorted by ids after timestamps since many of them share a timestamp
+var historySort = bson.D{{"ts", -1}, {"_id", -1}}
+
+// historyQuery returns the query of reports after a cursor
+func historyQuery(accountNumber string, after Cursor) (bson.M, error) {
 	query := bson.M{
 		"account_number": accountNumber,
-		"ts":             bson.M{"$lt": earierThan},
 	}
-	options := options.Find()
-	options = options.SetSort(bson.M{"ts": -1}).SetLimit(limit)
-	return query, options
+
+	if after.ID == "" {
+		query["ts"] = bson.M{"$lt": after.Time.Unix()}
+		return query, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(after.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	query["$or"] = bson.A{
+		bson.M{"ts": bson.M{"$lt": after.Time.Unix()}},
+		bson.M{"ts": after.Time.Unix(), "_id": bson.M{"$lt": id}},
+	}
+	return query, nil
 }
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/utils"
+)
+
+// a point is not recorded if the account stays within the distance (in meters)
+const locationHistoryMinDistance = 100.0
+
+// LocationHistory - the trail of an account recorded from its geo-positions
+type LocationHistory interface {
+	SetLocationHistoryEnabled(accountNumber string, enabled bool) error
+	AppendLocationHistory(record schema.LocationHistory, minInterval time.Duration) (bool, error)
+	GetLocationHistory(accountNumber string, before time.Time, limit int64) ([]schema.LocationHistory, error)
+	DeleteLocationHistory(accountNumber string) (int64, error)
+}
+
+// SetLocationHistoryEnabled opts an account in or out the recording of its trail
+func (m *mongoDB) SetLocationHistoryEnabled(accountNumber string, enabled bool) error {
+	c := m.client.Database(m.database).Collection(schema.ProfileCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	result, err := c.UpdateOne(ctx,
+		bson.M{"account_number": accountNumber},
+		bson.M{"$set": bson.M{"location_history_enabled": enabled}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errAccountNotFound
+	}
+
+	return nil
+}
+
+// AppendLocationHistory records a point if the account opts in. To keep the trail
+// compact, a point is skipped if the previous point is recorded within `minInterval`
+// and is close to it. It returns whether the point is recorded.
+func (m *mongoDB) AppendLocationHistory(record schema.LocationHistory, minInterval time.Duration) (bool, error) {
+	db := m.client.Database(m.database)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var profile schema.Profile
+	if err := db.Collection(schema.ProfileCollection).FindOne(ctx,
+		bson.M{"account_number": record.AccountNumber},
+		options.FindOne().SetProjection(bson.M{"location_history_enabled": 1}),
+	).Decode(&profile); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !profile.LocationHistoryEnabled {
+		return false, nil
+	}
+
+	c := db.Collection(schema.LocationHistoryCollection)
+
+	var last schema.LocationHistory
+	err := c.FindOne(ctx,
+		bson.M{"account_number": record.AccountNumber},
+		options.FindOne().SetSort(bson.M{"recorded_at": -1}),
+	).Decode(&last)
+	switch err {
+	case nil:
+		distance := utils.GreatCircleDistance(
+			last.Location.Coordinates[1], last.Location.Coordinates[0],
+			record.Location.Coordinates[1], record.Location.Coordinates[0])
+		if record.RecordedAt.Sub(last.RecordedAt) < minInterval && distance < locationHistoryMinDistance {
+			return false, nil
+		}
+	case mongo.ErrNoDocuments:
+	default:
+		return false, err
+	}
+
+	if _, err := c.InsertOne(ctx, record); err != nil {
+		log.WithError(err).WithField("prefix", mongoLogPrefix).Error("fail to record location history")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetLocationHistory returns the latest points recorded before a given time
+func (m *mongoDB) GetLocationHistory(accountNumber string, before time.Time, limit int64) ([]schema.LocationHistory, error) {
+	c := m.client.Database(m.database).Collection(schema.LocationHistoryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	cursor, err := c.Find(ctx,
+		bson.M{
+			"account_number": accountNumber,
+			"recorded_at":    bson.M{"$lt": before},
+		},
+		options.Find().SetSort(bson.M{"recorded_at": -1}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]schema.LocationHistory, 0)
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// DeleteLocationHistory removes the whole trail of an account
+func (m *mongoDB) DeleteLocationHistory(accountNumber string) (int64, error) {
+	c := m.client.Database(m.database).Collection(schema.LocationHistoryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	result, err := c.DeleteMany(ctx, bson.M{"account_number": accountNumber})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+type LocationHistoryTestSuite struct {
+	suite.Suite
+	connURI      string
+	testDBName   string
+	mongoClient  *mongo.Client
+	testDatabase *mongo.Database
+}
+
+func NewLocationHistoryTestSuite(connURI, dbName string) *LocationHistoryTestSuite {
+	return &LocationHistoryTestSuite{
+		connURI:    connURI,
+		testDBName: dbName,
+	}
+}
+
+func (s *LocationHistoryTestSuite) SetupSuite() {
+	if s.connURI == "" || s.testDBName == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	if err = mongoClient.Connect(context.Background()); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+	s.testDatabase = mongoClient.Database(s.testDBName)
+
+	// make sure the test suite is run with a clean environment
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+	if err := schema.NewMongoDBIndexer(s.connURI, s.testDBName).IndexLocationHistoryCollection(); err != nil {
+		s.T().Fatal(err)
+	}
+
+	if _, err := s.testDatabase.Collection(schema.ProfileCollection).InsertMany(context.Background(), []interface{}{
+		schema.Profile{ID: "location-history-enabled", AccountNumber: "account-location-enabled", LocationHistoryEnabled: true},
+		schema.Profile{ID: "location-history-disabled", AccountNumber: "account-location-disabled"},
+	}); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+// CleanMongoDB drop the whole test mongodb
+func (s *LocationHistoryTestSuite) CleanMongoDB() error {
+	return s.testDatabase.Drop(context.Background())
+}
+
+func (s *LocationHistoryTestSuite) TearDownSuite() {
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func (s *LocationHistoryTestSuite) point(accountNumber string, lat, long float64, at time.Time) schema.LocationHistory {
+	return schema.LocationHistory{
+		AccountNumber: accountNumber,
+		Location:      schema.GeoJSON{Type: "Point", Coordinates: []float64{long, lat}},
+		RecordedAt:    at,
+		ExpiresAt:     at.Add(time.Hour),
+	}
+}
+
+func (s *LocationHistoryTestSuite) TestAppendLocationHistory() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	recorded, err := store.AppendLocationHistory(s.point("account-location-enabled", 25.0, 121.5, now), 5*time.Minute)
+	s.NoError(err)
+	s.True(recorded)
+
+	// the account stays still
+	recorded, err = store.AppendLocationHistory(s.point("account-location-enabled", 25.0001, 121.5, now.Add(time.Minute)), 5*time.Minute)
+	s.NoError(err)
+	s.False(recorded)
+
+	// the account moves
+	recorded, err = store.AppendLocationHistory(s.point("account-location-enabled", 25.01, 121.5, now.Add(2*time.Minute)), 5*time.Minute)
+	s.NoError(err)
+	s.True(recorded)
+
+	// the account stays still for a long time
+	recorded, err = store.AppendLocationHistory(s.point("account-location-enabled", 25.01, 121.5, now.Add(10*time.Minute)), 5*time.Minute)
+	s.NoError(err)
+	s.True(recorded)
+
+	// the account does not opt in
+	recorded, err = store.AppendLocationHistory(s.point("account-location-disabled", 25.0, 121.5, now), 5*time.Minute)
+	s.NoError(err)
+	s.False(recorded)
+
+	records, err := store.GetLocationHistory("account-location-enabled", now.Add(time.Hour), 10)
+	s.NoError(err)
+	s.Len(records, 3)
+	s.Equal(now.Add(10*time.Minute), records[0].RecordedAt)
+
+	records, err = store.GetLocationHistory("account-location-enabled", now.Add(10*time.Minute), 1)
+	s.NoError(err)
+	s.Len(records, 1)
+	s.Equal(now.Add(2*time.Minute), records[0].RecordedAt)
+
+	records, err = store.GetLocationHistory("account-location-disabled", now.Add(time.Hour), 10)
+	s.NoError(err)
+	s.Len(records, 0)
+}
+
+func (s *LocationHistoryTestSuite) TestSetLocationHistoryEnabledAndDelete() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s.NoError(store.SetLocationHistoryEnabled("account-location-disabled", true))
+	recorded, err := store.AppendLocationHistory(s.point("account-location-disabled", 25.0, 121.5, now), time.Minute)
+	s.NoError(err)
+	s.True(recorded)
+
+	s.NoError(store.SetLocationHistoryEnabled("account-location-disabled", false))
+	recorded, err = store.AppendLocationHistory(s.point("account-location-disabled", 26.0, 121.5, now.Add(time.Hour)), time.Minute)
+	s.NoError(err)
+	s.False(recorded)
+
+	deleted, err := store.DeleteLocationHistory("account-location-disabled")
+	s.NoError(err)
+	s.Equal(int64(1), deleted)
+
+	s.Equal(errAccountNotFound, store.SetLocationHistoryEnabled("account-not-exist", true))
+}
+
+func TestLocationHistoryTestSuite(t *testing.T) {
+	suite.Run(t, NewLocationHistoryTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled", "test-location-history"))
+}
@@ -16,53 +16,50 @@ const (
 	metricUpdateInterval = 5 * time.Minute
 )
 
+// timeNow returns the time at which reports are weighted and counted for metrics
+var timeNow = time.Now
+
 type Metric interface {
 	CollectRawMetrics(location schema.Location) (*schema.Metric, error)
 	SyncProfileIndividualMetrics(profileID string) (*schema.IndividualMetric, error)
 	SyncAccountMetrics(accountNumber string, coefficient *schema.ScoreCoefficient, location schema.Location) (*schema.Metric, error)
 	SyncAccountPOIMetrics(accountNumber string, coefficient *schema.ScoreCoefficient, poiID primitive.ObjectID) (*schema.Metric, error)
-	SyncPOIMetrics(poiID primitive.ObjectID, resourceRating []schema.POIResourceRating, location schema.Location) (*schema.Metric, error)
+	SyncPOIMetrics(poiID primitive.ObjectID, placeType string, resourceRating []schema.POIResourceRating, location schema.Location) (*schema.Metric, error)
 }
 
 // CollectRawMetrics will gather data from various of sources that is required to
 // calculate an autonomy score
 func (m *mongoDB) CollectRawMetrics(location schema.Location) (*schema.Metric, error) {
-	now := time.Now().UTC()
-	todayStartAt := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-
-	yesterdayStartAtUnix := todayStartAt.AddDate(0, 0, -1).Unix()
-	todayStartAtUnix := todayStartAt.Unix()
-	tomorrowStartAtUnix := todayStartAt.AddDate(0, 0, 1).Unix()
-
-	behaviorDistrToday, err := m.FindBehaviorDistribution("", &location, consts.NEARBY_DISTANCE_RANGE, todayStartAtUnix, tomorrowStartAtUnix)
-	if err != nil {
-		return nil, err
-	}
-	behaviorDistrYesterday, err := m.FindBehaviorDistribution("", &location, consts.NEARBY_DISTANCE_RANGE, yesterdayStartAtUnix, todayStartAtUnix)
-	if err != nil {
-		return nil, err
-	}
-	behaviorReportTimes, err := m.FindNearbyBehaviorReportTimes(consts.NEARBY_DISTANCE_RANGE, location, todayStartAtUnix, tomorrowStartAtUnix)
-	if err != nil {
-		return nil, err
-	}
-	behaviorReportTimesYesterday, err := m.FindNearbyBehaviorReportTimes(consts.NEARBY_DISTANCE_RANGE, location, yesterdayStartAtUnix, todayStartAtUnix)
-	if err != nil {
-		return nil, err
+	now := timeNow().UTC()
+
+	// reports are weighted by their ages at now and a day ago, so scores change continuously
+	// instead of at the boundary of days. counts of reports in the last 24 hours and the 24
+	// hours before are kept for spike detection and explanation of changes, and behaviors are
+	// counted from the same reports.
+	dayAgo := now.Add(-score.ComparisonPeriod)
+	twoDaysAgo := dayAgo.Add(-score.ComparisonPeriod)
+	reportsStartAt := score.ReportsStartAt(now)
+	if reportsStartAt.After(twoDaysAgo) {
+		reportsStartAt = twoDaysAgo
 	}
+	reportsStartAtUnix := reportsStartAt.Unix()
 
-	symptomDistToday, err := m.FindSymptomDistribution("", &location, consts.NEARBY_DISTANCE_RANGE, todayStartAtUnix, tomorrowStartAtUnix, true)
-	if err != nil {
-		return nil, err
-	}
-	symptomDistYesterday, err := m.FindSymptomDistribution("", &location, consts.NEARBY_DISTANCE_RANGE, yesterdayStartAtUnix, todayStartAtUnix, true)
+	behaviorReports, err := m.FindNearbyReports(schema.ReportTypeBehavior, consts.NEARBY_DISTANCE_RANGE, location, reportsStartAtUnix, now.Unix()+1)
 	if err != nil {
 		return nil, err
 	}
-	symptomUserCountToday, symptomUserCountYesterday, err := m.GetNearbyReportingUserCount(schema.ReportTypeSymptom, consts.NEARBY_DISTANCE_RANGE, location, now)
+	behaviorReportTimes, behaviorDistr := score.DecayBehaviorReports(behaviorReports, now)
+	behaviorReportTimesYesterday, behaviorDistrYesterday := score.DecayBehaviorReports(behaviorReports, dayAgo)
+
+	behaviorCountToday := score.CountBehaviorReports(behaviorReports, dayAgo, now.Add(time.Second))
+	behaviorCountYesterday := score.CountBehaviorReports(behaviorReports, twoDaysAgo, dayAgo)
+
+	symptomReports, err := m.FindNearbyReports(schema.ReportTypeSymptom, consts.NEARBY_DISTANCE_RANGE, location, reportsStartAtUnix, now.Unix()+1)
 	if err != nil {
 		return nil, err
 	}
+	symptomPeople, symptomDistr := score.DecaySymptomReports(symptomReports, now)
+	symptomPeopleYesterday, symptomDistrYesterday := score.DecaySymptomReports(symptomReports, dayAgo)
 
 	if location.Country == "" {
 		log.Info("fetch poi geo info from external service")
@@ -74,6 +71,18 @@ func (m *mongoDB) CollectRawMetrics(location schema.Location) (*schema.Metric, e
 		}
 	}
 
+	// symptom spikes are detected against the baseline of the 24-hour windows before the last one
+	spikeConfig := score.SpikeConfigOf(location.AddressComponent)
+	symptomDists, err := m.FindDailySymptomDistributions(location, consts.NEARBY_DISTANCE_RANGE, now, spikeConfig.BaselineDays+1)
+	if err != nil {
+		return nil, err
+	}
+	symptomDistToday, symptomDistYesterday := symptomDists[0], symptomDists[1]
+	symptomBaseline := make([]schema.NearestSymptomData, spikeConfig.BaselineDays)
+	for i := range symptomBaseline {
+		symptomBaseline[i].WeightDistribution = symptomDists[spikeConfig.BaselineDays-i]
+	}
+
 	// Processing confirmed case data
 	activeCount, activeDiff, activeDiffPercent, err := m.GetCDSActive(location, now.Unix())
 	if err == ErrNoConfirmDataset || err == ErrInvalidConfirmDataset || err == ErrPoliticalTypeGeoInfo {
@@ -111,28 +120,50 @@ func (m *mongoDB) CollectRawMetrics(location schema.Location) (*schema.Metric, e
 		log.WithFields(log.Fields{"prefix": mongoLogPrefix, "activeCount": activeCount, "activeDiff": activeDiff, "activeDiffPercent": activeDiffPercent}).Debug("confirm info")
 	}
 
+	// confirm scores fall back to raw case counts if the population is unknown
+	population, err := m.GetPopulation(location)
+	if err == ErrPopulationNotFound {
+		log.WithFields(log.Fields{
+			"prefix":   mongoLogPrefix,
+			"location": location,
+		}).Warn("no population for confirmed cases")
+	} else if err != nil {
+		log.WithFields(log.Fields{
+			"prefix": mongoLogPrefix,
+			"error":  err,
+		}).Error("population info")
+		return nil, err
+	}
+
 	return &schema.Metric{
 		ConfirmedCount: activeCount,
 		ConfirmedDelta: activeDiffPercent,
 		Details: schema.Details{
 			Confirm: schema.ConfirmDetail{
 				ContinuousData: confirmData,
+				Population:     population,
 			},
 			Symptoms: schema.SymptomDetail{
-				TotalPeople:          float64(symptomUserCountToday),
-				TotalPeopleYesterday: float64(symptomUserCountYesterday),
+				TotalPeople:           symptomPeople,
+				TotalPeopleYesterday:  symptomPeopleYesterday,
+				Distribution:          symptomDistr,
+				DistributionYesterday: symptomDistrYesterday,
 				TodayData: schema.NearestSymptomData{
 					WeightDistribution: symptomDistToday,
 				},
 				YesterdayData: schema.NearestSymptomData{
 					WeightDistribution: symptomDistYesterday,
 				},
+				BaselineData: symptomBaseline,
+				SpikeConfig:  spikeConfig,
 			},
 			Behaviors: schema.BehaviorDetail{
 				ReportTimes:           behaviorReportTimes,
 				ReportTimesYesterday:  behaviorReportTimesYesterday,
-				TodayDistribution:     behaviorDistrToday,
-				YesterdayDistribution: behaviorDistrYesterday,
+				Distribution:          behaviorDistr,
+				DistributionYesterday: behaviorDistrYesterday,
+				TodayDistribution:     behaviorCountToday,
+				YesterdayDistribution: behaviorCountYesterday,
 			},
 		},
 	}, nil
@@ -140,7 +171,7 @@ func (m *mongoDB) CollectRawMetrics(location schema.Location) (*schema.Metric, e
 
 // SyncProfileIndividualMetrics calculate individual metrics and save into profile
 func (m *mongoDB) SyncProfileIndividualMetrics(profileID string) (*schema.IndividualMetric, error) {
-	now := time.Now().UTC()
+	now := timeNow().UTC()
 
 	symptomsToday, symptomsYesterday, err := m.GetSymptomCount(profileID, nil, 0, now)
 	if err != nil {
@@ -197,7 +228,7 @@ func (m *mongoDB) SyncAccountMetrics(accountNumber string, coefficient *schema.S
 		"raw_metrics":    rawMetrics,
 	}).Debug("collect raw metrics")
 
-	metric := score.CalculateMetric(*rawMetrics, coefficient)
+	metric := score.CalculateMetric(score.AccountFormula(accountNumber), *rawMetrics, coefficient)
 
 	if err := m.UpdateProfileMetric(accountNumber, metric); err != nil {
 		return nil, err
@@ -249,7 +280,7 @@ func (m *mongoDB) SyncAccountPOIMetrics(accountNumber string, coefficient *schem
 				"raw_metrics":    rawMetrics,
 			}).Debug("collect raw metrics")
 
-			metric := score.CalculateMetric(*rawMetrics, coefficient)
+			metric := score.CalculateMetric(score.AccountFormula(accountNumber), *rawMetrics, coefficient)
 
 			if err := m.UpdateProfilePOIMetric(accountNumber, poiID, metric); err != nil {
 				return nil, err
@@ -262,18 +293,18 @@ func (m *mongoDB) SyncAccountPOIMetrics(accountNumber string, coefficient *schem
 }
 
 // FIXME: should not update autonomy score when syncing metrics
-func (m *mongoDB) SyncPOIMetrics(poiID primitive.ObjectID, resourceRating []schema.POIResourceRating, location schema.Location) (*schema.Metric, error) {
+func (m *mongoDB) SyncPOIMetrics(poiID primitive.ObjectID, placeType string, resourceRating []schema.POIResourceRating, location schema.Location) (*schema.Metric, error) {
 	rawMetrics, err := m.CollectRawMetrics(location)
 	if err != nil {
 		return nil, err
 	}
 
-	metric := score.CalculateMetric(*rawMetrics, nil)
+	metric := score.CalculateMetric(score.DefaultFormula(), *rawMetrics, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	autonomyScore, _, autonomyScoreDelta := score.CalculatePOIAutonomyScore(resourceRating, metric)
+	autonomyScore, _, autonomyScoreDelta := score.CalculatePOIAutonomyScore(placeType, resourceRating, metric)
 
 	if err := m.UpdatePOIMetric(poiID, metric, autonomyScore, autonomyScoreDelta); err != nil {
 		return nil, err
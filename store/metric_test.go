@@ -12,6 +12,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
 )
 
 var (
@@ -33,6 +34,9 @@ var (
 	}
 )
 
+// metricTestNow is the time at which metrics are collected in tests, so that reports have fixed ages
+var metricTestNow = time.Date(2020, 6, 2, 12, 0, 0, 0, time.UTC)
+
 var (
 	metricTestSymptomReport1 = schema.SymptomReportData{
 		ProfileID: "test-account-profile-id",
@@ -41,7 +45,7 @@ var (
 			{ID: "fever"},
 		},
 		Location:  locationNangangTrainStation,
-		Timestamp: time.Now().Add(-24 * time.Hour).Unix(),
+		Timestamp: metricTestNow.Add(-24 * time.Hour).Unix(),
 	}
 	metricTestSymptomReport2 = schema.SymptomReportData{
 		ProfileID: "test-account-profile-id",
@@ -50,7 +54,7 @@ var (
 			{ID: "fever"},
 		},
 		Location:  locationNangangTrainStation,
-		Timestamp: time.Now().Add(-23 * time.Hour).Unix(),
+		Timestamp: metricTestNow.Add(-23 * time.Hour).Unix(),
 	}
 	metricTestSymptomReport3 = schema.SymptomReportData{
 		ProfileID: "test-account-profile-id",
@@ -58,7 +62,7 @@ var (
 			{ID: "cough"},
 		},
 		Location:  locationNangangTrainStation,
-		Timestamp: time.Now().Unix(),
+		Timestamp: metricTestNow.Unix(),
 	}
 
 	metricTestBehaviorReport1 = schema.BehaviorReportData{
@@ -68,7 +72,7 @@ var (
 			{ID: "social_distancing"},
 		},
 		Location:  locationNangangTrainStation,
-		Timestamp: time.Now().Add(-24 * time.Hour).Unix(),
+		Timestamp: metricTestNow.Add(-24 * time.Hour).Unix(),
 	}
 	metricTestBehaviorReport2 = schema.BehaviorReportData{
 		ProfileID: "test-account-profile-id",
@@ -77,7 +81,7 @@ var (
 			{ID: "social_distancing"},
 		},
 		Location:  locationNangangTrainStation,
-		Timestamp: time.Now().Add(-23 * time.Hour).Unix(),
+		Timestamp: metricTestNow.Add(-23 * time.Hour).Unix(),
 	}
 	metricTestBehaviorReport3 = schema.BehaviorReportData{
 		ProfileID: "test-account-profile-id",
@@ -86,7 +90,7 @@ var (
 			{ID: "social_distancing"},
 		},
 		Location:  locationNangangTrainStation,
-		Timestamp: time.Now().Unix(),
+		Timestamp: metricTestNow.Unix(),
 	}
 )
 
@@ -122,6 +126,7 @@ func (s *MetricTestSuite) SetupSuite() {
 
 	s.mongoClient = mongoClient
 	s.testDatabase = mongoClient.Database(s.testDBName)
+	timeNow = func() time.Time { return metricTestNow }
 
 	// make sure the test suite is run with a clean environment
 	if err := s.CleanMongoDB(); err != nil {
@@ -133,6 +138,10 @@ func (s *MetricTestSuite) SetupSuite() {
 	}
 }
 
+func (s *MetricTestSuite) TearDownSuite() {
+	timeNow = time.Now
+}
+
 // LoadMongoDBFixtures will preload fixtures into test mongodb
 func (s *MetricTestSuite) LoadMongoDBFixtures() error {
 	ctx := context.Background()
@@ -229,6 +238,32 @@ func (s *MetricTestSuite) TestSyncProfileIndividualMetricsWithoutData() {
 	s.Equal(profile.IndividualMetric.BehaviorDelta, m.BehaviorDelta)
 }
 
+// assertMetricTestReports checks the fixture reports are weighted by their ages at the test
+// time and a day before
+func (s *MetricTestSuite) assertMetricTestReports(m *schema.Metric) {
+	w := func(age time.Duration) float64 {
+		return score.DecayWeight(score.CurrentDecayConfig(), age)
+	}
+
+	// symptoms count by the latest reports of the profile
+	symptoms := m.Details.Symptoms
+	s.InDelta(w(0), symptoms.TotalPeople, 1e-12)
+	s.InDelta(w(0), symptoms.Distribution["cough"], 1e-12)
+	s.InDelta(w(23*time.Hour), symptoms.Distribution["fever"], 1e-12)
+
+	// reports after a day ago are not known then
+	s.InDelta(w(0), symptoms.TotalPeopleYesterday, 1e-12)
+	s.InDelta(w(0), symptoms.DistributionYesterday["cough"], 1e-12)
+	s.InDelta(w(0), symptoms.DistributionYesterday["fever"], 1e-12)
+
+	// every behavior report counts
+	behaviors := m.Details.Behaviors
+	s.InDelta(w(24*time.Hour)+w(23*time.Hour)+w(0), behaviors.ReportTimes, 1e-12)
+	s.InDelta(w(24*time.Hour)+w(23*time.Hour)+w(0), behaviors.Distribution["clean_hand"], 1e-12)
+	s.InDelta(w(0), behaviors.ReportTimesYesterday, 1e-12)
+	s.InDelta(w(0), behaviors.DistributionYesterday["social_distancing"], 1e-12)
+}
+
 // TestSyncPOIMetrics tests if data saves into mongodb
 func (s *MetricTestSuite) TestSyncPOIMetrics() {
 	store := NewMongoStore(s.mongoClient, s.testDBName)
@@ -257,7 +292,7 @@ func (s *MetricTestSuite) TestSyncPOIMetrics() {
 	s.NoError(err)
 	s.Equal(poiBefore.Score, 0.0)
 
-	_, err = store.SyncPOIMetrics(testMetricPOIID, resourceRating, schema.Location{
+	m, err := store.SyncPOIMetrics(testMetricPOIID, "", resourceRating, schema.Location{
 		AddressComponent: schema.AddressComponent{
 			Country: "Taiwan",
 		},
@@ -265,13 +300,15 @@ func (s *MetricTestSuite) TestSyncPOIMetrics() {
 		Latitude:  locationNangangTrainStation.Coordinates[1],
 	})
 	s.NoError(err)
+	s.assertMetricTestReports(m)
 
 	var poiAfter schema.POI
 	err = s.testDatabase.Collection(schema.POICollection).FindOne(context.Background(), bson.M{
 		"_id": testMetricPOIID,
 	}).Decode(&poiAfter)
 	s.NoError(err)
-	s.Equal(poiAfter.Score, 85.83333333333333)
+	poiScore, _, _ := score.CalculatePOIAutonomyScore("", resourceRating, *m)
+	s.Equal(poiScore, poiAfter.Score)
 }
 
 // TestSyncPOIMetricsWihtoutRating tests if data saves into mongodb
@@ -287,7 +324,7 @@ func (s *MetricTestSuite) TestSyncPOIMetricsWihtoutRating() {
 	s.NoError(err)
 	s.Equal(poiBefore.Score, 0.0)
 
-	_, err = store.SyncPOIMetrics(testMetricPOIID, resourceRating, schema.Location{
+	m, err := store.SyncPOIMetrics(testMetricPOIID, "", resourceRating, schema.Location{
 		AddressComponent: schema.AddressComponent{
 			Country: "Taiwan",
 		},
@@ -295,13 +332,15 @@ func (s *MetricTestSuite) TestSyncPOIMetricsWihtoutRating() {
 		Latitude:  locationNangangTrainStation.Coordinates[1],
 	})
 	s.NoError(err)
+	s.assertMetricTestReports(m)
 
+	// the score of a POI without ratings is the one of its neighborhood
 	var poiAfter schema.POI
 	err = s.testDatabase.Collection(schema.POICollection).FindOne(context.Background(), bson.M{
 		"_id": testMetricPOIID,
 	}).Decode(&poiAfter)
 	s.NoError(err)
-	s.Equal(poiAfter.Score, 29.16666666666667)
+	s.Equal(m.Score, poiAfter.Score)
 }
 
 func TestMetricTestSuite(t *testing.T) {
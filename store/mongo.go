@@ -27,10 +27,18 @@ type MongoStore interface {
 	History
 	Metric
 	ConfirmCDS
+	Population
 	Report
 	Guide
 	ScoreHistory
 	Suggestion
+	Token
+	Audit
+	AccountExport
+	AccountDeletion
+	RateLimit
+	LocationHistory
+	ProfileWatcher
 }
 
 // Closer - close db connection
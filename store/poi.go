@@ -18,6 +18,7 @@ import (
 
 	"github.com/bitmark-inc/autonomy-api/geo"
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
 	"github.com/bitmark-inc/autonomy-api/utils"
 )
 
@@ -50,11 +51,7 @@ var defaultResourceIDMap = map[string]map[string]string{}
 
 // LoadDefaultPOIResources loads resources from the tranlation list and cache it for later usage.
 func LoadDefaultPOIResources(lang string) error {
-	if lang == "" {
-		lang = "en"
-	}
-
-	lang = strings.ReplaceAll(strings.ToLower(lang), "-", "_")
+	lang = utils.ResolveLanguage(lang, "")
 
 	if _, ok := defaultResourceList[lang]; ok {
 		return nil
@@ -92,11 +89,7 @@ func LoadDefaultPOIResources(lang string) error {
 
 // ResolveResourceNameByID returns the name of a given resource id by languages
 func ResolveResourceNameByID(id, lang string) (string, error) {
-	lang = strings.ReplaceAll(strings.ToLower(lang), "-", "_")
-
-	if lang == "zh" {
-		lang = "zh_tw"
-	}
+	lang = utils.ResolveLanguage(lang, "")
 
 	m, ok := defaultResourceIDMap[lang]
 	if !ok {
@@ -111,11 +104,7 @@ func ResolveResourceNameByID(id, lang string) (string, error) {
 
 // getResourceList returns a list of resource list by language.
 func getResourceList(lang string, important bool) ([]schema.Resource, error) {
-	lang = strings.ReplaceAll(strings.ToLower(lang), "-", "_")
-
-	if lang == "zh" {
-		lang = "zh_tw"
-	}
+	lang = utils.ResolveLanguage(lang, "")
 
 	resourceList := defaultResourceList
 	if important {
@@ -153,6 +142,7 @@ type POI interface {
 	GetPOIResources(poiID primitive.ObjectID, importantOnly, includeAdded bool, lang string) ([]schema.Resource, error)
 	GetPOIResourceMetric(poiID primitive.ObjectID) (schema.POIRatingsMetric, error)
 	UpdatePOIRatingMetric(accountNumber string, poiID primitive.ObjectID, ratings []schema.RatingResource) error
+	RemovePOIRatings(accountNumber string) ([]primitive.ObjectID, error)
 }
 
 // AddPOI inserts a new POI record if it doesn't exist and append it to user's profile
@@ -225,7 +215,7 @@ func (m *mongoDB) AddPOI(alias, address, placeType string, lon, lat float64) (*s
 	}
 
 	if time.Since(time.Unix(poi.Metric.LastUpdate, 0)) > metricUpdateInterval {
-		newMetric, err := m.SyncPOIMetrics(poi.ID, poi.ResourceRatings.Resources, schema.Location{
+		newMetric, err := m.SyncPOIMetrics(poi.ID, poi.PlaceType, poi.ResourceRatings.Resources, schema.Location{
 			Latitude:  lat,
 			Longitude: lon,
 			AddressComponent: schema.AddressComponent{
@@ -326,6 +316,7 @@ func (m *mongoDB) ListPOI(accountNumber string) ([]schema.POIDetail, error) {
 // ListPOIByResource returns all POI that satisfied following conditions:
 // - the place has rated for a given resource.
 // - the distance between the place and a given coordinates is within 50000m
+// The resource score of a place is the Bayesian average of ratings of the resource.
 func (m *mongoDB) ListPOIByResource(resourceID string, coordinates schema.Location) ([]schema.POI, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
@@ -344,11 +335,10 @@ func (m *mongoDB) ListPOIByResource(resourceID string, coordinates schema.Locati
 			"resource_ratings.resources.ratings":     bson.M{"$gt": 0},
 		}),
 		AggregationAddFields(bson.M{
-			"resource_score": "$resource_ratings.resources.score",
+			"resource_ratings.resources": bson.A{"$resource_ratings.resources"},
 		}),
 		AggregationProject(bson.M{
-			"resource_ratings": 0,
-			"metric":           0,
+			"metric": 0,
 		}),
 	})
 	if err != nil {
@@ -360,6 +350,16 @@ func (m *mongoDB) ListPOIByResource(resourceID string, coordinates schema.Locati
 		return nil, err
 	}
 
+	now := time.Now()
+	for i, p := range pois {
+		for _, r := range p.ResourceRatings.Resources {
+			resourceScore, confidence := score.SmoothRating(p.PlaceType, r, now)
+			pois[i].ResourceScore = &resourceScore
+			pois[i].ResourceConfidence = &confidence
+		}
+		pois[i].ResourceRatings = schema.POIRatingsMetric{}
+	}
+
 	return pois, nil
 }
 
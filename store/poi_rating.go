@@ -7,6 +7,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
@@ -23,7 +24,7 @@ func (m *mongoDB) GetPOIResourceMetric(poiID primitive.ObjectID) (schema.POIRati
 		"_id": poiID,
 	}
 	var result schema.POI
-	err := c.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"resource_ratings": 1})).Decode(&result)
+	err := c.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"resource_ratings": 1, "place_type": 1})).Decode(&result)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"prefix": mongoLogPrefix,
@@ -32,9 +33,19 @@ func (m *mongoDB) GetPOIResourceMetric(poiID primitive.ObjectID) (schema.POIRati
 		}).Error("get poi fail")
 		return schema.POIRatingsMetric{}, err
 	}
+	result.ResourceRatings.PlaceType = result.PlaceType
 	return result.ResourceRatings, nil
 }
 
+// ratedAt returns the time a resource was rated by an account. Ratings saved before the
+// time of each resource was kept count as they were rated at the last update of the profile.
+func ratedAt(r schema.RatingResource, m schema.ProfileRatingsMetric) int64 {
+	if r.RatedAt == 0 {
+		return m.LastUpdate
+	}
+	return r.RatedAt
+}
+
 func (m *mongoDB) UpdatePOIRatingMetric(accountNumber string, poiID primitive.ObjectID, ratings []schema.RatingResource) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
@@ -64,17 +75,8 @@ func (m *mongoDB) UpdatePOIRatingMetric(accountNumber string, poiID primitive.Ob
 	}
 
 	now := time.Now().UTC()
-	todayStartAt := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	poiResourceMap := make(map[string]schema.POIResourceRating)
 	for _, r := range poiMetric.Resources { // make a current poi resources map
-		old, ok := poiResourceMap[r.Resource.ID]
-		if ok {
-			if old.LastUpdate < todayStartAt.Unix() {
-				r.LastDayScore = old.Score
-				r.LastDayRatings = old.Ratings
-			}
-		}
-		r.LastUpdate = now.Unix()
 		poiResourceMap[r.Resource.ID] = r
 	}
 	profileResourceMap := make(map[string]schema.RatingResource)
@@ -96,17 +98,18 @@ func (m *mongoDB) UpdatePOIRatingMetric(accountNumber string, poiID primitive.Ob
 		}
 		count, sum, average := score.ResourceScore(existPOIRating, r, existProfileRating, update)
 
-		//	count, sum, average := score.ResourceScore(val.Ratings, val.SumOfScore, r,)
-
-		poiResourceMap[r.Resource.ID] = schema.POIResourceRating{
-			Resource:       r.Resource,
-			SumOfScore:     sum,
-			Score:          average,
-			Ratings:        count,
-			LastUpdate:     existPOIRating.LastUpdate,
-			LastDayScore:   existPOIRating.LastDayScore,
-			LastDayRatings: existPOIRating.LastDayRatings,
+		rating := existPOIRating
+		if update {
+			rating = score.WithdrawRating(rating, existProfileRating.Score, ratedAt(existProfileRating, profileMetric), now)
 		}
+		rating = score.AddRating(rating, r.Score, now)
+
+		rating.Resource = r.Resource
+		rating.SumOfScore = sum
+		rating.Score = average
+		rating.Ratings = count
+		rating.LastUpdate = now.Unix()
+		poiResourceMap[r.Resource.ID] = rating
 	}
 
 	poiRatings := []schema.POIResourceRating{}
@@ -115,7 +118,7 @@ func (m *mongoDB) UpdatePOIRatingMetric(accountNumber string, poiID primitive.Ob
 		poiRatings = append(poiRatings, r)
 	}
 
-	autonomyScore, _, autonomyScoreDelta := score.CalculatePOIAutonomyScore(poiRatings, poi.Metric)
+	autonomyScore, _, autonomyScoreDelta := score.CalculatePOIAutonomyScore(poi.PlaceType, poiRatings, poi.Metric)
 
 	query := bson.M{
 		"_id": poiID,
@@ -150,11 +153,92 @@ func (m *mongoDB) UpdatePOIRatingMetric(accountNumber string, poiID primitive.Ob
 		}).Error("update poi resource_ratings")
 		return ErrPOINotFound
 	}
-	profileMetric.LastUpdate = time.Now().Unix()
-	profileMetric.Resources = ratings
+	profileRatings := make([]schema.RatingResource, 0, len(ratings))
+	for _, r := range ratings {
+		r.RatedAt = now.Unix()
+		profileRatings = append(profileRatings, r)
+	}
+	profileMetric.LastUpdate = now.Unix()
+	profileMetric.Resources = profileRatings
 	err = m.UpdateProfilePOIRatingMetric(accountNumber, poiID, profileMetric)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// RemovePOIRatings withdraws all ratings made by an account and recalculates the
+// aggregated ratings and autonomy scores of affected POIs. It returns IDs of those POIs.
+func (m *mongoDB) RemovePOIRatings(accountNumber string) ([]primitive.ObjectID, error) {
+	profile, err := m.GetProfile(accountNumber)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return []primitive.ObjectID{}, nil
+		}
+		return nil, err
+	}
+
+	c := m.client.Database(m.database).Collection(schema.POICollection)
+	affected := make([]primitive.ObjectID, 0)
+
+	for _, p := range profile.PointsOfInterest {
+		if len(p.ResourceRatings.Resources) == 0 {
+			continue
+		}
+
+		poi, err := m.GetPOI(p.ID)
+		if err != nil {
+			if err == ErrPOINotFound {
+				continue
+			}
+			return nil, err
+		}
+
+		profileRatings := make(map[string]schema.RatingResource)
+		for _, r := range p.ResourceRatings.Resources {
+			profileRatings[r.ID] = r
+		}
+
+		now := time.Now()
+		poiRatings := make([]schema.POIResourceRating, 0, len(poi.ResourceRatings.Resources))
+		for _, r := range poi.ResourceRatings.Resources {
+			if old, ok := profileRatings[r.Resource.ID]; ok {
+				r = score.WithdrawRating(r, old.Score, ratedAt(old, p.ResourceRatings), now)
+				r.Ratings, r.SumOfScore, r.Score = score.RemoveResourceScore(r, old)
+			}
+			poiRatings = append(poiRatings, r)
+		}
+
+		autonomyScore, _, autonomyScoreDelta := score.CalculatePOIAutonomyScore(poi.PlaceType, poiRatings, poi.Metric)
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		_, err = c.UpdateOne(ctx, bson.M{"_id": p.ID}, bson.M{
+			"$set": bson.M{
+				"resource_ratings": schema.POIRatingsMetric{
+					Resources:  poiRatings,
+					LastUpdate: now.Unix(),
+				},
+				"autonomy_score":       autonomyScore,
+				"autonomy_score_delta": autonomyScoreDelta,
+			},
+		})
+		cancel()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"prefix": mongoLogPrefix,
+				"poi ID": p.ID.String(),
+				"error":  err,
+			}).Error("remove poi resource_ratings")
+			return nil, err
+		}
+
+		// clear ratings of the profile so that they are not withdrawn twice
+		if err := m.UpdateProfilePOIRatingMetric(accountNumber, p.ID, schema.ProfileRatingsMetric{}); err != nil {
+			return nil, err
+		}
+
+		affected = append(affected, p.ID)
+	}
+
+	return affected, nil
+}
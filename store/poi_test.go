@@ -875,6 +875,10 @@ func (s *POITestSuite) TestListPOIByResourceNormal() {
 	pois, err := store.ListPOIByResource("resource_11", location)
 	s.NoError(err)
 	s.Len(pois, 2)
+	// resources without any rating score the prior
+	s.Equal(3.0, *pois[0].ResourceScore)
+	s.Equal(0.0, *pois[0].ResourceConfidence)
+	s.Empty(pois[0].ResourceRatings.Resources)
 
 	pois, err = store.ListPOIByResource("resource_12", location)
 	s.NoError(err)
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+var ErrPopulationNotFound = fmt.Errorf("population not found")
+
+type Population interface {
+	ImportPopulation(populations []schema.Population) (int64, error)
+	GetPopulation(loc schema.Location) (float64, error)
+}
+
+// ImportPopulation saves populations of areas. Populations of existing areas are replaced.
+// It returns the number of imported areas.
+func (m *mongoDB) ImportPopulation(populations []schema.Population) (int64, error) {
+	if len(populations) == 0 {
+		return 0, nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(populations))
+	for _, p := range populations {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"country": p.Country, "state": p.State, "county": p.County}).
+			SetReplacement(p).
+			SetUpsert(true))
+	}
+
+	c := m.client.Database(m.database).Collection(schema.PopulationCollection)
+	result, err := c.BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.UpsertedCount + result.MatchedCount, nil
+}
+
+// GetPopulation returns the population of the area which confirmed cases of a location are
+// counted by, i.e. counties in the US and the whole country elsewhere.
+func (m *mongoDB) GetPopulation(loc schema.Location) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	filter := bson.M{"country": loc.Country, "state": "", "county": ""}
+	if loc.Country == schema.CdsUSA {
+		filter["state"] = loc.State
+		filter["county"] = loc.County
+	}
+
+	var p schema.Population
+	c := m.client.Database(m.database).Collection(schema.PopulationCollection)
+	if err := c.FindOne(ctx, filter).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, ErrPopulationNotFound
+		}
+		return 0, err
+	}
+
+	if p.Count <= 0 {
+		return 0, ErrPopulationNotFound
+	}
+
+	return p.Count, nil
+}
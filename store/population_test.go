@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+type PopulationTestSuite struct {
+	suite.Suite
+	connURI      string
+	testDBName   string
+	mongoClient  *mongo.Client
+	testDatabase *mongo.Database
+}
+
+func NewPopulationTestSuite(connURI, dbName string) *PopulationTestSuite {
+	return &PopulationTestSuite{
+		connURI:    connURI,
+		testDBName: dbName,
+	}
+}
+
+func (s *PopulationTestSuite) SetupSuite() {
+	if s.connURI == "" || s.testDBName == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	if err = mongoClient.Connect(context.Background()); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+	s.testDatabase = mongoClient.Database(s.testDBName)
+
+	// make sure the test suite is run with a clean environment
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+	if err := schema.NewMongoDBIndexer(s.connURI, s.testDBName).IndexPopulationCollection(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+// CleanMongoDB drop the whole test mongodb
+func (s *PopulationTestSuite) CleanMongoDB() error {
+	return s.testDatabase.Drop(context.Background())
+}
+
+func (s *PopulationTestSuite) TearDownSuite() {
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func (s *PopulationTestSuite) TestImportAndGetPopulation() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	n, err := store.ImportPopulation([]schema.Population{
+		{Country: schema.CdsTaiwan, Count: 23000000},
+		{Country: schema.CdsUSA, State: "New York", County: "Kings County", Count: 2500000},
+	})
+	s.NoError(err)
+	s.Equal(int64(2), n)
+
+	// importing again replaces existing areas
+	n, err = store.ImportPopulation([]schema.Population{
+		{Country: schema.CdsTaiwan, Count: 23500000},
+	})
+	s.NoError(err)
+	s.Equal(int64(1), n)
+
+	count, err := s.testDatabase.Collection(schema.PopulationCollection).CountDocuments(context.Background(), bson.M{})
+	s.NoError(err)
+	s.Equal(int64(2), count)
+
+	population, err := store.GetPopulation(schema.Location{AddressComponent: schema.AddressComponent{Country: schema.CdsTaiwan, County: "Taipei City"}})
+	s.NoError(err)
+	s.Equal(23500000.0, population)
+
+	population, err = store.GetPopulation(schema.Location{AddressComponent: schema.AddressComponent{Country: schema.CdsUSA, State: "New York", County: "Kings County"}})
+	s.NoError(err)
+	s.Equal(2500000.0, population)
+
+	_, err = store.GetPopulation(schema.Location{AddressComponent: schema.AddressComponent{Country: schema.CdsUSA, State: "New York", County: "Queens County"}})
+	s.Equal(ErrPopulationNotFound, err)
+}
+
+func TestPopulationTestSuite(t *testing.T) {
+	suite.Run(t, NewPopulationTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled", "test-population"))
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+// ProfileWatcher - watch profiles for the changes of scores and metrics
+type ProfileWatcher interface {
+	WatchProfiles(ctx context.Context, handle func(schema.Profile)) error
+}
+
+// WatchProfiles calls `handle` with the latest profile whenever a profile is changed.
+// Scores are persisted by background workers in other processes, so changes are read
+// from the change stream of the profile collection, which requires a replica set.
+// Updates of other fields, like the location updated on most requests, are filtered out
+// before the profiles are looked up. It blocks until the context is done or the stream fails.
+func (m *mongoDB) WatchProfiles(ctx context.Context, handle func(schema.Profile)) error {
+	c := m.client.Database(m.database).Collection(schema.ProfileCollection)
+
+	pipeline := mongo.Pipeline{
+		// fields are updated by their paths, e.g. `points_of_interest.0.score`
+		{{"$addFields", bson.M{
+			"updatedPaths": bson.M{"$map": bson.M{
+				"input": bson.M{"$objectToArray": "$updateDescription.updatedFields"},
+				"in":    "$$this.k",
+			}},
+		}}},
+		{{"$match", bson.M{"$or": bson.A{
+			bson.M{"operationType": bson.M{"$in": bson.A{"insert", "replace"}}},
+			bson.M{
+				"operationType": "update",
+				"updatedPaths":  primitive.Regex{Pattern: `^(metric|individual_metric|points_of_interest)(\.|$)`},
+			},
+		}}}},
+		{{"$project", bson.M{
+			"fullDocument.account_number":     1,
+			"fullDocument.individual_metric":  1,
+			"fullDocument.metric":             1,
+			"fullDocument.points_of_interest": 1,
+		}}},
+	}
+
+	stream, err := c.Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event struct {
+			FullDocument *schema.Profile `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			log.WithField("prefix", mongoLogPrefix).WithError(err).Error("decode profile change")
+			continue
+		}
+
+		// the document is gone if it is deleted before the lookup
+		if event.FullDocument != nil {
+			handle(*event.FullDocument)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return stream.Err()
+}
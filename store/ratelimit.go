@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/autonomy-api/ratelimit"
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+const (
+	maxRateLimitAttempts  = 5
+	rateLimitRetryBackoff = 10 * time.Millisecond
+)
+
+var (
+	ErrRateLimitConflict = fmt.Errorf("rate limit bucket is updated concurrently")
+)
+
+// RateLimit - token buckets shared by all server instances
+type RateLimit interface {
+	TakeRateLimitToken(key string, limit ratelimit.Limit, now time.Time) (bool, time.Duration, error)
+	RefundRateLimitToken(key string, limit ratelimit.Limit, now time.Time) error
+}
+
+// TakeRateLimitToken takes a token from the bucket of `key`. A bucket is updated only
+// if it is not changed since it is read, otherwise the operation is retried after a
+// random backoff which grows with attempts.
+func (m *mongoDB) TakeRateLimitToken(key string, limit ratelimit.Limit, now time.Time) (bool, time.Duration, error) {
+	c := m.client.Database(m.database).Collection(schema.RateLimitCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	for i := 0; i < maxRateLimitAttempts; i++ {
+		if i > 0 {
+			backoff := rateLimitRetryBackoff << uint(i-1)
+			time.Sleep(backoff/2 + time.Duration(rand.Int63n(int64(backoff))))
+		}
+
+		var current schema.RateLimitBucket
+		found := true
+		if err := c.FindOne(ctx, bson.M{"_id": key}).Decode(&current); err != nil {
+			if err != mongo.ErrNoDocuments {
+				return false, 0, err
+			}
+			found = false
+		}
+
+		bucket := ratelimit.NewBucket(limit, now)
+		if found {
+			bucket = ratelimit.Bucket{Tokens: current.Tokens, UpdatedAt: current.UpdatedAt}
+		}
+
+		bucket, allowed, retryAfter := bucket.Take(limit, now)
+		next := schema.RateLimitBucket{
+			Key:       key,
+			Tokens:    bucket.Tokens,
+			UpdatedAt: bucket.UpdatedAt,
+			Version:   current.Version + 1,
+			// a full bucket is the same as a new one so it could be purged
+			ExpiresAt: bucket.FullAt(limit),
+		}
+
+		if found {
+			result, err := c.ReplaceOne(ctx, bson.M{"_id": key, "version": current.Version}, next)
+			if err != nil {
+				return false, 0, err
+			}
+			if result.MatchedCount == 0 {
+				continue
+			}
+		} else {
+			if _, err := c.InsertOne(ctx, next); err != nil {
+				if we, ok := err.(mongo.WriteException); ok {
+					if 1 == len(we.WriteErrors) && DuplicateKeyCode == we.WriteErrors[0].Code {
+						continue
+					}
+				}
+				return false, 0, err
+			}
+		}
+
+		return allowed, retryAfter, nil
+	}
+
+	return false, 0, ErrRateLimitConflict
+}
+
+// RefundRateLimitToken puts a taken token back into the bucket of `key`. The version is
+// increased so that a concurrent take reads the bucket again.
+func (m *mongoDB) RefundRateLimitToken(key string, limit ratelimit.Limit, now time.Time) error {
+	if limit.Unlimited() {
+		return nil
+	}
+
+	c := m.client.Database(m.database).Collection(schema.RateLimitCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err := c.UpdateOne(ctx, bson.M{"_id": key}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens":  bson.M{"$min": bson.A{bson.M{"$add": bson.A{"$tokens", 1}}, limit.Capacity()}},
+			"version": bson.M{"$add": bson.A{"$version", 1}},
+		}}},
+	})
+	return err
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/ratelimit"
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	connURI      string
+	testDBName   string
+	mongoClient  *mongo.Client
+	testDatabase *mongo.Database
+}
+
+func NewRateLimitTestSuite(connURI, dbName string) *RateLimitTestSuite {
+	return &RateLimitTestSuite{
+		connURI:    connURI,
+		testDBName: dbName,
+	}
+}
+
+func (s *RateLimitTestSuite) SetupSuite() {
+	if s.connURI == "" || s.testDBName == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	if err = mongoClient.Connect(context.Background()); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+	s.testDatabase = mongoClient.Database(s.testDBName)
+
+	// make sure the test suite is run with a clean environment
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+	if err := schema.NewMongoDBIndexer(s.connURI, s.testDBName).IndexRateLimitCollection(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+// CleanMongoDB drop the whole test mongodb
+func (s *RateLimitTestSuite) CleanMongoDB() error {
+	return s.testDatabase.Drop(context.Background())
+}
+
+func (s *RateLimitTestSuite) TearDownSuite() {
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func (s *RateLimitTestSuite) TestTakeRateLimitToken() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.TakeRateLimitToken("take", limit, now)
+		s.NoError(err)
+		s.True(allowed)
+	}
+
+	allowed, retryAfter, err := store.TakeRateLimitToken("take", limit, now)
+	s.NoError(err)
+	s.False(allowed)
+	s.Equal(30*time.Second, retryAfter)
+
+	allowed, _, err = store.TakeRateLimitToken("take", limit, now.Add(30*time.Second))
+	s.NoError(err)
+	s.True(allowed)
+}
+
+func (s *RateLimitTestSuite) TestRefundRateLimitToken() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	limit := ratelimit.Limit{Requests: 1, Period: time.Hour}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	allowed, _, err := store.TakeRateLimitToken("refund", limit, now)
+	s.NoError(err)
+	s.True(allowed)
+
+	s.NoError(store.RefundRateLimitToken("refund", limit, now))
+	// a bucket never holds more tokens than its capacity
+	s.NoError(store.RefundRateLimitToken("refund", limit, now))
+
+	allowed, _, err = store.TakeRateLimitToken("refund", limit, now)
+	s.NoError(err)
+	s.True(allowed)
+
+	allowed, _, err = store.TakeRateLimitToken("refund", limit, now)
+	s.NoError(err)
+	s.False(allowed)
+}
+
+func (s *RateLimitTestSuite) TestTakeRateLimitTokenConcurrently() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	limit := ratelimit.Limit{Requests: 5, Period: time.Hour}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	allowedCount := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed, _, err := store.TakeRateLimitToken("concurrent", limit, now)
+			if err == nil && allowed {
+				lock.Lock()
+				allowedCount++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// conflicting updates are retried or rejected and never exceed the budget
+	s.LessOrEqual(allowedCount, 5)
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, NewRateLimitTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled", "test-rate-limit"))
+}
@@ -8,11 +8,62 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"github.com/bitmark-inc/autonomy-api/geo"
 	"github.com/bitmark-inc/autonomy-api/schema"
 )
 
+// insertProfileReport inserts a report of a profile. If the idempotency key of the report is
+// used by another report of the profile after `since`, the report is not inserted and it
+// returns true. The key of a report before `since` is released for the new report. A report
+// of the same time as another report of the profile is ignored.
+func insertProfileReport(ctx context.Context, c *mongo.Collection, report interface{}, profileID, key string, since int64) (bool, error) {
+	for released := false; ; released = true {
+		_, err := c.InsertOne(ctx, report)
+		we, hasErr := err.(mongo.WriteException)
+		if !hasErr {
+			return false, err
+		}
+		if 1 != len(we.WriteErrors) || DuplicateKeyCode != we.WriteErrors[0].Code {
+			return false, err
+		}
+
+		if key == "" {
+			return false, nil
+		}
+
+		count, err := c.CountDocuments(ctx, bson.M{
+			"profile_id":      profileID,
+			"idempotency_key": key,
+			"ts":              bson.M{"$gte": since},
+		})
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+
+		if released {
+			return false, nil
+		}
+
+		result, err := c.UpdateMany(ctx, bson.M{
+			"profile_id":      profileID,
+			"idempotency_key": key,
+			"ts":              bson.M{"$lt": since},
+		}, bson.M{"$unset": bson.M{"idempotency_key": ""}})
+		if err != nil {
+			return false, err
+		}
+		if result.ModifiedCount == 0 {
+			return false, nil
+		}
+	}
+}
+
 type Report interface {
 	GetNearbyReportingUserCount(reportType schema.ReportType, dist int, loc schema.Location, now time.Time) (int, int, error)
+	FindNearbyReports(reportType schema.ReportType, dist int, loc schema.Location, start, end int64) ([]schema.ReportRecord, error)
 }
 
 func userCountPipelineByTime(dist int, loc schema.Location, startAt, EndAt time.Time) []bson.M {
@@ -39,7 +90,7 @@ func userCountPipelineByTime(dist int, loc schema.Location, startAt, EndAt time.
 }
 
 // GetNearbyReportingUserCount returns the number of users who have reported symptoms/behaviors
-// in the specified area on the day of a given time and the day before that day.
+// in the specified area in the last 24 hours until a given time and the 24 hours before.
 func (m *mongoDB) GetNearbyReportingUserCount(reportType schema.ReportType, dist int, loc schema.Location, now time.Time) (int, int, error) {
 	var c *mongo.Collection
 	switch reportType {
@@ -53,11 +104,11 @@ func (m *mongoDB) GetNearbyReportingUserCount(reportType schema.ReportType, dist
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	yesterdayStartAt, todayStartAt, tomorrowStartAt := getStartTimeOfConsecutiveDays(now)
+	previousStartAt, lastStartAt, endAt := getRollingWindows(now)
 
 	var todayCount, yesterdayCount int
 	{
-		cursor, err := c.Aggregate(ctx, userCountPipelineByTime(dist, loc, todayStartAt, tomorrowStartAt))
+		cursor, err := c.Aggregate(ctx, userCountPipelineByTime(dist, loc, lastStartAt, endAt))
 		if err != nil {
 			return 0, 0, err
 		}
@@ -74,7 +125,7 @@ func (m *mongoDB) GetNearbyReportingUserCount(reportType schema.ReportType, dist
 	}
 
 	{
-		cursor, err := c.Aggregate(ctx, userCountPipelineByTime(dist, loc, yesterdayStartAt, todayStartAt))
+		cursor, err := c.Aggregate(ctx, userCountPipelineByTime(dist, loc, previousStartAt, lastStartAt))
 		if err != nil {
 			return 0, 0, err
 		}
@@ -92,3 +143,70 @@ func (m *mongoDB) GetNearbyReportingUserCount(reportType schema.ReportType, dist
 
 	return todayCount, yesterdayCount, nil
 }
+
+// FindNearbyReports returns symptom/behavior reports in the specified area and within the
+// specified time range, with IDs of official, customized and legacy items of each report.
+func (m *mongoDB) FindNearbyReports(reportType schema.ReportType, dist int, loc schema.Location, start, end int64) ([]schema.ReportRecord, error) {
+	var c *mongo.Collection
+	var fields []string
+	switch reportType {
+	case schema.ReportTypeSymptom:
+		c = m.client.Database(m.database).Collection(schema.SymptomReportCollection)
+		fields = []string{"official_symptoms", "customized_symptoms", "symptoms"}
+	case schema.ReportTypeBehavior:
+		c = m.client.Database(m.database).Collection(schema.BehaviorReportCollection)
+		fields = []string{"official_behaviors", "customized_behaviors", "behaviors"}
+	default:
+		return nil, errors.New("invalid report type")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ids := bson.A{}
+	for _, field := range fields {
+		ids = append(ids, bson.M{"$ifNull": bson.A{specifyField(field + "._id"), bson.A{}}})
+	}
+
+	pipeline := []bson.M{
+		aggStageGeoProximity(dist, loc),
+		aggStageReportedBetween(start, end),
+		{
+			"$project": bson.M{
+				"profile_id": 1,
+				"ts":         1,
+				"ids": bson.M{
+					"$concatArrays": ids,
+				},
+			},
+		},
+	}
+
+	cursor, err := c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]schema.ReportRecord, 0)
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// coarsenReportLocation reduces the precision of the location of a report before it is saved
+func coarsenReportLocation(loc schema.GeoJSON) schema.GeoJSON {
+	if len(loc.Coordinates) != 2 {
+		return loc
+	}
+
+	coarsened := geo.CoarsenLocation(schema.Location{
+		Latitude:  loc.Coordinates[1],
+		Longitude: loc.Coordinates[0],
+	})
+
+	return schema.GeoJSON{
+		Type:        loc.Type,
+		Coordinates: []float64{coarsened.Longitude, coarsened.Latitude},
+	}
+}
@@ -9,16 +9,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
 )
 
 type ScoreHistory interface {
-	AddScoreRecord(owner string, scope schema.ScoreRecordType, score float64, ts int64) error
+	AddScoreRecord(owner string, scope schema.ScoreRecordType, score float64, formulaVersion string, ts int64) error
 	GetScoreAverage(owner string, start, end int64) (float64, error)
 	GetScoreTimeSeriesData(owner string, start, end int64, granularity schema.AggregationTimeGranularity) ([]schema.Bucket, error)
 }
 
+// AddScoreRecord averages scores of a day into a record. Scores of different formulas are not
+// comparable, so the average restarts if the formula is changed in the day. The record is
+// updated by a pipeline, so that concurrent updates do not lose any score.
 // TODO: consider user local time
-func (m *mongoDB) AddScoreRecord(owner string, recordType schema.ScoreRecordType, score float64, ts int64) error {
+func (m *mongoDB) AddScoreRecord(owner string, recordType schema.ScoreRecordType, value float64, formulaVersion string, ts int64) error {
 	c := m.client.Database(m.database).Collection(schema.ScoreHistoryCollection)
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
@@ -26,27 +30,29 @@ func (m *mongoDB) AddScoreRecord(owner string, recordType schema.ScoreRecordType
 	date := time.Unix(ts, 0).Format("2006-01-02")
 	query := bson.M{"owner": owner, "type": recordType, "date": date}
 
-	var record schema.ScoreRecord
-	err := c.FindOne(ctx, query).Decode(&record)
-	if err != nil && err != mongo.ErrNoDocuments {
-		return err
-	}
-	score = (record.Score*record.UpdateTimes + score) / (record.UpdateTimes + 1)
-
-	update := bson.M{
-		"$set": bson.M{
-			"score": score,
-			"ts":    ts,
-		},
-		"$inc": bson.M{"update_times": 1},
-		"$setOnInsert": bson.M{
-			"owner": owner,
-			"type":  recordType,
-			"date":  date,
-		},
+	// records before formulas were versioned are calculated by V1
+	sameFormula := bson.M{"$eq": bson.A{
+		bson.M{"$ifNull": bson.A{"$formula_version", score.FormulaVersionV1}},
+		formulaVersion,
+	}}
+
+	update := mongo.Pipeline{
+		{{"$set", bson.M{
+			"score":        bson.M{"$cond": bson.A{sameFormula, bson.M{"$ifNull": bson.A{"$score", 0}}, 0}},
+			"update_times": bson.M{"$cond": bson.A{sameFormula, bson.M{"$ifNull": bson.A{"$update_times", 0}}, 0}},
+		}}},
+		{{"$set", bson.M{
+			"score": bson.M{"$divide": bson.A{
+				bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{"$score", "$update_times"}}, value}},
+				bson.M{"$add": bson.A{"$update_times", 1}},
+			}},
+			"update_times":    bson.M{"$add": bson.A{"$update_times", 1}},
+			"ts":              ts,
+			"formula_version": bson.M{"$literal": formulaVersion},
+		}}},
 	}
 	opts := options.Update().SetUpsert(true)
-	_, err = c.UpdateOne(ctx, query, update, opts)
+	_, err := c.UpdateOne(ctx, query, update, opts)
 	return err
 }
 
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/bitmark-inc/autonomy-api/schema"
+	"github.com/bitmark-inc/autonomy-api/score"
 )
 
 type ScoreHistoryTestSuite struct {
@@ -65,7 +67,7 @@ func (s *ScoreHistoryTestSuite) TestAddScoreRecord() {
 
 	// user A: first update
 	firstUpdateTime := time.Date(2020, 5, 25, 12, 12, 0, 0, time.UTC)
-	err := store.AddScoreRecord("userA", schema.ScoreRecordTypeIndividual, 60.0, firstUpdateTime.Unix())
+	err := store.AddScoreRecord("userA", schema.ScoreRecordTypeIndividual, 60.0, "v1", firstUpdateTime.Unix())
 	s.NoError(err)
 
 	query := bson.M{
@@ -77,41 +79,44 @@ func (s *ScoreHistoryTestSuite) TestAddScoreRecord() {
 	s.NoError(err)
 	s.Equal(60.0, record.Score)
 	s.Equal(schema.ScoreRecord{
-		Owner:       "userA",
-		Type:        schema.ScoreRecordTypeIndividual,
-		Score:       60.0,
-		UpdateTimes: 1,
-		Date:        "2020-05-25",
+		Owner:          "userA",
+		Type:           schema.ScoreRecordTypeIndividual,
+		Score:          60.0,
+		UpdateTimes:    1,
+		Date:           "2020-05-25",
+		FormulaVersion: "v1",
 	}, record)
 
 	// user A: second update in the same day
 	secondUpdateTime := time.Date(2020, 5, 25, 12, 12, 0, 0, time.UTC)
-	err = store.AddScoreRecord("userA", schema.ScoreRecordTypeIndividual, 75.0, secondUpdateTime.Unix())
+	err = store.AddScoreRecord("userA", schema.ScoreRecordTypeIndividual, 75.0, "v1", secondUpdateTime.Unix())
 	s.NoError(err)
 	err = s.testDatabase.Collection(schema.ScoreHistoryCollection).FindOne(
 		context.Background(), query, options.FindOne()).Decode(&record)
 	s.NoError(err)
 	s.Equal(schema.ScoreRecord{
-		Owner:       "userA",
-		Type:        schema.ScoreRecordTypeIndividual,
-		Score:       67.5, // (60 + 75) / 2
-		UpdateTimes: 2,
-		Date:        "2020-05-25",
+		Owner:          "userA",
+		Type:           schema.ScoreRecordTypeIndividual,
+		Score:          67.5, // (60 + 75) / 2
+		UpdateTimes:    2,
+		Date:           "2020-05-25",
+		FormulaVersion: "v1",
 	}, record)
 
 	// user B: first update in the same day
 	secondUpdateTime = time.Date(2020, 5, 25, 12, 12, 0, 0, time.UTC)
-	err = store.AddScoreRecord("userB", schema.ScoreRecordTypeIndividual, 40.0, secondUpdateTime.Unix())
+	err = store.AddScoreRecord("userB", schema.ScoreRecordTypeIndividual, 40.0, "v1", secondUpdateTime.Unix())
 	s.NoError(err)
 	err = s.testDatabase.Collection(schema.ScoreHistoryCollection).FindOne(
 		context.Background(), query, options.FindOne()).Decode(&record)
 	s.NoError(err)
 	s.Equal(schema.ScoreRecord{
-		Owner:       "userA",
-		Type:        schema.ScoreRecordTypeIndividual,
-		Score:       67.5,
-		UpdateTimes: 2,
-		Date:        "2020-05-25",
+		Owner:          "userA",
+		Type:           schema.ScoreRecordTypeIndividual,
+		Score:          67.5,
+		UpdateTimes:    2,
+		Date:           "2020-05-25",
+		FormulaVersion: "v1",
 	}, record)
 	err = s.testDatabase.Collection(schema.ScoreHistoryCollection).FindOne(
 		context.Background(), bson.M{
@@ -120,12 +125,74 @@ func (s *ScoreHistoryTestSuite) TestAddScoreRecord() {
 		}, options.FindOne()).Decode(&record)
 	s.NoError(err)
 	s.Equal(schema.ScoreRecord{
-		Owner:       "userB",
-		Type:        schema.ScoreRecordTypeIndividual,
-		Score:       40.0,
-		UpdateTimes: 1,
-		Date:        "2020-05-25",
+		Owner:          "userB",
+		Type:           schema.ScoreRecordTypeIndividual,
+		Score:          40.0,
+		UpdateTimes:    1,
+		Date:           "2020-05-25",
+		FormulaVersion: "v1",
 	}, record)
+
+	// user A: the average restarts when the formula is changed
+	err = store.AddScoreRecord("userA", schema.ScoreRecordTypeIndividual, 90.0, "v2", secondUpdateTime.Unix())
+	s.NoError(err)
+	err = s.testDatabase.Collection(schema.ScoreHistoryCollection).FindOne(
+		context.Background(), query, options.FindOne()).Decode(&record)
+	s.NoError(err)
+	s.Equal(schema.ScoreRecord{
+		Owner:          "userA",
+		Type:           schema.ScoreRecordTypeIndividual,
+		Score:          90.0,
+		UpdateTimes:    1,
+		Date:           "2020-05-25",
+		FormulaVersion: "v2",
+	}, record)
+}
+
+func (s *ScoreHistoryTestSuite) TestAddScoreRecordOfLegacyRecord() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	ctx := context.Background()
+
+	// records without formula versions are averaged with scores of V1
+	_, err := s.testDatabase.Collection(schema.ScoreHistoryCollection).InsertOne(ctx, bson.M{
+		"owner":        "userLegacy",
+		"type":         schema.ScoreRecordTypeIndividual,
+		"score":        60.0,
+		"update_times": 1,
+		"date":         "2020-05-25",
+	})
+	s.NoError(err)
+
+	updateTime := time.Date(2020, 5, 25, 12, 12, 0, 0, time.UTC)
+	s.NoError(store.AddScoreRecord("userLegacy", schema.ScoreRecordTypeIndividual, 80.0, score.FormulaVersionV1, updateTime.Unix()))
+
+	var record schema.ScoreRecord
+	s.NoError(s.testDatabase.Collection(schema.ScoreHistoryCollection).FindOne(ctx, bson.M{"owner": "userLegacy"}).Decode(&record))
+	s.Equal(70.0, record.Score)
+	s.Equal(2.0, record.UpdateTimes)
+	s.Equal(score.FormulaVersionV1, record.FormulaVersion)
+}
+
+func (s *ScoreHistoryTestSuite) TestAddScoreRecordConcurrently() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	updateTime := time.Date(2020, 5, 25, 12, 12, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.NoError(store.AddScoreRecord("userConcurrent", schema.ScoreRecordTypeIndividual, float64(10*i), "v1", updateTime.Unix()))
+		}(i)
+	}
+	wg.Wait()
+
+	// no score is lost
+	var record schema.ScoreRecord
+	s.NoError(s.testDatabase.Collection(schema.ScoreHistoryCollection).FindOne(
+		context.Background(), bson.M{"owner": "userConcurrent"}).Decode(&record))
+	s.InDelta(55.0, record.Score, 1e-9)
+	s.Equal(10.0, record.UpdateTimes)
 }
 
 func (s *ScoreHistoryTestSuite) TestGetScoreAverage() {
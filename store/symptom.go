@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -19,6 +18,10 @@ import (
 	"github.com/bitmark-inc/autonomy-api/utils"
 )
 
+var (
+	ErrSymptomNotFound = fmt.Errorf("customized symptom not found")
+)
+
 var localizedSymptoms map[string][]schema.Symptom = map[string][]schema.Symptom{}
 var localizedSuggestedSymptoms map[string][]schema.Symptom = map[string][]schema.Symptom{}
 
@@ -27,12 +30,16 @@ type Symptom interface {
 	ListOfficialSymptoms(string) ([]schema.Symptom, error)
 	ListSuggestedSymptoms(lang string) ([]schema.Symptom, error)
 	ListCustomizedSymptoms() ([]schema.Symptom, error)
-	SymptomReportSave(data *schema.SymptomReportData) error
+	SymptomReportSave(data *schema.SymptomReportData, idempotentSince int64) (*schema.SymptomReportData, error)
+	FindSymptomReportByIdempotencyKey(profileID, key string, since int64) (*schema.SymptomReportData, error)
 	FindSymptomsByIDs(ids []string) ([]schema.Symptom, error)
 	FindSymptomDistribution(profileID string, loc *schema.Location, dist int, start, end int64, distinct bool) (map[string]int, error)
+	FindDailySymptomDistributions(loc schema.Location, dist int, now time.Time, days int) ([]map[string]int, error)
 	FindNearbyNonOfficialSymptoms(dist int, loc schema.Location) ([]schema.Symptom, error)
 	GetSymptomCount(profileID string, loc *schema.Location, dist int, now time.Time) (int, int, error)
 	GetPersonalSymptomTimeSeriesData(profileID string, start, end int64, utcOffset string, granularity schema.AggregationTimeGranularity) (map[string][]schema.Bucket, error)
+	UpdateCustomizedSymptom(id, name, desc string) error
+	MergeCustomizedSymptom(fromID, toID string) error
 }
 
 func (m *mongoDB) CreateSymptom(symptom schema.Symptom) (string, error) {
@@ -65,7 +72,7 @@ func (m *mongoDB) ListOfficialSymptoms(lang string) ([]schema.Symptom, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	lang = strings.ReplaceAll(strings.ToLower(lang), "-", "_")
+	lang = utils.ResolveLanguage(lang, "")
 
 	if symptoms, ok := localizedSymptoms[lang]; ok {
 		return symptoms, nil
@@ -110,7 +117,7 @@ func (m *mongoDB) ListSuggestedSymptoms(lang string) ([]schema.Symptom, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	lang = strings.ReplaceAll(strings.ToLower(lang), "-", "_")
+	lang = utils.ResolveLanguage(lang, "")
 
 	if symptoms, ok := localizedSuggestedSymptoms[lang]; ok {
 		return symptoms, nil
@@ -174,20 +181,48 @@ func (m *mongoDB) ListCustomizedSymptoms() ([]schema.Symptom, error) {
 	return symptoms, nil
 }
 
-// SymptomReportSave save  a record instantly in database
-func (m *mongoDB) SymptomReportSave(data *schema.SymptomReportData) error {
+// SymptomReportSave save  a record instantly in database. If the idempotency key of the
+// report is used by another report after `idempotentSince`, the report is not saved and
+// the original one is returned.
+func (m *mongoDB) SymptomReportSave(data *schema.SymptomReportData, idempotentSince int64) (*schema.SymptomReportData, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	c := m.client.Database(m.database)
-	_, err := c.Collection(schema.SymptomReportCollection).InsertOne(ctx, *data)
-	we, hasErr := err.(mongo.WriteException)
-	if hasErr {
-		if 1 == len(we.WriteErrors) && DuplicateKeyCode == we.WriteErrors[0].Code {
-			return nil
+
+	report := *data
+	report.Location = coarsenReportLocation(report.Location)
+
+	replayed, err := insertProfileReport(ctx, c.Collection(schema.SymptomReportCollection), report,
+		report.ProfileID, report.IdempotencyKey, idempotentSince)
+	if err != nil || !replayed {
+		return nil, err
+	}
+
+	return m.FindSymptomReportByIdempotencyKey(report.ProfileID, report.IdempotencyKey, idempotentSince)
+}
+
+// FindSymptomReportByIdempotencyKey returns a report of a profile submitted with the
+// idempotency key after `since`. It returns nil if there is no such report.
+func (m *mongoDB) FindSymptomReportByIdempotencyKey(profileID, key string, since int64) (*schema.SymptomReportData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	c := m.client.Database(m.database).Collection(schema.SymptomReportCollection)
+
+	query := bson.M{
+		"profile_id":      profileID,
+		"idempotency_key": key,
+		"ts":              bson.M{"$gte": since},
+	}
+
+	var report schema.SymptomReportData
+	if err := c.FindOne(ctx, query).Decode(&report); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
-	return nil
+
+	return &report, nil
 }
 
 func (m *mongoDB) FindSymptomsByIDs(ids []string) ([]schema.Symptom, error) {
@@ -333,6 +368,94 @@ func (m *mongoDB) FindSymptomDistribution(profileID string, loc *schema.Location
 	return result, nil
 }
 
+// FindDailySymptomDistributions returns distributions of symptoms reported in the area in the
+// last `days` 24-hour windows, from the one ending at `now`. Values mean how many people have
+// reported each symptom in the window, the same as FindSymptomDistribution with `distinct`.
+// All windows are aggregated at once, so that baselines of many days could be collected cheaply.
+func (m *mongoDB) FindDailySymptomDistributions(loc schema.Location, dist int, now time.Time, days int) ([]map[string]int, error) {
+	c := m.client.Database(m.database).Collection(schema.SymptomReportCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	period := int64(24 * time.Hour / time.Second)
+	end := now.Unix() + 1
+	pipeline := []bson.M{
+		aggStageGeoProximity(dist, loc),
+		aggStageReportedBetween(end-int64(days)*period, end),
+		{
+			"$project": bson.M{
+				"profile_id": 1,
+				"day": bson.M{
+					"$floor": bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{end - 1, "$ts"}}, period}},
+				},
+				"symptoms": bson.M{
+					"$concatArrays": bson.A{
+						bson.M{"$ifNull": bson.A{"$official_symptoms", bson.A{}}},
+						bson.M{"$ifNull": bson.A{"$customized_symptoms", bson.A{}}},
+						bson.M{"$ifNull": bson.A{"$symptoms", bson.A{}}},
+					},
+				},
+			},
+		},
+		{
+			"$unwind": bson.M{
+				"path":                       "$symptoms",
+				"preserveNullAndEmptyArrays": false,
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{"day": "$day", "profile_id": "$profile_id"},
+				"symptoms": bson.M{
+					"$addToSet": "$symptoms._id",
+				},
+			},
+		}, // for each user of each day, the types of symptoms reported
+		{
+			"$unwind": bson.M{
+				"path":                       "$symptoms",
+				"preserveNullAndEmptyArrays": false,
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{"day": "$_id.day", "symptom": "$symptoms"},
+				"count": bson.M{
+					"$sum": 1,
+				},
+			},
+		}, // for each symptom of each day, the number of users who have reported it
+	}
+
+	cursor, err := c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]int, days)
+	for i := range result {
+		result[i] = make(map[string]int)
+	}
+	for cursor.Next(ctx) {
+		var aggItem struct {
+			ID struct {
+				Day       int    `bson:"day"`
+				SymptomID string `bson:"symptom"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		}
+		if err := cursor.Decode(&aggItem); err != nil {
+			return nil, err
+		}
+		if aggItem.ID.Day < 0 || aggItem.ID.Day >= days {
+			continue
+		}
+		result[aggItem.ID.Day][aggItem.ID.SymptomID] = aggItem.Count
+	}
+
+	return result, nil
+}
+
 // FindNearbyNonOfficialSymptoms returns non-official symptoms reported today in the specified area.
 func (m *mongoDB) FindNearbyNonOfficialSymptoms(dist int, loc schema.Location) ([]schema.Symptom, error) {
 	distribution, err := m.FindSymptomDistribution("", &loc, dist, 0, 9223372036854775807, true)
@@ -368,7 +491,8 @@ func (m *mongoDB) FindNearbyNonOfficialSymptoms(dist int, loc schema.Location) (
 	return symptoms, nil
 }
 
-// GetSymptomCount returns the number of reported symptoms for today and yesterday.
+// GetSymptomCount returns the number of reported symptoms in the last 24 hours until `now`
+// and the 24 hours before.
 //
 // Either profileID of loc is required.
 // If profileID is provided, returned values are personal metrics.
@@ -394,24 +518,15 @@ func (m *mongoDB) GetSymptomCount(profileID string, loc *schema.Location, dist i
 		return 0, 0, errors.New("either profile ID or location not provided")
 	}
 
-	yesterdayStartAt, todayStartAt, tomorrowStartAt := getStartTimeOfConsecutiveDays(now)
+	previousStartAt, lastStartAt, endAt := getRollingWindows(now)
 
 	pipeline := []bson.M{
 		filter,
-		aggStageReportedBetween(yesterdayStartAt.Unix(), tomorrowStartAt.Unix()),
+		aggStageReportedBetween(previousStartAt.Unix(), endAt.Unix()),
 		{
 			"$project": bson.M{
 				"profile_id": 1,
-				"day": bson.M{
-					"$dateToString": bson.M{
-						"format": "%Y-%m-%d",
-						"date": bson.M{
-							"$toDate": bson.M{
-								"$multiply": bson.A{"$ts", 1000},
-							},
-						},
-					},
-				},
+				"window":     aggWindowOfReport(lastStartAt),
 				"symptoms": bson.M{
 					"$concatArrays": bson.A{
 						bson.M{"$ifNull": bson.A{"$official_symptoms", bson.A{}}},
@@ -431,7 +546,7 @@ func (m *mongoDB) GetSymptomCount(profileID string, loc *schema.Location, dist i
 			"$group": bson.M{
 				"_id": bson.M{
 					"profile_id": "$profile_id",
-					"day":        "$day",
+					"window":     "$window",
 				},
 				"symptoms": bson.M{
 					"$addToSet": "$symptoms._id",
@@ -440,7 +555,7 @@ func (m *mongoDB) GetSymptomCount(profileID string, loc *schema.Location, dist i
 		},
 		{
 			"$group": bson.M{
-				"_id": "$_id.day",
+				"_id": "$_id.window",
 				"count": bson.M{
 					"$sum": bson.M{"$size": "$symptoms"},
 				},
@@ -453,20 +568,18 @@ func (m *mongoDB) GetSymptomCount(profileID string, loc *schema.Location, dist i
 		return 0, 0, err
 	}
 	var aggItem struct {
-		Date  string `bson:"_id"`
-		Count int    `bson:"count"`
+		Window int `bson:"_id"`
+		Count  int `bson:"count"`
 	}
-	result := make(map[string]int)
+	result := make(map[int]int)
 	for cursor.Next(ctx) {
 		if err := cursor.Decode(&aggItem); err != nil {
 			return 0, 0, err
 		}
-		result[aggItem.Date] = aggItem.Count
+		result[aggItem.Window] = aggItem.Count
 	}
 
-	today := todayStartAt.Format("2006-01-02")
-	yesterday := yesterdayStartAt.Format("2006-01-02")
-	return result[today], result[yesterday], nil
+	return result[0], result[1], nil
 }
 
 // GetPersonalSymptomTimeSeriesData returns the number of reported symptoms
@@ -583,3 +696,93 @@ func (m *mongoDB) GetPersonalSymptomTimeSeriesData(profileID string, start, end
 	}
 	return results, nil
 }
+
+// embeddedSymptomFields are the fields of each collection which embed symptoms
+var embeddedSymptomFields = map[string]string{
+	schema.SymptomReportCollection: "symptoms",
+	schema.ProfileCollection:       "customized_symptom",
+}
+
+// UpdateCustomizedSymptom edits the name and the description of a customized symptom.
+// Symptoms embedded in existing reports and profiles are updated as well.
+func (m *mongoDB) UpdateCustomizedSymptom(id, name, desc string) error {
+	if 0 == len(name) {
+		return errors.New("empty symptom")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	c := m.client.Database(m.database)
+
+	result, err := c.Collection(schema.SymptomCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "source": schema.CustomizedSymptom},
+		bson.M{"$set": bson.M{"name": name, "desc": desc}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrSymptomNotFound
+	}
+
+	for collection, field := range embeddedSymptomFields {
+		if _, err := c.Collection(collection).UpdateMany(ctx,
+			bson.M{field + "._id": id},
+			bson.M{"$set": bson.M{field + ".$.name": name, field + ".$.desc": desc}},
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MergeCustomizedSymptom merges a customized symptom into another symptom.
+// Reports and profiles of the merged symptom are rewritten to the target one and the
+// merged symptom is removed.
+func (m *mongoDB) MergeCustomizedSymptom(fromID, toID string) error {
+	if fromID == toID {
+		return errors.New("can not merge a symptom into itself")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	c := m.client.Database(m.database)
+
+	var from, to schema.Symptom
+	if err := c.Collection(schema.SymptomCollection).FindOne(ctx,
+		bson.M{"_id": fromID, "source": schema.CustomizedSymptom}).Decode(&from); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrSymptomNotFound
+		}
+		return err
+	}
+
+	if err := c.Collection(schema.SymptomCollection).FindOne(ctx, bson.M{"_id": toID}).Decode(&to); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrSymptomNotFound
+		}
+		return err
+	}
+
+	for collection, field := range embeddedSymptomFields {
+		// documents which have both symptoms only need to drop the merged one
+		if _, err := c.Collection(collection).UpdateMany(ctx,
+			bson.M{field + "._id": bson.M{"$all": bson.A{fromID, toID}}},
+			bson.M{"$pull": bson.M{field: bson.M{"_id": fromID}}},
+		); err != nil {
+			return err
+		}
+
+		if _, err := c.Collection(collection).UpdateMany(ctx,
+			bson.M{field + "._id": fromID},
+			bson.M{"$set": bson.M{field + ".$": to}},
+		); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.Collection(schema.SymptomCollection).DeleteOne(ctx, bson.M{"_id": fromID})
+	return err
+}
@@ -2,10 +2,12 @@ package store
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
@@ -157,22 +159,46 @@ func (s *SymptomTestSuite) TestFindSymptomDistribution() {
 	}, distribution)
 }
 
+func (s *SymptomTestSuite) TestFindDailySymptomDistributions() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	now := time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	distributions, err := store.FindDailySymptomDistributions(schema.Location{
+		Longitude: locationBitmark.Coordinates[0],
+		Latitude:  locationBitmark.Coordinates[1],
+	}, s.neighborhoodRadius, now, 3)
+	s.NoError(err)
+	s.Equal([]map[string]int{
+		{
+			"cough":            1,
+			"fever":            1,
+			"loss_taste_smell": 1,
+			"new_symptom_1":    1,
+		},
+		{
+			"cough": 1,
+			"fever": 1,
+		},
+		{},
+	}, distributions)
+}
+
 func (s *SymptomTestSuite) TestGetSymptomCountForIndividual() {
 	store := NewMongoStore(s.mongoClient, s.testDBName)
 
-	now := time.Date(2020, 5, 25, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2020, 5, 25, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err := store.GetSymptomCount("userA", nil, 0, now)
 	s.NoError(err)
 	s.Equal(2, todayCount)
 	s.Equal(0, yesterdayCount)
 
-	now = time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetSymptomCount("userA", nil, 0, now)
 	s.NoError(err)
 	s.Equal(2, todayCount)
 	s.Equal(2, yesterdayCount)
 
-	now = time.Date(2020, 5, 27, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 27, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetSymptomCount("userA", nil, 0, now)
 	s.NoError(err)
 	s.Equal(0, todayCount)
@@ -188,31 +214,53 @@ func (s *SymptomTestSuite) TestGetSymptomCountForCommunity() {
 	}
 	dist := consts.CORHORT_DISTANCE_RANGE
 
-	now := time.Date(2020, 5, 25, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2020, 5, 25, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err := store.GetSymptomCount("", loc, dist, now)
 	s.NoError(err)
 	s.Equal(2, todayCount)
 	s.Equal(0, yesterdayCount)
 
-	now = time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetSymptomCount("", loc, dist, now)
 	s.NoError(err)
 	s.Equal(4, todayCount)
 	s.Equal(2, yesterdayCount)
 
-	now = time.Date(2020, 5, 27, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 27, 18, 0, 0, 0, time.UTC)
 	todayCount, yesterdayCount, err = store.GetSymptomCount("", loc, dist, now)
 	s.NoError(err)
 	s.Equal(0, todayCount)
 	s.Equal(4, yesterdayCount)
 }
 
+func (s *SymptomTestSuite) TestGetSymptomCountAroundMidnight() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	loc := &schema.Location{
+		Longitude: locationBitmark.Coordinates[0],
+		Latitude:  locationBitmark.Coordinates[1],
+	}
+
+	// counts of rolling windows do not jump at midnight
+	beforeMidnight := time.Date(2020, 5, 26, 23, 0, 0, 0, time.UTC)
+	todayCount, yesterdayCount, err := store.GetSymptomCount("", loc, consts.CORHORT_DISTANCE_RANGE, beforeMidnight)
+	s.NoError(err)
+	s.Equal(4, todayCount)
+	s.Equal(2, yesterdayCount)
+
+	afterMidnight := time.Date(2020, 5, 27, 1, 0, 0, 0, time.UTC)
+	todayCount, yesterdayCount, err = store.GetSymptomCount("", loc, consts.CORHORT_DISTANCE_RANGE, afterMidnight)
+	s.NoError(err)
+	s.Equal(4, todayCount)
+	s.Equal(2, yesterdayCount)
+}
+
 func (s *SymptomTestSuite) TestGetNearbyReportingSymptomsUserCount() {
 	store := NewMongoStore(s.mongoClient, s.testDBName)
 
 	dist := consts.CORHORT_DISTANCE_RANGE
 
-	now := time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	count, countYesterday, err := store.GetNearbyReportingUserCount(
 		schema.ReportTypeSymptom,
 		dist,
@@ -225,7 +273,7 @@ func (s *SymptomTestSuite) TestGetNearbyReportingSymptomsUserCount() {
 	s.Equal(2, count)
 	s.Equal(1, countYesterday)
 
-	now = time.Date(2020, 5, 26, 12, 0, 0, 0, time.UTC)
+	now = time.Date(2020, 5, 26, 18, 0, 0, 0, time.UTC)
 	count, countYesterday, err = store.GetNearbyReportingUserCount(
 		schema.ReportTypeSymptom,
 		dist,
@@ -238,6 +286,35 @@ func (s *SymptomTestSuite) TestGetNearbyReportingSymptomsUserCount() {
 	s.Equal(0, countYesterday)
 }
 
+func (s *SymptomTestSuite) TestFindNearbySymptomReports() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	start := time.Date(2020, 5, 25, 0, 0, 0, 0, time.UTC).Unix()
+	end := time.Date(2020, 5, 27, 0, 0, 0, 0, time.UTC).Unix()
+
+	reports, err := store.FindNearbyReports(
+		schema.ReportTypeSymptom,
+		consts.CORHORT_DISTANCE_RANGE,
+		schema.Location{
+			Longitude: locationTaipeiTrainStation.Coordinates[0],
+			Latitude:  locationTaipeiTrainStation.Coordinates[1],
+		}, start, end)
+	s.NoError(err)
+	s.Equal([]schema.ReportRecord{
+		{ProfileID: "userB", IDs: []string{"new_symptom_2"}, Timestamp: tsMay26Evening},
+	}, reports)
+
+	reports, err = store.FindNearbyReports(
+		schema.ReportTypeSymptom,
+		consts.CORHORT_DISTANCE_RANGE,
+		schema.Location{
+			Longitude: locationTaipeiTrainStation.Coordinates[0],
+			Latitude:  locationTaipeiTrainStation.Coordinates[1],
+		}, start, tsMay26Evening)
+	s.NoError(err)
+	s.Empty(reports)
+}
+
 func (s *SymptomTestSuite) TestGetPersonalSymptomTimeSeriesData() {
 	store := NewMongoStore(s.mongoClient, s.testDBName)
 
@@ -323,6 +400,109 @@ func (s *SymptomTestSuite) TestGetPersonalSymptomTimeSeriesData() {
 	s.Equal(expected, results)
 }
 
+func (s *SymptomTestSuite) TestMergeCustomizedSymptom() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	ctx := context.Background()
+
+	fromID, err := store.CreateSymptom(schema.Symptom{Name: "Headache"})
+	s.NoError(err)
+	toID, err := store.CreateSymptom(schema.Symptom{Name: "Head ache"})
+	s.NoError(err)
+
+	farAway := schema.GeoJSON{Type: "Point", Coordinates: []float64{0, 0}}
+	_, err = s.testDatabase.Collection(schema.SymptomReportCollection).InsertMany(ctx, []interface{}{
+		schema.SymptomReportData{
+			ProfileID: "userMerge",
+			Symptoms:  []schema.Symptom{{ID: fromID, Name: "Headache"}},
+			Location:  farAway,
+			Timestamp: 1,
+		},
+		schema.SymptomReportData{
+			ProfileID: "userMerge",
+			Symptoms:  []schema.Symptom{{ID: fromID, Name: "Headache"}, {ID: toID, Name: "Head ache"}},
+			Location:  farAway,
+			Timestamp: 2,
+		},
+	})
+	s.NoError(err)
+
+	s.Equal(ErrSymptomNotFound, store.MergeCustomizedSymptom("cough", toID))
+	s.NoError(store.MergeCustomizedSymptom(fromID, toID))
+
+	count, err := s.testDatabase.Collection(schema.SymptomReportCollection).CountDocuments(ctx, bson.M{"symptoms._id": fromID})
+	s.NoError(err)
+	s.Equal(int64(0), count)
+
+	count, err = s.testDatabase.Collection(schema.SymptomReportCollection).CountDocuments(ctx, bson.M{
+		"profile_id":   "userMerge",
+		"symptoms":     bson.M{"$size": 1},
+		"symptoms._id": toID,
+	})
+	s.NoError(err)
+	s.Equal(int64(2), count)
+
+	count, err = s.testDatabase.Collection(schema.SymptomCollection).CountDocuments(ctx, bson.M{"_id": fromID})
+	s.NoError(err)
+	s.Equal(int64(0), count)
+}
+
+func (s *SymptomTestSuite) TestSymptomReportSaveConcurrently() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+	now := time.Now().Unix()
+	london := schema.GeoJSON{Type: "Point", Coordinates: []float64{-0.1276, 51.5072}}
+
+	// repeat submissions with the same key are saved only once
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	saved := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			original, err := store.SymptomReportSave(&schema.SymptomReportData{
+				ProfileID:      "userIdempotency",
+				Location:       london,
+				Timestamp:      now - int64(i),
+				IdempotencyKey: "key-1",
+			}, now-3600)
+			s.NoError(err)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if original == nil {
+				saved++
+			} else {
+				s.Equal("key-1", original.IdempotencyKey)
+			}
+		}(i)
+	}
+	wg.Wait()
+	s.Equal(1, saved)
+
+	c := s.testDatabase.Collection(schema.SymptomReportCollection)
+	count, err := c.CountDocuments(context.Background(), bson.M{"profile_id": "userIdempotency"})
+	s.NoError(err)
+	s.Equal(int64(1), count)
+
+	// a key used before the window is released for a new report
+	original, err := store.SymptomReportSave(&schema.SymptomReportData{
+		ProfileID:      "userIdempotency",
+		Location:       london,
+		Timestamp:      now + 7200,
+		IdempotencyKey: "key-1",
+	}, now+3600)
+	s.NoError(err)
+	s.Nil(original)
+
+	count, err = c.CountDocuments(context.Background(), bson.M{"profile_id": "userIdempotency"})
+	s.NoError(err)
+	s.Equal(int64(2), count)
+
+	count, err = c.CountDocuments(context.Background(), bson.M{"profile_id": "userIdempotency", "idempotency_key": "key-1"})
+	s.NoError(err)
+	s.Equal(int64(1), count)
+}
+
 func TestSymptomTestSuite(t *testing.T) {
 	suite.Run(t, NewSymptomTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled", "test-db"))
 }
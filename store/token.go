@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+var (
+	ErrTokenNotFound = fmt.Errorf("token not found")
+	ErrTokenRevoked  = fmt.Errorf("token revoked")
+)
+
+// Token - operations for issued JWTs
+type Token interface {
+	AddToken(token schema.Token) error
+	GetToken(id string) (*schema.Token, error)
+	RevokeToken(id string) error
+	RevokeAccountTokens(accountNumber string) error
+}
+
+// AddToken saves an issued token so it could be checked or revoked later
+func (m *mongoDB) AddToken(token schema.Token) error {
+	c := m.client.Database(m.database).Collection(schema.TokenCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	if _, err := c.InsertOne(ctx, token); err != nil {
+		log.WithError(err).WithField("prefix", mongoLogPrefix).Error("fail to save token")
+		return err
+	}
+
+	return nil
+}
+
+// GetToken returns a token by its `jti`
+func (m *mongoDB) GetToken(id string) (*schema.Token, error) {
+	c := m.client.Database(m.database).Collection(schema.TokenCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var token schema.Token
+	if err := c.FindOne(ctx, bson.M{"_id": id}).Decode(&token); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RevokeToken marks a token as revoked. The token is revoked atomically, so that only one of
+// concurrent callers succeeds and the others get `ErrTokenRevoked`.
+func (m *mongoDB) RevokeToken(id string) error {
+	c := m.client.Database(m.database).Collection(schema.TokenCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	err := c.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true}},
+	).Err()
+	if err == nil {
+		return nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	count, err := c.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTokenNotFound
+	}
+	return ErrTokenRevoked
+}
+
+// RevokeAccountTokens revokes all outstanding tokens of an account
+func (m *mongoDB) RevokeAccountTokens(accountNumber string) error {
+	c := m.client.Database(m.database).Collection(schema.TokenCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	if _, err := c.UpdateMany(ctx,
+		bson.M{"account_number": accountNumber, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true}},
+	); err != nil {
+		log.WithError(err).WithField("prefix", mongoLogPrefix).WithField("account_number", accountNumber).Error("fail to revoke account tokens")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/bitmark-inc/autonomy-api/schema"
+)
+
+type TokenTestSuite struct {
+	suite.Suite
+	connURI      string
+	testDBName   string
+	mongoClient  *mongo.Client
+	testDatabase *mongo.Database
+}
+
+func NewTokenTestSuite(connURI, dbName string) *TokenTestSuite {
+	return &TokenTestSuite{
+		connURI:    connURI,
+		testDBName: dbName,
+	}
+}
+
+func (s *TokenTestSuite) SetupSuite() {
+	if s.connURI == "" || s.testDBName == "" {
+		s.T().Fatal("invalid test suite configuration")
+	}
+
+	opts := options.Client().ApplyURI(s.connURI)
+	mongoClient, err := mongo.NewClient(opts)
+	if nil != err {
+		s.T().Fatalf("create mongo client with error: %s", err)
+	}
+
+	if err = mongoClient.Connect(context.Background()); nil != err {
+		s.T().Fatalf("connect mongo database with error: %s", err.Error())
+	}
+
+	s.mongoClient = mongoClient
+	s.testDatabase = mongoClient.Database(s.testDBName)
+
+	// make sure the test suite is run with a clean environment
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+	if err := schema.NewMongoDBIndexer(s.connURI, s.testDBName).IndexTokenCollection(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+// CleanMongoDB drop the whole test mongodb
+func (s *TokenTestSuite) CleanMongoDB() error {
+	return s.testDatabase.Drop(context.Background())
+}
+
+func (s *TokenTestSuite) TearDownSuite() {
+	if err := s.CleanMongoDB(); err != nil {
+		s.T().Fatal(err)
+	}
+}
+
+func (s *TokenTestSuite) newToken(id, accountNumber string, tokenType schema.TokenType) schema.Token {
+	now := time.Now().UTC()
+	return schema.Token{
+		ID:            id,
+		AccountNumber: accountNumber,
+		Type:          tokenType,
+		IssuedAt:      now,
+		ExpiresAt:     now.Add(time.Hour),
+	}
+}
+
+func (s *TokenTestSuite) TestAddAndGetToken() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	s.NoError(store.AddToken(s.newToken("token-add", "account-token-add", schema.AccessToken)))
+
+	token, err := store.GetToken("token-add")
+	s.NoError(err)
+	s.Equal("account-token-add", token.AccountNumber)
+	s.Equal(schema.AccessToken, token.Type)
+	s.False(token.Revoked)
+}
+
+func (s *TokenTestSuite) TestGetNonExistentToken() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	_, err := store.GetToken("token-not-exist")
+	s.Equal(ErrTokenNotFound, err)
+}
+
+func (s *TokenTestSuite) TestRevokeToken() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	s.NoError(store.AddToken(s.newToken("token-revoke", "account-token-revoke", schema.RefreshToken)))
+	s.NoError(store.RevokeToken("token-revoke"))
+
+	token, err := store.GetToken("token-revoke")
+	s.NoError(err)
+	s.True(token.Revoked)
+
+	s.Equal(ErrTokenRevoked, store.RevokeToken("token-revoke"))
+	s.Equal(ErrTokenNotFound, store.RevokeToken("token-not-exist"))
+}
+
+func (s *TokenTestSuite) TestRevokeTokenConcurrently() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	s.NoError(store.AddToken(s.newToken("token-revoke-race", "account-token-race", schema.RefreshToken)))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.RevokeToken("token-revoke-race")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			s.Equal(ErrTokenRevoked, err)
+		}
+	}
+	s.Equal(1, succeeded)
+}
+
+func (s *TokenTestSuite) TestRevokeAccountTokens() {
+	store := NewMongoStore(s.mongoClient, s.testDBName)
+
+	s.NoError(store.AddToken(s.newToken("token-account-1", "account-token-all", schema.AccessToken)))
+	s.NoError(store.AddToken(s.newToken("token-account-2", "account-token-all", schema.RefreshToken)))
+	s.NoError(store.AddToken(s.newToken("token-other-account", "account-token-other", schema.AccessToken)))
+
+	s.NoError(store.RevokeAccountTokens("account-token-all"))
+
+	for _, id := range []string{"token-account-1", "token-account-2"} {
+		token, err := store.GetToken(id)
+		s.NoError(err)
+		s.True(token.Revoked)
+	}
+
+	token, err := store.GetToken("token-other-account")
+	s.NoError(err)
+	s.False(token.Revoked)
+}
+
+func TestTokenTestSuite(t *testing.T) {
+	suite.Run(t, NewTokenTestSuite("mongodb://127.0.0.1:27017/?compressors=disabled", "test-token"))
+}
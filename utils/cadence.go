@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/cadence/.gen/go/shared"
 	cadenceClient "go.uber.org/cadence/client"
 
 	"github.com/bitmark-inc/autonomy-api/external/cadence"
@@ -79,3 +80,30 @@ func TriggerPOIUpdate(client cadence.CadenceClient, c context.Context, poiIDs []
 	}
 	return nil
 }
+
+// CancelAccountWorkflows is a helper function to cancel all score and nudge
+// workflows of an account. Workflows which are not running are skipped.
+func CancelAccountWorkflows(client cadence.CadenceClient, c context.Context, accountNumber string, poiIDs []primitive.ObjectID) error {
+	workflowIDs := []string{
+		fmt.Sprintf("account-state-%s", accountNumber),
+		fmt.Sprintf("account-nudge-symptom-follow-up-%s", accountNumber),
+		fmt.Sprintf("account-nudge-behavior-follow-up-on-risk-%s", accountNumber),
+		fmt.Sprintf("account-behavior-on-symptom-score-spike-%s", accountNumber),
+		fmt.Sprintf("account-behavior-on-risk-area-%s", accountNumber),
+		fmt.Sprintf("account-nudge-symptom-spike-%s", accountNumber),
+	}
+
+	for _, id := range poiIDs {
+		workflowIDs = append(workflowIDs, fmt.Sprintf("poi-%s-nudge-symptom-spike-%s", id.Hex(), accountNumber))
+	}
+
+	for _, id := range workflowIDs {
+		if err := client.CancelWorkflow(c, id, ""); err != nil {
+			if _, ok := err.(*shared.EntityNotExistsError); ok {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
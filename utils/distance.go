@@ -0,0 +1,21 @@
+package utils
+
+import "math"
+
+const earthRadius = 6371000.0 // in meters
+
+// GreatCircleDistance returns the distance in meters between two coordinates
+// by the haversine formula
+func GreatCircleDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadian := func(degree float64) float64 {
+		return degree * math.Pi / 180
+	}
+
+	dLat := toRadian(lat2 - lat1)
+	dLng := toRadian(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadian(lat1))*math.Cos(toRadian(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGreatCircleDistance(t *testing.T) {
+	assert.Equal(t, float64(0), GreatCircleDistance(25.0330, 121.5654, 25.0330, 121.5654))
+
+	// Taipei 101 to Taipei Main Station is about 5 km
+	assert.InDelta(t, 5025, GreatCircleDistance(25.0340, 121.5645, 25.0478, 121.5170), 10)
+
+	// one degree of latitude is about 111 km
+	assert.InDelta(t, 111195, GreatCircleDistance(0, 0, 1, 0), 1)
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"io/ioutil"
 	"path"
+	"strings"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/spf13/viper"
@@ -9,15 +11,184 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// LocaleFile is the file in `i18n.dir` which lists locale bundles
+const LocaleFile = "locales.yaml"
+
+const defaultLanguage = "en"
+
+// Locale is the metadata of a locale bundle. A language is added by putting its
+// bundle `<code>.yaml` into `i18n.dir` and listing it in `locales.yaml`.
+type Locale struct {
+	// Code is the name of the bundle and the language used by stores and caches
+	Code string `yaml:"code"`
+	// Aliases are language tags which are resolved to this locale, e.g. zh-Hant
+	Aliases []string `yaml:"aliases"`
+	// Fallback is the locale to look up messages which are not translated
+	Fallback string `yaml:"fallback"`
+	// OneSignal is the language code used by OneSignal for notifications
+	OneSignal string `yaml:"onesignal"`
+}
+
+// LocaleList is the content of `locales.yaml`
+type LocaleList struct {
+	Default string   `yaml:"default"`
+	Locales []Locale `yaml:"locales"`
+}
+
 var bundle *i18n.Bundle
 
+var (
+	locales        = LocaleList{Default: defaultLanguage}
+	localeByCode   = map[string]Locale{}
+	localeByLookup = map[string]string{}
+)
+
 func InitI18NBundle() {
-	bundle = i18n.NewBundle(language.English)
+	dir := viper.GetString("i18n.dir")
+
+	list, err := ReadLocaleList(path.Join(dir, LocaleFile))
+	if err != nil {
+		panic(err)
+	}
+	setLocales(list)
+
+	bundle = i18n.NewBundle(languageTag(locales.Default))
 	bundle.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
-	bundle.MustLoadMessageFile(path.Join(viper.GetString("i18n.dir"), "en.yaml"))
-	bundle.MustLoadMessageFile(path.Join(viper.GetString("i18n.dir"), "zh_tw.yaml"))
+	for _, l := range locales.Locales {
+		bundle.MustLoadMessageFile(path.Join(dir, l.Code+".yaml"))
+	}
+}
+
+// ReadLocaleList reads a locale list from a file
+func ReadLocaleList(file string) (LocaleList, error) {
+	var list LocaleList
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return list, err
+	}
+
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return list, err
+	}
+
+	if list.Default == "" {
+		list.Default = defaultLanguage
+	}
+
+	return list, nil
+}
+
+func setLocales(list LocaleList) {
+	locales = list
+	localeByCode = map[string]Locale{}
+	localeByLookup = map[string]string{}
+
+	for _, l := range list.Locales {
+		localeByCode[l.Code] = l
+		localeByLookup[normalizeLanguage(l.Code)] = l.Code
+	}
+	// aliases never shadow codes of other locales
+	for _, l := range list.Locales {
+		for _, alias := range l.Aliases {
+			if _, ok := localeByLookup[normalizeLanguage(alias)]; !ok {
+				localeByLookup[normalizeLanguage(alias)] = l.Code
+			}
+		}
+	}
+}
+
+// Languages returns codes of all supported locales
+func Languages() []string {
+	codes := make([]string, 0, len(locales.Locales))
+	for _, l := range locales.Locales {
+		codes = append(codes, l.Code)
+	}
+	return codes
+}
+
+// OneSignalLanguages returns a map from OneSignal language codes to locale codes
+// for locales which are used in notifications
+func OneSignalLanguages() map[string]string {
+	m := map[string]string{}
+	for _, l := range locales.Locales {
+		if l.OneSignal != "" {
+			m[l.OneSignal] = l.Code
+		}
+	}
+	return m
+}
+
+// ResolveLanguage returns the locale of a request. `lang` overrides the preferences in
+// `acceptLanguage`. A language tag is matched against codes and aliases of locales, and
+// its subtags are removed one by one until a locale is found, e.g. zh-Hant-TW, zh-Hant
+// and then zh. The default locale is returned if none of them is supported.
+func ResolveLanguage(lang, acceptLanguage string) string {
+	if code, ok := lookupLanguage(lang); ok {
+		return code
+	}
+
+	// tags are sorted by their quality values
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	for _, t := range tags {
+		if code, ok := lookupLanguage(t.String()); ok {
+			return code
+		}
+	}
+
+	return locales.Default
+}
+
+func lookupLanguage(lang string) (string, bool) {
+	subtags := strings.Split(normalizeLanguage(lang), "_")
+	for i := len(subtags); i > 0; i-- {
+		if code, ok := localeByLookup[strings.Join(subtags[:i], "_")]; ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// LanguageChain returns the locale of a language followed by its fallback locales,
+// e.g. zh_tw and then en. The default locale is always the last one.
+func LanguageChain(lang string) []string {
+	code := ResolveLanguage(lang, "")
+
+	chain := make([]string, 0)
+	visited := map[string]bool{}
+	for code != "" && !visited[code] {
+		chain = append(chain, code)
+		visited[code] = true
+		code = localeByCode[code].Fallback
+	}
+
+	if !visited[locales.Default] {
+		chain = append(chain, locales.Default)
+	}
+
+	return chain
 }
 
+// NewLocalizer returns a localizer which looks up messages along the fallback chain of a language
 func NewLocalizer(lang string) *i18n.Localizer {
-	return i18n.NewLocalizer(bundle, lang)
+	chain := LanguageChain(lang)
+	tags := make([]string, len(chain))
+	for i, code := range chain {
+		tags[i] = languageTag(code).String()
+	}
+	return i18n.NewLocalizer(bundle, tags...)
+}
+
+func normalizeLanguage(lang string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(lang)), "-", "_")
+}
+
+// languageTag returns the language tag of a locale code, e.g. zh-TW for zh_tw
+func languageTag(code string) language.Tag {
+	return language.Make(strings.ReplaceAll(code, "_", "-"))
+}
+
+// LanguageTag returns the BCP 47 tag of a locale code, e.g. zh-TW for zh_tw
+func LanguageTag(code string) string {
+	return languageTag(code).String()
 }
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func initTestI18NBundle() {
+	viper.Set("i18n.dir", "../i18n")
+	InitI18NBundle()
+}
+
+func TestResolveLanguage(t *testing.T) {
+	initTestI18NBundle()
+
+	testCases := []struct {
+		lang           string
+		acceptLanguage string
+		expected       string
+	}{
+		{"", "", "en"},
+		{"en", "", "en"},
+		{"zh_tw", "", "zh_tw"},
+		{"zh-TW", "", "zh_tw"},
+		{"zh-Hant-TW", "", "zh_tw"},
+		{"zh", "", "zh_tw"},
+		{"zh-Hans", "", "zh_cn"},
+		{"zh-Hans-CN", "", "zh_cn"},
+		{"zh-cn", "", "zh_cn"},
+		{"fr", "", "en"},
+		{"", "zh-Hant-TW,zh;q=0.9,en;q=0.8", "zh_tw"},
+		{"", "fr-FR,zh-CN;q=0.8,en;q=0.5", "zh_cn"},
+		{"", "en;q=0.5,zh-Hans;q=0.9", "zh_cn"},
+		{"", "fr-FR,de", "en"},
+		{"", "invalid;;", "en"},
+		{"en", "zh-TW", "en"},
+		{"fr", "zh-TW", "zh_tw"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, ResolveLanguage(tc.lang, tc.acceptLanguage), "%q %q", tc.lang, tc.acceptLanguage)
+	}
+}
+
+func TestLanguageChain(t *testing.T) {
+	initTestI18NBundle()
+
+	assert.Equal(t, []string{"en"}, LanguageChain("en"))
+	assert.Equal(t, []string{"zh_tw", "en"}, LanguageChain("zh-Hant-TW"))
+	assert.Equal(t, []string{"zh_cn", "zh_tw", "en"}, LanguageChain("zh-Hans"))
+	assert.Equal(t, []string{"en"}, LanguageChain("fr"))
+}
+
+func TestLanguageChainWithCircularFallback(t *testing.T) {
+	defer initTestI18NBundle()
+
+	setLocales(LocaleList{
+		Default: "en",
+		Locales: []Locale{
+			{Code: "en"},
+			{Code: "a", Fallback: "b"},
+			{Code: "b", Fallback: "a"},
+		},
+	})
+	assert.Equal(t, []string{"a", "b", "en"}, LanguageChain("a"))
+}
+
+func TestNewLocalizer(t *testing.T) {
+	initTestI18NBundle()
+
+	for lang, expected := range map[string]string{
+		"":           "Fever",
+		"fr":         "Fever",
+		"zh-Hant-TW": "發燒",
+		"zh_tw":      "發燒",
+		"zh-Hans":    "发烧",
+	} {
+		name, err := NewLocalizer(lang).Localize(&i18n.LocalizeConfig{MessageID: "symptoms.fever.name"})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, name, lang)
+	}
+}
+
+func TestNewLocalizerWithUntranslatedMessages(t *testing.T) {
+	defer initTestI18NBundle()
+
+	dir, err := ioutil.TempDir("", "i18n")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for file, content := range map[string]string{
+		LocaleFile:   "locales:\n- code: en\n- code: zh_tw\n  fallback: en\n- code: zh_cn\n  fallback: zh_tw\n",
+		"en.yaml":    "a: A\nb: B\nc: C\n",
+		"zh_tw.yaml": "a: 甲\nb: 乙\n",
+		"zh_cn.yaml": "a: 甲甲\n",
+	} {
+		assert.NoError(t, ioutil.WriteFile(path.Join(dir, file), []byte(content), 0644))
+	}
+
+	viper.Set("i18n.dir", dir)
+	InitI18NBundle()
+
+	localizer := NewLocalizer("zh-CN")
+	for id, expected := range map[string]string{"a": "甲甲", "b": "乙", "c": "C"} {
+		message, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: id})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, message, id)
+	}
+}
+
+func TestOneSignalLanguages(t *testing.T) {
+	initTestI18NBundle()
+
+	assert.Equal(t, map[string]string{
+		"en":      "en",
+		"zh-Hant": "zh_tw",
+		"zh-Hans": "zh_cn",
+	}, OneSignalLanguages())
+}
+
+func TestLanguageTag(t *testing.T) {
+	assert.Equal(t, "en", LanguageTag("en"))
+	assert.Equal(t, "zh-TW", LanguageTag("zh_tw"))
+	assert.Equal(t, "zh-CN", LanguageTag("zh_cn"))
+}